@@ -0,0 +1,325 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+const jsondata = `[
+	{"type":"function","name":"baz","inputs":[{"name":"x","type":"uint32"},{"name":"y","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"sam","inputs":[{"name":"a","type":"bytes"},{"name":"b","type":"bool"},{"name":"c","type":"uint256[]"}],"outputs":[]},
+	{"type":"function","name":"f","inputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint32[]"},{"name":"c","type":"bytes10"},{"name":"d","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"get","stateMutability":"view","inputs":[],"outputs":[{"name":"value","type":"int256"},{"name":"owner","type":"address"},{"name":"tags","type":"string[]"}]},
+	{"type":"function","name":"point","stateMutability":"pure","inputs":[{"name":"p","type":"tuple","internalType":"struct Geo.Point","components":[{"name":"x","type":"int64"},{"name":"label","type":"string"}]}],"outputs":[{"name":"","type":"tuple","components":[{"name":"x","type":"int64"},{"name":"label","type":"string"}]}]},
+	{"type":"function","name":"over","inputs":[{"name":"a","type":"uint8"}],"outputs":[]},
+	{"type":"function","name":"over","inputs":[{"name":"a","type":"uint16"}],"outputs":[]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"memo","type":"string","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"error","name":"Insufficient","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]},
+	{"type":"constructor","inputs":[{"name":"owner","type":"address"}]}
+]`
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(strings.Replace(strings.Replace(s, "\n", "", -1), "\t", "", -1))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func parseTestABI(t *testing.T) ABI {
+	abi, err := JSON(strings.NewReader(jsondata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abi
+}
+
+// The expected encodings are the examples of the Solidity ABI specification.
+func TestPackSpecExamples(t *testing.T) {
+	abi := parseTestABI(t)
+
+	packed, err := abi.Pack("baz", uint32(69), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := mustHex("cdcd77c0" +
+		"0000000000000000000000000000000000000000000000000000000000000045" +
+		"0000000000000000000000000000000000000000000000000000000000000001")
+	if !bytes.Equal(packed, exp) {
+		t.Errorf("baz: have %x, want %x", packed, exp)
+	}
+
+	packed, err = abi.Pack("sam", []byte("dave"), true, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = mustHex("a5643bf2" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6461766500000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000003")
+	if !bytes.Equal(packed, exp) {
+		t.Errorf("sam: have %x, want %x", packed, exp)
+	}
+
+	var b10 [10]byte
+	copy(b10[:], "1234567890")
+	packed, err = abi.Pack("f", big.NewInt(0x123), []uint32{0x456, 0x789}, b10, []byte("Hello, world!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = mustHex("8be65246" +
+		"0000000000000000000000000000000000000000000000000000000000000123" +
+		"0000000000000000000000000000000000000000000000000000000000000080" +
+		"3132333435363738393000000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000456" +
+		"0000000000000000000000000000000000000000000000000000000000000789" +
+		"000000000000000000000000000000000000000000000000000000000000000d" +
+		"48656c6c6f2c20776f726c642100000000000000000000000000000000000000")
+	if !bytes.Equal(packed, exp) {
+		t.Errorf("f: have %x, want %x", packed, exp)
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	abi := parseTestABI(t)
+	if _, err := abi.Pack("baz", uint32(1)); err == nil {
+		t.Error("expected argument count error")
+	}
+	if _, err := abi.Pack("baz", "x", true); err == nil {
+		t.Error("expected type error")
+	}
+	if _, err := abi.Pack("over", 256); err == nil {
+		t.Error("expected range error for uint8")
+	}
+	if _, err := abi.Pack("missing"); err == nil {
+		t.Error("expected unknown method error")
+	}
+}
+
+func TestOverloadedMethods(t *testing.T) {
+	abi := parseTestABI(t)
+	if abi.Methods["over"].Sig != "over(uint8)" || abi.Methods["over0"].Sig != "over(uint16)" {
+		t.Errorf("wrong overloads: %v / %v", abi.Methods["over"].Sig, abi.Methods["over0"].Sig)
+	}
+	m, err := abi.MethodById(abi.Methods["over0"].ID)
+	if err != nil || m.Name != "over0" {
+		t.Errorf("MethodById: %v %v", m, err)
+	}
+	if !abi.Methods["get"].IsConstant() {
+		t.Error("expected get to be constant")
+	}
+}
+
+func TestRoundTripOutputs(t *testing.T) {
+	abi := parseTestABI(t)
+	owner := common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+	data, err := abi.Methods["get"].Outputs.Pack(big.NewInt(-5), owner, []string{"a", "longer tag value spanning more than thirty-two bytes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := abi.Unpack("get", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(*big.Int).Int64() != -5 {
+		t.Errorf("value: have %v", values[0])
+	}
+	if values[1].(common.Address) != owner {
+		t.Errorf("owner: have %v", values[1])
+	}
+	if tags := values[2].([]string); len(tags) != 2 || tags[1] != "longer tag value spanning more than thirty-two bytes" {
+		t.Errorf("tags: have %v", values[2])
+	}
+
+	var out struct {
+		Value *big.Int
+		Owner common.Address
+		Tags  []string
+	}
+	if err := abi.UnpackIntoInterface(&out, "get", data); err != nil {
+		t.Fatal(err)
+	}
+	if out.Value.Int64() != -5 || out.Owner != owner || len(out.Tags) != 2 {
+		t.Errorf("struct unpack: %+v", out)
+	}
+}
+
+func TestTuples(t *testing.T) {
+	abi := parseTestABI(t)
+	method := abi.Methods["point"]
+	if method.Sig != "point((int64,string))" {
+		t.Fatalf("wrong signature %q", method.Sig)
+	}
+	if name := method.Inputs[0].Type.TupleRawName; name != "GeoPoint" {
+		t.Errorf("wrong tuple name %q", name)
+	}
+	type point struct {
+		X     int64
+		Label string
+	}
+	in := point{X: -42, Label: "origin"}
+	packed, err := abi.Pack("point", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := method.Inputs.Unpack(packed[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := *ConvertType(values[0], new(point)).(*point)
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("tuple round trip: have %+v, want %+v", out, in)
+	}
+	// Tuples can also be given as a list of values.
+	packed2, err := abi.Pack("point", []interface{}{int64(-42), "origin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed, packed2) {
+		t.Error("struct and list encodings differ")
+	}
+}
+
+func TestStaticArrays(t *testing.T) {
+	typ, _ := NewType("uint8[2][3]", "", nil)
+	args := Arguments{{Type: typ}}
+	in := [3][2]uint8{{1, 2}, {3, 4}, {5, 6}}
+	packed, err := args.Pack(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packed) != 6*32 {
+		t.Fatalf("static array should be encoded inline, got %d bytes", len(packed))
+	}
+	values, err := args.Unpack(packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values[0], in) {
+		t.Errorf("have %v, want %v", values[0], in)
+	}
+	if _, err := args.Pack([2][2]uint8{}); err == nil {
+		t.Error("expected length mismatch error")
+	}
+}
+
+func TestUnpackMalformed(t *testing.T) {
+	typ, _ := NewType("string", "", nil)
+	args := Arguments{{Type: typ}}
+	bad := mustHex("00000000000000000000000000000000000000000000000000000000000000ff")
+	if _, err := args.Unpack(bad); err == nil {
+		t.Error("expected error for out of bounds offset")
+	}
+	typ, _ = NewType("bool", "", nil)
+	if _, err := (Arguments{{Type: typ}}).Unpack(mustHex("0000000000000000000000000000000000000000000000000000000000000002")); err == nil {
+		t.Error("expected error for malformed bool")
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	abi := parseTestABI(t)
+	event := abi.Events["Transfer"]
+	if event.Sig != "Transfer(address,address,string,uint256)" {
+		t.Fatalf("wrong event signature %q", event.Sig)
+	}
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	topics, err := MakeTopics([]interface{}{from}, []interface{}{to}, []interface{}{"hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := event.Inputs.NonIndexed().Pack(big.NewInt(1000))
+	log := &types.Log{
+		Topics: []common.Hash{event.ID, topics[0][0], topics[1][0], topics[2][0]},
+		Data:   data,
+	}
+	ev, values, err := abi.DecodeLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Name != "Transfer" {
+		t.Errorf("wrong event %s", ev.Name)
+	}
+	if values["from"] != from || values["to"] != to {
+		t.Errorf("wrong addresses: %v", values)
+	}
+	if values["memo"] != crypto.Keccak256Hash([]byte("hello")) {
+		t.Errorf("wrong memo hash: %v", values["memo"])
+	}
+	if values["value"].(*big.Int).Int64() != 1000 {
+		t.Errorf("wrong value: %v", values["value"])
+	}
+
+	var out struct {
+		From  common.Address
+		To    common.Address
+		Value *big.Int
+	}
+	if err := abi.UnpackLog(&out, "Transfer", log); err != nil {
+		t.Fatal(err)
+	}
+	if out.From != from || out.Value.Int64() != 1000 {
+		t.Errorf("struct log unpack: %+v", out)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	abi := parseTestABI(t)
+	e := abi.Errors["Insufficient"]
+	args, _ := e.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	name, values, err := abi.DecodeError(append(e.ID[:], args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Insufficient" || values[0].(*big.Int).Int64() != 1 || values[1].(*big.Int).Int64() != 2 {
+		t.Errorf("wrong error: %s %v", name, values)
+	}
+
+	// Error(string) with reason "Not enough Ether provided."
+	revert := mustHex("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001a" +
+		"4e6f7420656e6f7567682045746865722070726f76696465642e000000000000")
+	reason, err := UnpackRevert(revert)
+	if err != nil || reason != "Not enough Ether provided." {
+		t.Errorf("revert reason: %q %v", reason, err)
+	}
+	if _, _, err := abi.DecodeError([]byte{1, 2, 3, 4}); err == nil {
+		t.Error("expected error for unknown selector")
+	}
+}
+
+func TestFromDefinition(t *testing.T) {
+	var definition interface{}
+	if err := json.Unmarshal([]byte(jsondata), &definition); err != nil {
+		t.Fatal(err)
+	}
+	abi, err := FromDefinition(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abi.Methods) != 7 || len(abi.Events) != 1 || len(abi.Errors) != 1 {
+		t.Errorf("wrong abi contents: %d methods, %d events, %d errors", len(abi.Methods), len(abi.Events), len(abi.Errors))
+	}
+	packed, err := abi.Pack("", common.Address{1})
+	if err != nil || len(packed) != 32 {
+		t.Errorf("constructor pack: %x %v", packed, err)
+	}
+}
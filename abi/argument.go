@@ -0,0 +1,155 @@
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Argument holds the name of the argument and the corresponding type.
+// Types are used when packing and testing arguments.
+type Argument struct {
+	Name    string
+	Type    Type
+	Indexed bool // indexed is only used by events
+}
+
+// Arguments is an ordered list of arguments.
+type Arguments []Argument
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (argument *Argument) UnmarshalJSON(data []byte) error {
+	var arg ArgumentMarshaling
+	if err := json.Unmarshal(data, &arg); err != nil {
+		return fmt.Errorf("abi: failed to unmarshal json for argument: %v", err)
+	}
+	typ, err := NewType(arg.Type, arg.InternalType, arg.Components)
+	if err != nil {
+		return err
+	}
+	argument.Type = typ
+	argument.Name = arg.Name
+	argument.Indexed = arg.Indexed
+	return nil
+}
+
+// NonIndexed returns the arguments with indexed arguments filtered out.
+func (arguments Arguments) NonIndexed() Arguments {
+	var ret []Argument
+	for _, arg := range arguments {
+		if !arg.Indexed {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+// isTuple returns true for non-atomic constructs, like (uint,uint) or uint[].
+func (arguments Arguments) isTuple() bool {
+	return len(arguments) > 1
+}
+
+// Pack performs the operation Go format -> Hexdata.
+func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
+	if len(args) != len(arguments) {
+		return nil, fmt.Errorf("abi: argument count mismatch: got %d for %d", len(args), len(arguments))
+	}
+	types := make([]Type, len(arguments))
+	values := make([]reflect.Value, len(arguments))
+	for i, arg := range arguments {
+		types[i] = arg.Type
+		values[i] = reflect.ValueOf(args[i])
+	}
+	packed, err := packSequence(types, values)
+	if err != nil {
+		return nil, fmt.Errorf("abi: packing arguments: %v", err)
+	}
+	return packed, nil
+}
+
+// Unpack performs the operation hexdata -> Go format, returning the values in
+// argument order. Indexed arguments are skipped.
+func (arguments Arguments) Unpack(data []byte) ([]interface{}, error) {
+	if len(data) == 0 {
+		if len(arguments.NonIndexed()) != 0 {
+			return nil, errors.New("abi: attempting to unmarshall an empty string while arguments are expected")
+		}
+		return make([]interface{}, 0), nil
+	}
+	return arguments.UnpackValues(data)
+}
+
+// UnpackIntoMap performs the operation hexdata -> mapping of argument name to
+// argument value.
+func (arguments Arguments) UnpackIntoMap(v map[string]interface{}, data []byte) error {
+	if v == nil {
+		return errors.New("abi: cannot unpack into a nil map")
+	}
+	values, err := arguments.Unpack(data)
+	if err != nil {
+		return err
+	}
+	for i, arg := range arguments.NonIndexed() {
+		v[arg.Name] = values[i]
+	}
+	return nil
+}
+
+// UnpackValues can be used to unpack ABI-encoded hexdata according to the ABI
+// specification, without supplying a struct to unpack into. Instead, this
+// method returns a list containing the values.
+func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
+	nonIndexed := arguments.NonIndexed()
+	retval := make([]interface{}, 0, len(nonIndexed))
+	offset := 0
+	for _, arg := range nonIndexed {
+		v, err := toGoType(offset, arg.Type, data)
+		if err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(arg.Type)
+		retval = append(retval, v)
+	}
+	return retval, nil
+}
+
+// Copy performs the operation values -> go format, storing the unpacked
+// values into v, which must be a pointer to a struct (for multiple values,
+// fields matched by name) or to a variable of the single value's type.
+func (arguments Arguments) Copy(v interface{}, values []interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
+	}
+	if len(values) == 0 {
+		if len(arguments.NonIndexed()) != 0 {
+			return errors.New("abi: attempting to copy no values while arguments are expected")
+		}
+		return nil
+	}
+	dst := rv.Elem()
+	if !arguments.isTuple() && dst.Kind() != reflect.Struct {
+		return set(dst, reflect.ValueOf(values[0]))
+	}
+	if !arguments.isTuple() && dst.Kind() == reflect.Struct && reflect.TypeOf(values[0]).Kind() == reflect.Struct {
+		return set(dst, reflect.ValueOf(values[0]))
+	}
+	if dst.Kind() != reflect.Struct {
+		return fmt.Errorf("abi: cannot unmarshal tuple into %v", dst.Type())
+	}
+	for i, arg := range arguments.NonIndexed() {
+		name := ToCamelCase(arg.Name)
+		if name == "" {
+			return fmt.Errorf("abi: purely underscored output cannot unpack to struct")
+		}
+		field := dst.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("abi: field %s can't be found in the given value", name)
+		}
+		if err := set(field, reflect.ValueOf(values[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
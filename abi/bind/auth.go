@@ -0,0 +1,33 @@
+package bind
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// ErrNotAuthorized is returned when an account is not properly unlocked.
+var ErrNotAuthorized = errors.New("not authorized to sign this account")
+
+// NewKeyedTransactor is a utility method to easily create a transaction signer
+// from a single private key, signing with the Pangu signer of the given chain.
+func NewKeyedTransactor(key *ecdsa.PrivateKey, chainID *big.Int) (*TransactOpts, error) {
+	if chainID == nil {
+		return nil, errors.New("no chain id specified")
+	}
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.NewPanguSigner(chainID)
+	return &TransactOpts{
+		From: keyAddr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, ErrNotAuthorized
+			}
+			return types.SignTx(tx, signer, key)
+		},
+	}, nil
+}
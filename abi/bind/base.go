@@ -0,0 +1,362 @@
+// Package bind generates type-safe Go bindings for Solidity contracts and
+// provides the runtime the generated code is built on: deploying, calling
+// and transacting with contracts on the mainchain or on a subchain, and
+// filtering their event logs.
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/abi"
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/params"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// SignerFn is a signer function callback when a contract requires a method to
+// sign the transaction before submission.
+type SignerFn func(common.Address, *types.Transaction) (*types.Transaction, error)
+
+// CallOpts is the collection of options to fine tune a contract call request.
+type CallOpts struct {
+	Pending     bool            // Whether to operate on the pending state or the last known one
+	From        common.Address  // Optional the sender address, otherwise the first account is used
+	BlockNumber *big.Int        // Optional the block number on which the call should be performed
+	Context     context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// TransactOpts is the collection of authorization data required to create a
+// valid MOAC transaction.
+type TransactOpts struct {
+	From   common.Address // MOAC account to send the transaction from
+	Nonce  *big.Int       // Nonce to use for the transaction execution (nil = use pending state)
+	Signer SignerFn       // Method to use for signing the transaction (mandatory)
+
+	Value    *big.Int // Funds to transfer along the transaction (nil = 0 = no funds)
+	GasPrice *big.Int // Gas price to use for the transaction execution (nil = gas price oracle)
+	GasLimit *big.Int // Gas limit to set for the transaction execution (nil = estimate)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	Start uint64  // Start of the queried range
+	End   *uint64 // End of the range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// Subchain identifies the subchain a dapp contract lives on. Transactions to
+// a subchain dapp are addressed to the subchain base contract on the
+// mainchain and relayed by the vnode proxy given as Via.
+type Subchain struct {
+	Address common.Address // Subchain base contract on the mainchain
+	Via     common.Address // Vnode proxy beneficiary relaying the transactions
+}
+
+// BoundContract is the base wrapper object that reflects a contract on the
+// MOAC network. It contains a collection of methods that are used by the
+// higher level contract bindings to operate.
+type BoundContract struct {
+	address    common.Address     // Deployment address of the contract on the MOAC blockchain
+	subchain   *Subchain          // Subchain hosting the contract, nil for mainchain contracts
+	abi        abi.ABI            // Reflect based ABI to access the correct MOAC methods
+	caller     ContractCaller     // Read interface to interact with the blockchain
+	transactor ContractTransactor // Write interface to interact with the blockchain
+	filterer   ContractFilterer   // Event filtering to interact with the blockchain
+}
+
+// NewBoundContract creates a low level contract interface through which calls
+// and transactions may be made through.
+func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
+	return &BoundContract{
+		address:    address,
+		abi:        abi,
+		caller:     caller,
+		transactor: transactor,
+		filterer:   filterer,
+	}
+}
+
+// NewSubchainBoundContract creates a low level contract interface to a dapp
+// deployed at address on the given subchain.
+func NewSubchainBoundContract(subchain Subchain, address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
+	c := NewBoundContract(address, abi, caller, transactor, filterer)
+	c.subchain = &subchain
+	return c
+}
+
+// DeployContract deploys a contract onto the MOAC blockchain and binds the
+// deployment address with a Go wrapper.
+func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
+
+	input, err := c.abi.Pack("", params...)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	tx, err := c.transact(opts, nil, append(common.CopyBytes(bytecode), input...), 0, nil)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	c.address = crypto.CreateAddress(opts.From, tx.Nonce())
+	return c.address, tx, c, nil
+}
+
+// DeploySubchainContract deploys a dapp onto the given subchain. The dapp
+// address is assigned by the subchain and is not known until the transaction
+// has been processed, so no bound contract is returned; bind one with
+// NewSubchainBoundContract once the address is available.
+func DeploySubchainContract(opts *TransactOpts, subchain Subchain, abi abi.ABI, bytecode []byte, backend ContractTransactor, params ...interface{}) (*types.Transaction, error) {
+	input, err := abi.Pack("", params...)
+	if err != nil {
+		return nil, err
+	}
+	c := &BoundContract{subchain: &subchain, abi: abi, transactor: backend}
+	return c.transact(opts, &subchain.Address, append(common.CopyBytes(bytecode), input...), scs.DappCreate, &subchain.Via)
+}
+
+// Address returns the address the contract is bound to.
+func (c *BoundContract) Address() common.Address {
+	return c.address
+}
+
+// Subchain returns the subchain hosting the contract, or nil if the contract
+// lives on the mainchain.
+func (c *BoundContract) Subchain() *Subchain {
+	return c.subchain
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method string, params ...interface{}) error {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	if results == nil {
+		results = new([]interface{})
+	}
+	// Pack the input, call and unpack the results
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	msg := c.callMsg(opts.From, input)
+	ctx := ensureContext(opts.Context)
+
+	var output []byte
+	if opts.Pending {
+		pb, ok := c.caller.(PendingContractCaller)
+		if !ok {
+			return ErrNoPendingState
+		}
+		output, err = pb.PendingCallContract(ctx, msg)
+		if err == nil && len(output) == 0 && c.subchain == nil {
+			// Make sure we have a contract to operate on, and bail out otherwise.
+			if code, err := pb.PendingCodeAt(ctx, c.address); err != nil {
+				return err
+			} else if len(code) == 0 {
+				return ErrNoCode
+			}
+		}
+	} else {
+		output, err = c.caller.CallContract(ctx, msg, opts.BlockNumber)
+		if err == nil && len(output) == 0 && c.subchain == nil {
+			// Make sure we have a contract to operate on, and bail out otherwise.
+			if code, err := c.caller.CodeAt(ctx, c.address, opts.BlockNumber); err != nil {
+				return err
+			} else if len(code) == 0 {
+				return ErrNoCode
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(*results) == 0 {
+		res, err := c.abi.Unpack(method, output)
+		*results = res
+		return err
+	}
+	res := *results
+	return c.abi.UnpackIntoInterface(res[0], method, output)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	// Pack up the parameters and invoke the contract
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.RawTransact(opts, input)
+}
+
+// RawTransact initiates a transaction with the given raw calldata as input.
+// It's usually used to initiate transactions for invoking **Fallback** function.
+func (c *BoundContract) RawTransact(opts *TransactOpts, calldata []byte) (*types.Transaction, error) {
+	if c.subchain != nil {
+		data := append(c.address.Bytes(), calldata...)
+		return c.transact(opts, &c.subchain.Address, data, scs.DirectCall, &c.subchain.Via)
+	}
+	return c.transact(opts, &c.address, calldata, 0, nil)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (c *BoundContract) Transfer(opts *TransactOpts) (*types.Transaction, error) {
+	if c.subchain != nil {
+		return c.transact(opts, &c.subchain.Address, c.address.Bytes(), scs.DirectTransfer, &c.subchain.Via)
+	}
+	return c.transact(opts, &c.address, nil, 0, nil)
+}
+
+// callMsg assembles the call message for input, wrapping it for the subchain
+// if the contract lives on one.
+func (c *BoundContract) callMsg(from common.Address, input []byte) CallMsg {
+	if c.subchain != nil {
+		to := c.subchain.Address
+		via := c.subchain.Via
+		return CallMsg{
+			From:         from,
+			To:           &to,
+			Data:         append(c.address.Bytes(), input...),
+			ShardingFlag: scs.DirectCall,
+			Via:          &via,
+		}
+	}
+	to := c.address
+	return CallMsg{From: from, To: &to, Data: input}
+}
+
+// transact executes an actual transaction invocation, first deriving any missing
+// authorization fields, and then scheduling the transaction for execution.
+func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, input []byte, shardingFlag uint64, via *common.Address) (*types.Transaction, error) {
+	var err error
+
+	// Ensure a valid value field and resolve the account nonce
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	var nonce uint64
+	if opts.Nonce == nil {
+		nonce, err = c.transactor.PendingNonceAt(ensureContext(opts.Context), opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve account nonce: %v", err)
+		}
+	} else {
+		nonce = opts.Nonce.Uint64()
+	}
+	// Figure out the gas allowance and gas price values
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = c.transactor.SuggestGasPrice(ensureContext(opts.Context))
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	}
+	gasLimit := opts.GasLimit
+	if gasLimit == nil {
+		if shardingFlag != 0 {
+			// Subchain transactions are not executed by the mainchain, so
+			// they cannot be estimated there.
+			gasLimit = big.NewInt(params.DirectCallGasLimit)
+		} else {
+			// Gas estimation cannot succeed without code for method invocations
+			if contract != nil {
+				if code, err := c.transactor.PendingCodeAt(ensureContext(opts.Context), c.address); err != nil {
+					return nil, err
+				} else if len(code) == 0 {
+					return nil, ErrNoCode
+				}
+			}
+			// If the contract surely has code (or code is not needed), estimate the transaction
+			msg := CallMsg{From: opts.From, To: contract, GasPrice: gasPrice, Value: value, Data: input}
+			gasLimit, err = c.transactor.EstimateGas(ensureContext(opts.Context), msg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+			}
+		}
+	}
+	// Create the transaction, sign it and schedule it for execution
+	var rawTx *types.Transaction
+	if contract == nil {
+		rawTx = types.NewContractCreation(nonce, value, gasLimit, gasPrice, shardingFlag, via, input)
+	} else {
+		rawTx = types.NewTransaction(nonce, *contract, value, gasLimit, gasPrice, shardingFlag, via, input)
+	}
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	signedTx, err := opts.Signer(opts.From, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// FilterLogs filters contract logs for past blocks, returning the logs of
+// the named event that match the given indexed argument query. Subchain
+// dapps have no mainchain logs, ErrSubchainLogs is returned for them.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) ([]types.Log, error) {
+	if c.subchain != nil {
+		return nil, ErrSubchainLogs
+	}
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("event '%s' not found", name)
+	}
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{event.ID}}, query...)
+
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		return nil, err
+	}
+	config := FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+		FromBlock: new(big.Int).SetUint64(opts.Start),
+	}
+	if opts.End != nil {
+		config.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	return c.filterer.FilterLogs(ensureContext(opts.Context), config)
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure.
+func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	return c.abi.UnpackLog(out, event, &log)
+}
+
+// UnpackLogIntoMap unpacks a retrieved log into the provided map.
+func (c *BoundContract) UnpackLogIntoMap(out map[string]interface{}, event string, log types.Log) error {
+	return c.abi.UnpackLogIntoMap(out, event, &log)
+}
+
+// ensureContext is a helper method to ensure a context is not nil, even if the
+// user specified it as such.
+func ensureContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.TODO()
+	}
+	return ctx
+}
@@ -0,0 +1,262 @@
+package bind
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/abi"
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/params"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// mockBackend records the calls and transactions it receives and answers
+// with canned data.
+type mockBackend struct {
+	code    []byte
+	output  []byte
+	logs    []types.Log
+	nonce   uint64
+	calls   []CallMsg
+	sent    []*types.Transaction
+	queries []FilterQuery
+}
+
+func (b *mockBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return b.code, nil
+}
+
+func (b *mockBackend) CallContract(ctx context.Context, call CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.calls = append(b.calls, call)
+	return b.output, nil
+}
+
+func (b *mockBackend) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return b.code, nil
+}
+
+func (b *mockBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.nonce, nil
+}
+
+func (b *mockBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(20000000000), nil
+}
+
+func (b *mockBackend) EstimateGas(ctx context.Context, call CallMsg) (*big.Int, error) {
+	b.calls = append(b.calls, call)
+	return big.NewInt(21000), nil
+}
+
+func (b *mockBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *mockBackend) FilterLogs(ctx context.Context, query FilterQuery) ([]types.Log, error) {
+	b.queries = append(b.queries, query)
+	return b.logs, nil
+}
+
+const counterABI = `[
+	{"type":"function","name":"get","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"add","inputs":[{"name":"delta","type":"uint256"}],"outputs":[]},
+	{"type":"event","name":"Added","inputs":[{"name":"by","type":"address","indexed":true},{"name":"delta","type":"uint256","indexed":false}]}
+]`
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testChainID = big.NewInt(101)
+	dappAddr    = common.HexToAddress("0x00000000000000000000000000000000000da990")
+	testChain   = Subchain{
+		Address: common.HexToAddress("0x0000000000000000000000000000000000005c5c"),
+		Via:     common.HexToAddress("0x000000000000000000000000000000000000f1a0"),
+	}
+)
+
+func newTestContract(t *testing.T, subchain *Subchain) (*BoundContract, *mockBackend, *TransactOpts) {
+	parsed, err := abi.JSON(strings.NewReader(counterABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &mockBackend{code: []byte{0x60}, nonce: 7}
+	opts, err := NewKeyedTransactor(testKey, testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subchain != nil {
+		return NewSubchainBoundContract(*subchain, dappAddr, parsed, backend, backend, backend), backend, opts
+	}
+	return NewBoundContract(dappAddr, parsed, backend, backend, backend), backend, opts
+}
+
+func checkSender(t *testing.T, tx *types.Transaction, want common.Address) {
+	from, err := types.Sender(types.NewPanguSigner(testChainID), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != want {
+		t.Errorf("sender mismatch: have %x, want %x", from, want)
+	}
+}
+
+func TestTransactMainchain(t *testing.T) {
+	c, backend, opts := newTestContract(t, nil)
+	tx, err := c.Transact(opts, "add", big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.sent) != 1 || backend.sent[0] != tx {
+		t.Fatal("transaction not sent")
+	}
+	input, _ := c.abi.Pack("add", big.NewInt(3))
+	if *tx.To() != dappAddr || !bytes.Equal(tx.Data(), input) {
+		t.Errorf("wrong recipient or data: %x %x", tx.To(), tx.Data())
+	}
+	if tx.ShardingFlag() != 0 || tx.Via() != nil {
+		t.Errorf("mainchain tx has sharding flag %d, via %v", tx.ShardingFlag(), tx.Via())
+	}
+	if tx.Nonce() != 7 || tx.GasLimit().Int64() != 21000 {
+		t.Errorf("wrong nonce %d or gas %v", tx.Nonce(), tx.GasLimit())
+	}
+	checkSender(t, tx, opts.From)
+
+	backend.code = nil
+	if _, err := c.Transact(opts, "add", big.NewInt(3)); err != ErrNoCode {
+		t.Errorf("expected ErrNoCode, got %v", err)
+	}
+}
+
+func TestTransactSubchain(t *testing.T) {
+	c, backend, opts := newTestContract(t, &testChain)
+	tx, err := c.Transact(opts, "add", big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, _ := c.abi.Pack("add", big.NewInt(3))
+	if *tx.To() != testChain.Address {
+		t.Errorf("subchain tx sent to %x, want %x", tx.To(), testChain.Address)
+	}
+	if !bytes.Equal(tx.Data(), append(dappAddr.Bytes(), input...)) {
+		t.Errorf("subchain tx data not prefixed with dapp address: %x", tx.Data())
+	}
+	if tx.ShardingFlag() != scs.DirectCall || tx.Via() == nil || *tx.Via() != testChain.Via {
+		t.Errorf("wrong sharding flag %d or via %v", tx.ShardingFlag(), tx.Via())
+	}
+	if tx.GasLimit().Int64() != params.DirectCallGasLimit {
+		t.Errorf("wrong gas limit %v", tx.GasLimit())
+	}
+	if len(backend.calls) != 0 {
+		t.Error("subchain transaction should not be estimated on the mainchain")
+	}
+	checkSender(t, tx, opts.From)
+
+	tx, err = c.Transfer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.ShardingFlag() != scs.DirectTransfer || !bytes.Equal(tx.Data(), dappAddr.Bytes()) {
+		t.Errorf("wrong subchain transfer: flag %d, data %x", tx.ShardingFlag(), tx.Data())
+	}
+}
+
+func TestDeploy(t *testing.T) {
+	parsed, _ := abi.JSON(strings.NewReader(counterABI))
+	backend := &mockBackend{nonce: 2}
+	opts, _ := NewKeyedTransactor(testKey, testChainID)
+
+	addr, tx, _, err := DeployContract(opts, parsed, []byte{0x60, 0x60}, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.To() != nil || addr != crypto.CreateAddress(opts.From, 2) {
+		t.Errorf("wrong deployment: to %v, address %x", tx.To(), addr)
+	}
+
+	tx, err = DeploySubchainContract(opts, testChain, parsed, []byte{0x60, 0x60}, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *tx.To() != testChain.Address || tx.ShardingFlag() != scs.DappCreate || *tx.Via() != testChain.Via {
+		t.Errorf("wrong subchain deployment: to %x, flag %d", tx.To(), tx.ShardingFlag())
+	}
+	if !bytes.Equal(tx.Data(), []byte{0x60, 0x60}) {
+		t.Errorf("wrong subchain deployment data %x", tx.Data())
+	}
+}
+
+func TestCall(t *testing.T) {
+	for _, subchain := range []*Subchain{nil, &testChain} {
+		c, backend, _ := newTestContract(t, subchain)
+		backend.output = common.LeftPadBytes([]byte{42}, 32)
+
+		var out []interface{}
+		if err := c.Call(nil, &out, "get"); err != nil {
+			t.Fatal(err)
+		}
+		if out[0].(*big.Int).Int64() != 42 {
+			t.Errorf("wrong result %v", out[0])
+		}
+		msg := backend.calls[0]
+		if subchain == nil {
+			if *msg.To != dappAddr || msg.ShardingFlag != 0 || msg.Via != nil {
+				t.Errorf("wrong mainchain call %+v", msg)
+			}
+		} else if *msg.To != testChain.Address || msg.ShardingFlag != scs.DirectCall || *msg.Via != testChain.Via ||
+			!bytes.HasPrefix(msg.Data, dappAddr.Bytes()) {
+			t.Errorf("wrong subchain call %+v", msg)
+		}
+		if err := c.Call(&CallOpts{Pending: true}, &out, "get"); err != ErrNoPendingState {
+			t.Errorf("expected ErrNoPendingState, got %v", err)
+		}
+	}
+}
+
+func TestFilterLogsSubchain(t *testing.T) {
+	c, backend, _ := newTestContract(t, &testChain)
+	if _, err := c.FilterLogs(nil, "Added"); err != ErrSubchainLogs {
+		t.Fatalf("expected %v, got %v", ErrSubchainLogs, err)
+	}
+	if len(backend.queries) != 0 {
+		t.Errorf("mainchain queried for subchain logs: %v", backend.queries)
+	}
+}
+
+func TestFilterLogs(t *testing.T) {
+	c, backend, _ := newTestContract(t, nil)
+	by := common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	event := c.abi.Events["Added"]
+	data, _ := event.Inputs.NonIndexed().Pack(big.NewInt(5))
+	backend.logs = []types.Log{{
+		Address: dappAddr,
+		Topics:  []common.Hash{event.ID, common.BytesToHash(by.Bytes())},
+		Data:    data,
+	}}
+	end := uint64(100)
+	logs, err := c.FilterLogs(&FilterOpts{Start: 10, End: &end}, "Added", []interface{}{by})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := backend.queries[0]
+	if query.FromBlock.Uint64() != 10 || query.ToBlock.Uint64() != 100 || query.Addresses[0] != dappAddr {
+		t.Errorf("wrong filter range or address: %+v", query)
+	}
+	if len(query.Topics) != 2 || query.Topics[0][0] != event.ID || query.Topics[1][0] != common.BytesToHash(by.Bytes()) {
+		t.Errorf("wrong topics: %v", query.Topics)
+	}
+	var added struct {
+		By    common.Address
+		Delta *big.Int
+	}
+	if err := c.UnpackLog(&added, "Added", logs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if added.By != by || added.Delta.Int64() != 5 {
+		t.Errorf("wrong event %+v", added)
+	}
+}
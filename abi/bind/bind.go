@@ -0,0 +1,369 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/MOACChain/MoacLib/abi"
+	"github.com/MOACChain/MoacLib/common/compiler"
+)
+
+// Bind generates a Go wrapper around a contract ABI. The wrapper exposes
+// deployment, constant calls, transactions and event filtering for each of
+// the given contracts, on the mainchain as well as on subchains. Bytecodes
+// may be empty, in which case no deploy methods are generated.
+func Bind(types []string, abis []string, bytecodes []string, pkg string) (string, error) {
+	if len(types) != len(abis) || len(types) != len(bytecodes) {
+		return "", errors.New("bind: mismatching number of types, ABIs and bytecodes")
+	}
+	data := &tmplData{
+		Package: pkg,
+		structs: make(map[string]*tmplStruct),
+		names:   make(map[string]bool),
+	}
+	for i, typ := range types {
+		if err := data.addContract(typ, abis[i], bytecodes[i]); err != nil {
+			return "", err
+		}
+	}
+	for _, s := range data.structs {
+		data.Structs = append(data.Structs, s)
+	}
+	sort.Slice(data.Structs, func(i, j int) bool { return data.Structs[i].Name < data.Structs[j].Name })
+
+	buffer := new(bytes.Buffer)
+	tmpl := template.Must(template.New("").Parse(tmplSource))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// BindContracts generates Go wrappers for the contracts returned by the
+// compiler package. Contract names of the form "source:Name" are bound under
+// Name.
+func BindContracts(contracts map[string]*compiler.Contract, pkg string) (string, error) {
+	var names []string
+	for name := range contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var types, abis, bytecodes []string
+	for _, name := range names {
+		contract := contracts[name]
+		definition, ok := contract.Info.AbiDefinition.(string)
+		if !ok {
+			blob, err := json.Marshal(contract.Info.AbiDefinition)
+			if err != nil {
+				return "", fmt.Errorf("bind: invalid ABI of %s: %v", name, err)
+			}
+			definition = string(blob)
+		}
+		types = append(types, name[strings.LastIndex(name, ":")+1:])
+		abis = append(abis, definition)
+		bytecodes = append(bytecodes, contract.Code)
+	}
+	return Bind(types, abis, bytecodes, pkg)
+}
+
+// tmplData is the data structure required to fill the binding template.
+type tmplData struct {
+	Package   string
+	Contracts []*tmplContract
+	Structs   []*tmplStruct
+
+	structs map[string]*tmplStruct // tuple structs keyed by canonical type
+	names   map[string]bool        // struct names already in use
+}
+
+// tmplContract contains the data needed to generate an individual contract binding.
+type tmplContract struct {
+	Type        string
+	InputABI    string
+	InputBin    string
+	Constructor tmplMethod
+	Calls       []*tmplMethod
+	Transacts   []*tmplMethod
+	Events      []*tmplEvent
+	Fallback    bool
+	Receive     bool
+}
+
+// tmplMethod is a wrapper around an abi.Method that contains a few preprocessed
+// and cached data fields.
+type tmplMethod struct {
+	Original   abi.Method
+	Normalized string
+	Inputs     []tmplArg
+	Outputs    []tmplArg
+	Structured bool // Whether the returns should be accumulated into a struct
+}
+
+// tmplEvent is a wrapper around an abi.Event that contains a few preprocessed
+// and cached data fields.
+type tmplEvent struct {
+	Original   abi.Event
+	Normalized string
+	Fields     []tmplArg
+}
+
+// tmplArg is a single method argument, event field or struct field.
+type tmplArg struct {
+	Name    string // Go parameter name, lower camel case
+	Field   string // Go field name, upper camel case
+	Type    string // Go type of the value
+	Indexed bool
+	Hashed  bool // Indexed dynamic type, only available as its hash
+	Filter  string
+}
+
+// tmplStruct is a Go struct generated for a Solidity tuple.
+type tmplStruct struct {
+	Name   string
+	Fields []tmplArg
+}
+
+func (data *tmplData) addContract(typ, abiJSON, bytecode string) error {
+	evmABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return fmt.Errorf("bind: invalid ABI of %s: %v", typ, err)
+	}
+	// Strip any whitespace from the JSON ABI
+	stripped := new(bytes.Buffer)
+	if err := json.Compact(stripped, []byte(abiJSON)); err != nil {
+		return err
+	}
+	contract := &tmplContract{
+		Type:     capitalise(typ),
+		InputABI: stripped.String(),
+		InputBin: strings.TrimPrefix(strings.TrimSpace(bytecode), "0x"),
+		Fallback: evmABI.HasFallback(),
+		Receive:  evmABI.HasReceive(),
+	}
+	if contract.Constructor.Inputs, err = data.bindArgs(evmABI.Constructor.Inputs); err != nil {
+		return err
+	}
+	var methods []string
+	for name := range evmABI.Methods {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+	for _, name := range methods {
+		original := evmABI.Methods[name]
+		method := &tmplMethod{Original: original, Normalized: capitalise(original.Name)}
+		if method.Inputs, err = data.bindArgs(original.Inputs); err != nil {
+			return err
+		}
+		if method.Outputs, err = data.bindArgs(original.Outputs); err != nil {
+			return err
+		}
+		if original.IsConstant() {
+			method.Structured = len(original.Outputs) > 1
+			for _, out := range original.Outputs {
+				if out.Name == "" || abi.ToCamelCase(out.Name) == "" {
+					method.Structured = false
+				}
+			}
+			contract.Calls = append(contract.Calls, method)
+		} else {
+			contract.Transacts = append(contract.Transacts, method)
+		}
+	}
+	var events []string
+	for name := range evmABI.Events {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+	for _, name := range events {
+		original := evmABI.Events[name]
+		event := &tmplEvent{Original: original, Normalized: capitalise(original.Name)}
+		if event.Fields, err = data.bindArgs(original.Inputs); err != nil {
+			return err
+		}
+		for i, input := range original.Inputs {
+			field := &event.Fields[i]
+			field.Indexed = input.Indexed
+			field.Filter = field.Type
+			if input.Indexed && isHashed(input.Type) {
+				field.Hashed = true
+				field.Type = "common.Hash"
+				// Strings and bytes are hashed by the topic filter, any
+				// other dynamic value has to be filtered by its hash.
+				if input.Type.T != abi.StringTy && input.Type.T != abi.BytesTy {
+					field.Filter = "common.Hash"
+				}
+			}
+		}
+		contract.Events = append(contract.Events, event)
+	}
+	data.Contracts = append(data.Contracts, contract)
+	return nil
+}
+
+// bindArgs converts ABI arguments into template arguments, registering the
+// structs needed for their tuple types.
+func (data *tmplData) bindArgs(args abi.Arguments) ([]tmplArg, error) {
+	var bound []tmplArg
+	for i, arg := range args {
+		typ, err := data.bindType(arg.Type)
+		if err != nil {
+			return nil, err
+		}
+		bound = append(bound, tmplArg{
+			Name:  paramName(arg.Name, i),
+			Field: fieldName(arg.Name, i),
+			Type:  typ,
+		})
+	}
+	return bound, nil
+}
+
+// bindType converts a Solidity type to its Go counterpart, which is the
+// type the abi package packs from and unpacks into.
+func (data *tmplData) bindType(t abi.Type) (string, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		switch t.Size {
+		case 8, 16, 32, 64:
+			if t.T == abi.UintTy {
+				return fmt.Sprintf("uint%d", t.Size), nil
+			}
+			return fmt.Sprintf("int%d", t.Size), nil
+		}
+		return "*big.Int", nil
+	case abi.BoolTy:
+		return "bool", nil
+	case abi.StringTy:
+		return "string", nil
+	case abi.AddressTy:
+		return "common.Address", nil
+	case abi.BytesTy:
+		return "[]byte", nil
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case abi.FunctionTy:
+		return "[24]byte", nil
+	case abi.SliceTy:
+		elem, err := data.bindType(*t.Elem)
+		return "[]" + elem, err
+	case abi.ArrayTy:
+		elem, err := data.bindType(*t.Elem)
+		return fmt.Sprintf("[%d]%s", t.Size, elem), err
+	case abi.TupleTy:
+		return data.bindStruct(t)
+	}
+	return "", fmt.Errorf("bind: unsupported type %v", t)
+}
+
+// bindStruct registers the Go struct of a tuple type and returns its name.
+// Tuples of the same canonical type share one struct.
+func (data *tmplData) bindStruct(t abi.Type) (string, error) {
+	if s, ok := data.structs[t.String()]; ok {
+		return s.Name, nil
+	}
+	name := capitalise(t.TupleRawName)
+	if name == "" {
+		name = "Struct0"
+	}
+	for i := 1; data.names[name]; i++ {
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+	s := &tmplStruct{Name: name}
+	data.names[name] = true
+	data.structs[t.String()] = s
+
+	for i, elem := range t.TupleElems {
+		typ, err := data.bindType(*elem)
+		if err != nil {
+			return "", err
+		}
+		s.Fields = append(s.Fields, tmplArg{Field: t.TupleType.Field(i).Name, Type: typ})
+	}
+	return name, nil
+}
+
+// isHashed reports whether an indexed event argument of type t is stored
+// as the hash of its value.
+func isHashed(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
+
+// capitalise makes a camel-case string which starts with an upper case character.
+func capitalise(input string) string {
+	return abi.ToCamelCase(input)
+}
+
+// decapitalise makes a camel-case string which starts with a lower case character.
+func decapitalise(input string) string {
+	if len(input) == 0 {
+		return input
+	}
+	goForm := abi.ToCamelCase(input)
+	return strings.ToLower(goForm[:1]) + goForm[1:]
+}
+
+// paramName converts an argument name to a Go parameter name, replacing
+// missing names and names clashing with Go keywords.
+func paramName(name string, index int) string {
+	name = decapitalise(name)
+	if name == "" || !isIdentifier(name) {
+		return fmt.Sprintf("arg%d", index)
+	}
+	if isKeyWord(name) || isReserved(name) {
+		return name + "_"
+	}
+	return name
+}
+
+// fieldName converts an argument name to an exported Go field name.
+func fieldName(name string, index int) string {
+	name = capitalise(name)
+	if name == "" || !isIdentifier(name) {
+		return fmt.Sprintf("Arg%d", index)
+	}
+	return name
+}
+
+func isIdentifier(name string) bool {
+	for i, c := range name {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isReserved reports whether name is used by the generated code itself.
+func isReserved(name string) bool {
+	switch name {
+	case "opts", "auth", "backend", "subchain", "address", "contract", "parsed", "err", "out", "outstruct", "tx", "logs":
+		return true
+	}
+	return false
+}
+
+func isKeyWord(arg string) bool {
+	switch arg {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+		"for", "func", "go", "goto", "if", "import", "interface", "iota", "map", "make", "new",
+		"package", "range", "return", "select", "struct", "switch", "type", "var":
+		return true
+	}
+	return false
+}
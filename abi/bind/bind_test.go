@@ -0,0 +1,110 @@
+package bind
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common/compiler"
+)
+
+const tokenABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"},{"name":"name","type":"string"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"info","stateMutability":"view","inputs":[],"outputs":[{"name":"name","type":"string"},{"name":"decimals","type":"uint8"}]},
+	{"type":"function","name":"position","stateMutability":"pure","inputs":[{"name":"p","type":"tuple","internalType":"struct Geo.Point","components":[{"name":"x","type":"int64"},{"name":"y","type":"int64"}]}],"outputs":[{"name":"","type":"tuple[]","internalType":"struct Geo.Point[]","components":[{"name":"x","type":"int64"},{"name":"y","type":"int64"}]}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"approve","inputs":[{"name":"address","type":"address"},{"name":"type","type":"uint256"}],"outputs":[]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"memo","type":"string","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"receive","stateMutability":"payable"},
+	{"type":"fallback","stateMutability":"payable"}
+]`
+
+func TestBindOutput(t *testing.T) {
+	code, err := Bind([]string{"Token"}, []string{tokenABI}, []string{"0x6060"}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func DeployToken(auth *bind.TransactOpts, backend bind.ContractBackend, supply *big.Int, name string)",
+		"func DeployTokenOnSubchain(auth *bind.TransactOpts, subchain bind.Subchain, backend bind.ContractTransactor, supply *big.Int, name string)",
+		"func NewTokenOnSubchain(subchain bind.Subchain, address common.Address, backend bind.ContractBackend)",
+		"func (_Token *TokenCaller) BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error)",
+		"func (_Token *TokenCaller) Info(opts *bind.CallOpts) (struct {",
+		"func (_Token *TokenCaller) Position(opts *bind.CallOpts, p GeoPoint) ([]GeoPoint, error)",
+		"func (_Token *TokenTransactor) Transfer(opts *bind.TransactOpts, to common.Address, value *big.Int)",
+		"func (_Token *TokenTransactor) Transfer0(opts *bind.TransactOpts, to common.Address, value *big.Int, data []byte)",
+		"func (_Token *TokenTransactor) Approve(opts *bind.TransactOpts, address_ common.Address, type_ *big.Int)",
+		"func (_Token *TokenTransactor) Receive(opts *bind.TransactOpts)",
+		"func (_Token *TokenTransactor) Fallback(opts *bind.TransactOpts, calldata []byte)",
+		"func (_Token *TokenFilterer) FilterTransfer(opts *bind.FilterOpts, from []common.Address, to []common.Address, memo []string)",
+		"Memo  common.Hash",
+		"type GeoPoint struct",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+}
+
+// TestBindCompiles checks that generated bindings build against this module.
+func TestBindCompiles(t *testing.T) {
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+	// The compiler reports decoded ABIs, feed the binder the same.
+	var definition interface{}
+	if err := json.Unmarshal([]byte(tokenABI), &definition); err != nil {
+		t.Fatal(err)
+	}
+	contracts := map[string]*compiler.Contract{
+		"token.sol:Token": {
+			Code: "0x6060",
+			Info: compiler.ContractInfo{AbiDefinition: definition},
+		},
+	}
+	code, err := BindContracts(contracts, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Build the binding as its own module outside the source tree, using
+	// this checkout of the library and its dependency versions.
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomod, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gosum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomod = []byte(strings.Replace(string(gomod), "module github.com/MOACChain/MoacLib", "module bindtest", 1) +
+		"\nrequire github.com/MOACChain/MoacLib v0.0.0\nreplace github.com/MOACChain/MoacLib => " + root + "\n")
+
+	dir, err := ioutil.TempDir("", "bindtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string][]byte{"go.mod": gomod, "go.sum": gosum, "token.go": []byte(code)}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(gocmd, "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated binding does not build: %v\n%s\n%s", err, out, code)
+	}
+}
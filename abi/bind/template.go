@@ -0,0 +1,270 @@
+package bind
+
+// tmplSource is the Go source template that the generated Go contract binding
+// is based on.
+const tmplSource = `// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/MOACChain/MoacLib/abi"
+	"github.com/MOACChain/MoacLib/abi/bind"
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = abi.ConvertType
+	_ = bind.NewBoundContract
+	_ = common.Big1
+	_ = types.BloomLookup
+)
+{{range .Structs}}
+// {{.Name}} is an auto generated low-level Go binding around a user-defined struct.
+type {{.Name}} struct {
+{{range .Fields}}	{{.Field}} {{.Type}}
+{{end}}}
+{{end}}
+{{range $contract := .Contracts}}
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+const {{.Type}}ABI = {{printf "%q" .InputABI}}
+{{if .InputBin}}
+// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+const {{.Type}}Bin = "0x{{.InputBin}}"
+
+// Deploy{{.Type}} deploys a new MOAC contract, binding an instance of {{.Type}} to it.
+func Deploy{{.Type}}(auth *bind.TransactOpts, backend bind.ContractBackend{{range .Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (common.Address, *types.Transaction, *{{.Type}}, error) {
+	parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex({{.Type}}Bin), backend{{range .Constructor.Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
+}
+
+// Deploy{{.Type}}OnSubchain deploys a new instance of {{.Type}} as a dapp on the
+// given subchain. Bind to it with New{{.Type}}OnSubchain once the subchain has
+// assigned its address.
+func Deploy{{.Type}}OnSubchain(auth *bind.TransactOpts, subchain bind.Subchain, backend bind.ContractTransactor{{range .Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (*types.Transaction, error) {
+	parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.DeploySubchainContract(auth, subchain, parsed, common.FromHex({{.Type}}Bin), backend{{range .Constructor.Inputs}}, {{.Name}}{{end}})
+}
+{{end}}
+// {{.Type}} is an auto generated Go binding around a MOAC contract.
+type {{.Type}} struct {
+	{{.Type}}Caller     // Read-only binding to the contract
+	{{.Type}}Transactor // Write-only binding to the contract
+	{{.Type}}Filterer   // Log filterer for contract events
+}
+
+// {{.Type}}Caller is an auto generated read-only Go binding around a MOAC contract.
+type {{.Type}}Caller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// {{.Type}}Transactor is an auto generated write-only Go binding around a MOAC contract.
+type {{.Type}}Transactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// {{.Type}}Filterer is an auto generated log filtering Go binding around a MOAC contract events.
+type {{.Type}}Filterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
+	contract, err := bind{{.Type}}(nil, address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
+}
+
+// New{{.Type}}OnSubchain creates a new instance of {{.Type}}, bound to a dapp
+// deployed on the given subchain.
+func New{{.Type}}OnSubchain(subchain bind.Subchain, address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
+	contract, err := bind{{.Type}}(&subchain, address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
+}
+
+// New{{.Type}}Caller creates a new read-only instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}Caller(address common.Address, caller bind.ContractCaller) (*{{.Type}}Caller, error) {
+	contract, err := bind{{.Type}}(nil, address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}Caller{contract: contract}, nil
+}
+
+// New{{.Type}}Transactor creates a new write-only instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}Transactor(address common.Address, transactor bind.ContractTransactor) (*{{.Type}}Transactor, error) {
+	contract, err := bind{{.Type}}(nil, address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}Transactor{contract: contract}, nil
+}
+
+// New{{.Type}}Filterer creates a new log filterer instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}Filterer(address common.Address, filterer bind.ContractFilterer) (*{{.Type}}Filterer, error) {
+	contract, err := bind{{.Type}}(nil, address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}Filterer{contract: contract}, nil
+}
+
+// bind{{.Type}} binds a generic wrapper to an already deployed contract.
+func bind{{.Type}}(subchain *bind.Subchain, address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	if subchain != nil {
+		return bind.NewSubchainBoundContract(*subchain, address, parsed, caller, transactor, filterer), nil
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+{{range .Calls}}
+// {{.Normalized}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}Caller) {{.Normalized}}(opts *bind.CallOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) ({{if .Structured}}struct {
+{{range .Outputs}}	{{.Field}} {{.Type}}
+{{end}}}, {{else}}{{range .Outputs}}{{.Type}}, {{end}}{{end}}error) {
+	var out []interface{}
+	err := _{{$contract.Type}}.contract.Call(opts, &out, "{{.Original.Name}}"{{range .Inputs}}, {{.Name}}{{end}})
+{{- if .Structured}}
+	outstruct := new(struct {
+{{range .Outputs}}		{{.Field}} {{.Type}}
+{{end}}	})
+	if err != nil {
+		return *outstruct, err
+	}
+{{range $i, $_ := .Outputs}}	outstruct.{{.Field}} = *abi.ConvertType(out[{{$i}}], new({{.Type}})).(*{{.Type}})
+{{end}}
+	return *outstruct, err
+{{else}}
+	if err != nil {
+		return {{range .Outputs}}*new({{.Type}}), {{end}}err
+	}
+{{range $i, $_ := .Outputs}}	out{{$i}} := *abi.ConvertType(out[{{$i}}], new({{.Type}})).(*{{.Type}})
+{{end}}
+	return {{range $i, $_ := .Outputs}}out{{$i}}, {{end}}err
+{{end}}}
+{{end}}
+{{range .Transacts}}
+// {{.Normalized}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}Transactor) {{.Normalized}}(opts *bind.TransactOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (*types.Transaction, error) {
+	return _{{$contract.Type}}.contract.Transact(opts, "{{.Original.Name}}"{{range .Inputs}}, {{.Name}}{{end}})
+}
+{{end}}
+{{if .Fallback}}
+// Fallback is a paid mutator transaction binding the contract fallback function.
+func (_{{.Type}} *{{.Type}}Transactor) Fallback(opts *bind.TransactOpts, calldata []byte) (*types.Transaction, error) {
+	return _{{.Type}}.contract.RawTransact(opts, calldata)
+}
+{{end}}
+{{if .Receive}}
+// Receive is a paid mutator transaction binding the contract receive function.
+func (_{{.Type}} *{{.Type}}Transactor) Receive(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _{{.Type}}.contract.Transfer(opts)
+}
+{{end}}
+{{range .Events}}
+// {{$contract.Type}}{{.Normalized}}Iterator is returned from Filter{{.Normalized}} and is used to iterate over the raw logs and unpacked data for {{.Normalized}} events raised by the {{$contract.Type}} contract.
+type {{$contract.Type}}{{.Normalized}}Iterator struct {
+	Event *{{$contract.Type}}{{.Normalized}} // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+	logs     []types.Log         // Logs left to iterate over
+	fail     error               // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a decoding error, false is returned
+// and Error() can be queried for the exact failure.
+func (it *{{$contract.Type}}{{.Normalized}}Iterator) Next() bool {
+	if it.fail != nil || len(it.logs) == 0 {
+		return false
+	}
+	log := it.logs[0]
+	it.logs = it.logs[1:]
+
+	it.Event = new({{$contract.Type}}{{.Normalized}})
+	if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+		it.fail = err
+		return false
+	}
+	it.Event.Raw = log
+	return true
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *{{$contract.Type}}{{.Normalized}}Iterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *{{$contract.Type}}{{.Normalized}}Iterator) Close() error {
+	it.logs = nil
+	return nil
+}
+
+// {{$contract.Type}}{{.Normalized}} represents a {{.Normalized}} event raised by the {{$contract.Type}} contract.
+type {{$contract.Type}}{{.Normalized}} struct {
+{{range .Fields}}	{{.Field}} {{.Type}}
+{{end}}	Raw types.Log // Blockchain specific contextual infos
+}
+
+// Filter{{.Normalized}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Filter{{.Normalized}}(opts *bind.FilterOpts{{range .Fields}}{{if .Indexed}}, {{.Name}} []{{.Filter}}{{end}}{{end}}) (*{{$contract.Type}}{{.Normalized}}Iterator, error) {
+{{range .Fields}}{{if .Indexed}}	var {{.Name}}Rule []interface{}
+	for _, {{.Name}}Item := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+	}
+{{end}}{{end}}
+	logs, err := _{{$contract.Type}}.contract.FilterLogs(opts, "{{.Original.Name}}"{{range .Fields}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	return &{{$contract.Type}}{{.Normalized}}Iterator{contract: _{{$contract.Type}}.contract, event: "{{.Original.Name}}", logs: logs}, nil
+}
+
+// Parse{{.Normalized}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Parse{{.Normalized}}(log types.Log) (*{{$contract.Type}}{{.Normalized}}, error) {
+	event := new({{$contract.Type}}{{.Normalized}})
+	if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+{{end}}
+{{end}}
+`
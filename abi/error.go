@@ -0,0 +1,106 @@
+package abi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+var (
+	// revertSelector is the selector of the builtin Error(string) error.
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	// panicSelector is the selector of the builtin Panic(uint256) error.
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+	errNoRevertData = errors.New("abi: not a revert reason")
+)
+
+// Error is a custom error declared in a contract and returned as revert data.
+type Error struct {
+	Name   string
+	Inputs Arguments
+	str    string
+
+	// Sig contains the string signature according to the ABI spec.
+	// e.g.	 error foo(uint32 a, int b) = "foo(uint32,int256)"
+	// Please note that "int" is substitute for its canonical representation "int256"
+	Sig string
+
+	// ID returns the canonical representation of the error's signature used by the
+	// abi definition to identify event names and types.
+	ID [4]byte
+}
+
+// NewError creates a new Error, precomputing its selector and signature.
+func NewError(name string, inputs Arguments) Error {
+	names := make([]string, len(inputs))
+	types := make([]string, len(inputs))
+	for i, input := range inputs {
+		if input.Name == "" {
+			inputs[i] = Argument{
+				Name: fmt.Sprintf("arg%d", i),
+				Type: input.Type,
+			}
+		}
+		names[i] = fmt.Sprintf("%v %v", input.Type, inputs[i].Name)
+		types[i] = input.Type.String()
+	}
+	sig := fmt.Sprintf("%v(%v)", name, strings.Join(types, ","))
+
+	e := Error{
+		Name:   name,
+		Inputs: inputs,
+		str:    fmt.Sprintf("error %v(%v)", name, strings.Join(names, ", ")),
+		Sig:    sig,
+	}
+	copy(e.ID[:], crypto.Keccak256([]byte(sig))[:4])
+	return e
+}
+
+func (e Error) String() string {
+	return e.str
+}
+
+// Unpack decodes the arguments of the error from revert data, which must
+// start with the error's selector.
+func (e *Error) Unpack(data []byte) ([]interface{}, error) {
+	if len(data) < 4 {
+		return nil, errors.New("abi: invalid data for unpacking")
+	}
+	if !bytes.Equal(data[:4], e.ID[:]) {
+		return nil, errors.New("abi: data does not match error selector")
+	}
+	return e.Inputs.Unpack(data[4:])
+}
+
+// UnpackRevert resolves the abi-encoded revert reason. According to the
+// solidity spec https://solidity.readthedocs.io/en/latest/control-structures.html#revert,
+// the provided revert reason is abi-encoded as if it were a call to a function
+// `Error(string)`. Panics raised by the compiler (`Panic(uint256)`) are
+// rendered with their code.
+func UnpackRevert(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", errNoRevertData
+	}
+	switch {
+	case bytes.Equal(data[:4], revertSelector):
+		typ, _ := NewType("string", "", nil)
+		unpacked, err := (Arguments{{Type: typ}}).Unpack(data[4:])
+		if err != nil {
+			return "", err
+		}
+		return unpacked[0].(string), nil
+	case bytes.Equal(data[:4], panicSelector):
+		typ, _ := NewType("uint256", "", nil)
+		unpacked, err := (Arguments{{Type: typ}}).Unpack(data[4:])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("panic: 0x%x", unpacked[0].(*big.Int)), nil
+	}
+	return "", errNoRevertData
+}
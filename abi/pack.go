@@ -0,0 +1,252 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/common/math"
+)
+
+var errPackNil = errors.New("abi: cannot pack nil value")
+
+// pack encodes the value v according to the type t.
+func (t Type) pack(v reflect.Value) ([]byte, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil, errPackNil
+	}
+	switch t.T {
+	case SliceTy, ArrayTy:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, typeErr(t, v)
+		}
+		if t.T == ArrayTy && v.Len() != t.Size {
+			return nil, fmt.Errorf("abi: cannot use array of length %d as %v", v.Len(), t)
+		}
+		var ret []byte
+		if t.T == SliceTy {
+			// dynamic arrays are prefixed with their length
+			ret = append(ret, packNum(reflect.ValueOf(v.Len()))...)
+		}
+		elems := make([]reflect.Value, v.Len())
+		types := make([]Type, v.Len())
+		for i := range elems {
+			elems[i], types[i] = v.Index(i), *t.Elem
+		}
+		enc, err := packSequence(types, elems)
+		if err != nil {
+			return nil, err
+		}
+		return append(ret, enc...), nil
+
+	case TupleTy:
+		elems, err := tupleFields(t, v)
+		if err != nil {
+			return nil, err
+		}
+		types := make([]Type, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			types[i] = *elem
+		}
+		return packSequence(types, elems)
+
+	default:
+		return packElement(t, v)
+	}
+}
+
+// packSequence encodes a sequence of values with the head/tail scheme used
+// for argument lists, arrays and tuples.
+func packSequence(types []Type, values []reflect.Value) ([]byte, error) {
+	headSize := 0
+	for _, t := range types {
+		headSize += getTypeSize(t)
+	}
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := t.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if isDynamicType(t) {
+			head = append(head, packNum(reflect.ValueOf(headSize+len(tail)))...)
+			tail = append(tail, enc...)
+		} else {
+			head = append(head, enc...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// tupleFields returns the values of the tuple elements held by v, which may
+// be a struct with matching field names, or a slice or array with one entry
+// per element.
+func tupleFields(t Type, v reflect.Value) ([]reflect.Value, error) {
+	fields := make([]reflect.Value, len(t.TupleElems))
+	switch v.Kind() {
+	case reflect.Struct:
+		for i, name := range t.TupleRawNames {
+			field := v.FieldByName(t.TupleType.Field(i).Name)
+			if !field.IsValid() {
+				field = v.FieldByName(ToCamelCase(name))
+			}
+			if !field.IsValid() {
+				return nil, fmt.Errorf("abi: field %s can't be found in the given value", name)
+			}
+			fields[i] = field
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(t.TupleElems) {
+			return nil, fmt.Errorf("abi: tuple %v needs %d elements, got %d", t, len(t.TupleElems), v.Len())
+		}
+		for i := range fields {
+			fields[i] = v.Index(i)
+		}
+	default:
+		return nil, typeErr(t, v)
+	}
+	return fields, nil
+}
+
+// packElement packs the elementary value v according to the type t.
+func packElement(t Type, v reflect.Value) ([]byte, error) {
+	switch t.T {
+	case IntTy, UintTy:
+		if !isInteger(v) {
+			return nil, typeErr(t, v)
+		}
+		if err := checkIntRange(t, v); err != nil {
+			return nil, err
+		}
+		return packNum(v), nil
+	case BoolTy:
+		if v.Kind() != reflect.Bool {
+			return nil, typeErr(t, v)
+		}
+		if v.Bool() {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return math.PaddedBigBytes(common.Big0, 32), nil
+	case StringTy:
+		if v.Kind() != reflect.String {
+			return nil, typeErr(t, v)
+		}
+		return packBytesSlice([]byte(v.String()), v.Len()), nil
+	case AddressTy:
+		if v.Type() != addressT {
+			return nil, typeErr(t, v)
+		}
+		return common.LeftPadBytes(v.Interface().(common.Address).Bytes(), 32), nil
+	case BytesTy:
+		b, ok := byteSlice(v)
+		if !ok {
+			return nil, typeErr(t, v)
+		}
+		return packBytesSlice(b, len(b)), nil
+	case FixedBytesTy, FunctionTy:
+		b, ok := byteSlice(v)
+		if !ok || len(b) != t.Size {
+			return nil, typeErr(t, v)
+		}
+		return common.RightPadBytes(b, 32), nil
+	default:
+		return nil, fmt.Errorf("abi: could not pack element, unknown type: %v", t.T)
+	}
+}
+
+// packNum packs the given number (using the reflect value) and will cast it
+// to the appropriate number representation, using two's complement for
+// negative values.
+func packNum(value reflect.Value) []byte {
+	switch kind := value.Kind(); kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return math.PaddedBigBytes(new(big.Int).SetUint64(value.Uint()), 32)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return math.PaddedBigBytes(math.U256(big.NewInt(value.Int())), 32)
+	case reflect.Ptr:
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(value.Interface().(*big.Int))), 32)
+	default:
+		panic("abi: fatal error")
+	}
+}
+
+// packBytesSlice packs the given bytes as [L, V] as the canonical
+// representation of dynamic bytes and strings.
+func packBytesSlice(bytes []byte, l int) []byte {
+	length := packNum(reflect.ValueOf(l))
+	padded := len(bytes)
+	if padded%32 != 0 {
+		padded += 32 - padded%32
+	}
+	return append(length, common.RightPadBytes(bytes, padded)...)
+}
+
+// checkIntRange verifies that the integer v fits into the abi integer type.
+func checkIntRange(t Type, v reflect.Value) error {
+	var n *big.Int
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = new(big.Int).SetUint64(v.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = big.NewInt(v.Int())
+	default:
+		n = v.Interface().(*big.Int)
+	}
+	var min, max *big.Int
+	if t.T == UintTy {
+		min, max = new(big.Int), new(big.Int).Sub(new(big.Int).Lsh(common.Big1, uint(t.Size)), common.Big1)
+	} else {
+		max = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, uint(t.Size-1)), common.Big1)
+		min = new(big.Int).Neg(new(big.Int).Lsh(common.Big1, uint(t.Size-1)))
+	}
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 {
+		return fmt.Errorf("abi: value %v out of range for %v", n, t)
+	}
+	return nil
+}
+
+func isInteger(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Ptr:
+		return v.Type() == bigT && !v.IsNil()
+	}
+	return false
+}
+
+// byteSlice returns the content of a byte slice or byte array.
+func byteSlice(v reflect.Value) ([]byte, bool) {
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	if v.Kind() == reflect.Slice {
+		return v.Bytes(), true
+	}
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b, true
+}
+
+// indirect recursively dereferences the value until it either gets the value
+// or finds a big.Int.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Ptr && v.Type() != bigT {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		return indirect(v.Elem())
+	}
+	return v
+}
+
+func typeErr(t Type, v reflect.Value) error {
+	return fmt.Errorf("abi: cannot use %v as type %v", v.Type(), t)
+}
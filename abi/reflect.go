@@ -0,0 +1,118 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ConvertType converts an interface of a runtime type into an interface of
+// the given type, e.g. turn this code:
+//
+//	var fields []reflect.StructField
+//
+//	fields = append(fields, reflect.StructField{
+//			Name: "X",
+//			Type: reflect.TypeOf(new(big.Int)),
+//			Tag:  reflect.StructTag("json:\"" + "x" + "\""),
+//	}
+//
+// into:
+//
+//	type TupleT struct { X *big.Int }
+//
+// It panics if the conversion is impossible; the inputs are expected to come
+// from Unpack with a matching ABI.
+func ConvertType(in interface{}, proto interface{}) interface{} {
+	protoType := reflect.TypeOf(proto)
+	if reflect.TypeOf(in).ConvertibleTo(protoType) {
+		return reflect.ValueOf(in).Convert(protoType).Interface()
+	}
+	// Use set as a last ditch effort
+	if err := set(reflect.ValueOf(proto), reflect.ValueOf(in)); err != nil {
+		panic(err)
+	}
+	return proto
+}
+
+// set attempts to assign src to dst by either setting, copying or otherwise.
+//
+// set is a bit more lenient when it comes to assignment and doesn't force an
+// as strict ruleset as bare `reflect` does.
+func set(dst, src reflect.Value) error {
+	dstType, srcType := dst.Type(), src.Type()
+	switch {
+	case dstType.Kind() == reflect.Interface && dst.Elem().IsValid() && (dst.Elem().Type().Kind() == reflect.Ptr || dst.Elem().CanSet()):
+		return set(dst.Elem(), src)
+	case dstType.Kind() == reflect.Ptr && dstType.Elem() != bigT.Elem():
+		return set(dst.Elem(), src)
+	case srcType.AssignableTo(dstType) && dst.CanSet():
+		dst.Set(src)
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice && dst.CanSet():
+		return setSlice(dst, src)
+	case dstType.Kind() == reflect.Array:
+		return setArray(dst, src)
+	case dstType.Kind() == reflect.Struct:
+		return setStruct(dst, src)
+	default:
+		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
+	}
+	return nil
+}
+
+// setSlice attempts to assign src to dst when slices are not assignable by
+// default, e.g. src: [][]byte -> dst: [][15]byte. setSlice ignores if we
+// cannot copy all of src's elements.
+func setSlice(dst, src reflect.Value) error {
+	slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		if err := set(slice.Index(i), src.Index(i)); err != nil {
+			return err
+		}
+	}
+	if dst.CanSet() {
+		dst.Set(slice)
+		return nil
+	}
+	return fmt.Errorf("abi: cannot set slice, destination not settable")
+}
+
+func setArray(dst, src reflect.Value) error {
+	if src.Kind() == reflect.Ptr {
+		return set(dst, indirect(src))
+	}
+	if src.Kind() != reflect.Array && src.Kind() != reflect.Slice {
+		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
+	}
+	if src.Len() != dst.Len() {
+		return fmt.Errorf("abi: cannot unmarshal %v of length %d in to %v", src.Type(), src.Len(), dst.Type())
+	}
+	array := reflect.New(dst.Type()).Elem()
+	for i := 0; i < array.Len(); i++ {
+		if err := set(array.Index(i), src.Index(i)); err != nil {
+			return err
+		}
+	}
+	if dst.CanSet() {
+		dst.Set(array)
+		return nil
+	}
+	return fmt.Errorf("abi: cannot set array, destination not settable")
+}
+
+func setStruct(dst, src reflect.Value) error {
+	if src.Kind() != reflect.Struct {
+		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
+	}
+	for i := 0; i < src.NumField(); i++ {
+		srcField := src.Field(i)
+		name := src.Type().Field(i).Name
+		dstField := dst.FieldByName(name)
+		if !dstField.IsValid() {
+			return fmt.Errorf("abi: field %s can't be found in %v", name, dst.Type())
+		}
+		if err := set(dstField, srcField); err != nil {
+			return err
+		}
+	}
+	return nil
+}
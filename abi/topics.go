@@ -0,0 +1,173 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/common/math"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+var errNoEventSignature = errors.New("abi: no event signature")
+
+// MakeTopics converts a filter query argument list into a filter topic set.
+// Each inner slice holds the alternatives for one indexed argument; a nil
+// entry matches any value.
+func MakeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		for _, rule := range filter {
+			var topic common.Hash
+
+			// Try to generate the topic based on simple types
+			switch rule := rule.(type) {
+			case common.Hash:
+				copy(topic[:], rule[:])
+			case common.Address:
+				copy(topic[common.HashLength-common.AddressLength:], rule[:])
+			case *big.Int:
+				copy(topic[:], math.PaddedBigBytes(math.U256(new(big.Int).Set(rule)), 32))
+			case bool:
+				if rule {
+					topic[common.HashLength-1] = 1
+				}
+			case int8, int16, int32, int64, uint8, uint16, uint32, uint64, int, uint:
+				copy(topic[:], packNum(reflect.ValueOf(rule)))
+			case string:
+				copy(topic[:], crypto.Keccak256([]byte(rule)))
+			case []byte:
+				copy(topic[:], crypto.Keccak256(rule))
+
+			default:
+				// Attempt to generate the topic from funky types
+				val := reflect.ValueOf(rule)
+				if val.Kind() == reflect.Array && val.Type().Elem().Kind() == reflect.Uint8 && val.Len() <= 32 {
+					reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
+				} else {
+					return nil, fmt.Errorf("abi: unsupported indexed type: %T", rule)
+				}
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// ParseTopics decodes the indexed arguments of an event from its topics,
+// excluding the signature topic, into a map from argument name to value.
+// Indexed arguments of dynamic types (strings, bytes, arrays and tuples) are
+// only available as their Keccak256 hash and are returned as common.Hash.
+func ParseTopics(out map[string]interface{}, fields Arguments, topics []common.Hash) error {
+	var indexed Arguments
+	for _, arg := range fields {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(indexed) != len(topics) {
+		return fmt.Errorf("abi: topic/field count mismatch: %d topics for %d indexed fields", len(topics), len(indexed))
+	}
+	for i, arg := range indexed {
+		switch arg.Type.T {
+		case StringTy, BytesTy, SliceTy, ArrayTy, TupleTy:
+			// Array types (including strings and bytes) have their keccak256 hashes stored in the topic- not a hash
+			// whose bytes can be decoded to the actual value- so the best we can do is retrieve that hash
+			out[arg.Name] = topics[i]
+		case FunctionTy:
+			if garbage := topics[i][:8]; !isZero(garbage) {
+				return fmt.Errorf("abi: bind: got improperly encoded function type, got %v", topics[i].Bytes())
+			}
+			var tmp [24]byte
+			copy(tmp[:], topics[i][8:32])
+			out[arg.Name] = tmp
+		default:
+			v, err := toGoType(0, arg.Type, topics[i].Bytes())
+			if err != nil {
+				return err
+			}
+			out[arg.Name] = v
+		}
+	}
+	return nil
+}
+
+// DecodeLog identifies the event of a log by its signature topic and decodes
+// all its arguments, indexed ones from the topics and the others from the
+// log data. It returns the matching event and the values keyed by name.
+// Anonymous events cannot be identified and are not supported.
+func (abi *ABI) DecodeLog(log *types.Log) (*Event, map[string]interface{}, error) {
+	if len(log.Topics) == 0 {
+		return nil, nil, errNoEventSignature
+	}
+	event, err := abi.EventByID(log.Topics[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make(map[string]interface{})
+	if err := abi.UnpackLogIntoMap(out, event.Name, log); err != nil {
+		return nil, nil, err
+	}
+	return event, out, nil
+}
+
+// UnpackLogIntoMap decodes the arguments of the named event from a log into
+// a map from argument name to value.
+func (abi *ABI) UnpackLogIntoMap(out map[string]interface{}, name string, log *types.Log) error {
+	event, ok := abi.Events[name]
+	if !ok {
+		return fmt.Errorf("abi: could not locate event: %s", name)
+	}
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return errNoEventSignature
+		}
+		topics = topics[1:]
+	}
+	if len(log.Data) > 0 {
+		if err := event.Inputs.UnpackIntoMap(out, log.Data); err != nil {
+			return err
+		}
+	} else if len(event.Inputs.NonIndexed()) != 0 {
+		return errors.New("abi: log data is empty while arguments are expected")
+	}
+	return ParseTopics(out, event.Inputs, topics)
+}
+
+// UnpackLog decodes the arguments of the named event from a log into out,
+// which must be a pointer to a struct with fields named after the event
+// arguments in camel case.
+func (abi *ABI) UnpackLog(out interface{}, name string, log *types.Log) error {
+	values := make(map[string]interface{})
+	if err := abi.UnpackLogIntoMap(values, name, log); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("abi: UnpackLog(non-struct-pointer %T)", out)
+	}
+	dst := rv.Elem()
+	for _, arg := range abi.Events[name].Inputs {
+		field := dst.FieldByName(ToCamelCase(arg.Name))
+		if !field.IsValid() {
+			continue
+		}
+		if err := set(field, reflect.ValueOf(values[arg.Name])); err != nil {
+			return fmt.Errorf("abi: field %s: %v", arg.Name, err)
+		}
+	}
+	return nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
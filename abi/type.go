@@ -0,0 +1,317 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/MOACChain/MoacLib/common"
+)
+
+// Type enumerator
+const (
+	IntTy byte = iota
+	UintTy
+	BoolTy
+	StringTy
+	SliceTy
+	ArrayTy
+	TupleTy
+	AddressTy
+	FixedBytesTy
+	BytesTy
+	FunctionTy
+)
+
+var (
+	// typeRegex parses the elementary part of an abi type, e.g. uint256 or bytes32
+	typeRegex = regexp.MustCompile(`^([a-z]+)([0-9]*)$`)
+
+	bigT     = reflect.TypeOf(&big.Int{})
+	addressT = reflect.TypeOf(common.Address{})
+)
+
+// Type is the reflection of a supported argument type.
+type Type struct {
+	Elem *Type // element type of slices and arrays
+	Size int   // bit size of integers, byte size of fixed bytes, length of arrays
+	T    byte  // Our own type checking
+
+	stringKind string // canonical type string used in signatures
+
+	// Tuple relative fields
+	TupleRawName  string       // Raw struct name defined in source code, may be empty
+	TupleElems    []*Type      // Type information of all tuple fields
+	TupleRawNames []string     // Raw field name of all tuple fields
+	TupleType     reflect.Type // Underlying struct of the tuple
+}
+
+// ArgumentMarshaling is the JSON representation of an argument or component.
+type ArgumentMarshaling struct {
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	InternalType string               `json:"internalType,omitempty"`
+	Components   []ArgumentMarshaling `json:"components,omitempty"`
+	Indexed      bool                 `json:"indexed,omitempty"`
+}
+
+// NewType creates a new reflection type of abi type given in t. Tuples need
+// their components to be given.
+func NewType(t string, internalType string, components []ArgumentMarshaling) (typ Type, err error) {
+	// check that array brackets are equal if they exist
+	if strings.Count(t, "[") != strings.Count(t, "]") {
+		return Type{}, errors.New("abi: invalid arg type in abi")
+	}
+	typ.stringKind = t
+
+	// if there are brackets, get ready to go into slice/array mode and
+	// recursively create the type
+	if strings.Count(t, "[") != 0 {
+		i := strings.LastIndex(t, "[")
+		embeddedType, err := NewType(t[:i], strings.TrimSuffix(internalType, t[i:]), components)
+		if err != nil {
+			return Type{}, err
+		}
+		sized := strings.TrimSuffix(t[i+1:], "]")
+		typ.Elem = &embeddedType
+		typ.stringKind = embeddedType.stringKind + t[i:]
+		if sized == "" {
+			typ.T = SliceTy
+			return typ, nil
+		}
+		if typ.Size, err = strconv.Atoi(sized); err != nil || typ.Size <= 0 {
+			return Type{}, fmt.Errorf("abi: invalid array size in %q", t)
+		}
+		typ.T = ArrayTy
+		return typ, nil
+	}
+
+	if t == "tuple" {
+		return newTupleType(internalType, components)
+	}
+	matches := typeRegex.FindStringSubmatch(t)
+	if matches == nil {
+		return Type{}, fmt.Errorf("abi: invalid type %q", t)
+	}
+	var varSize int
+	if matches[2] != "" {
+		if varSize, err = strconv.Atoi(matches[2]); err != nil {
+			return Type{}, fmt.Errorf("abi: error parsing variable size: %v", err)
+		}
+	}
+	switch matches[1] {
+	case "int", "uint":
+		if matches[2] == "" {
+			varSize = 256
+			typ.stringKind = t + "256"
+		}
+		if varSize == 0 || varSize > 256 || varSize%8 != 0 {
+			return Type{}, fmt.Errorf("abi: invalid integer size in %q", t)
+		}
+		typ.Size = varSize
+		if matches[1] == "int" {
+			typ.T = IntTy
+		} else {
+			typ.T = UintTy
+		}
+	case "bool":
+		typ.T = BoolTy
+	case "address":
+		typ.Size = 20
+		typ.T = AddressTy
+	case "string":
+		typ.T = StringTy
+	case "bytes":
+		if matches[2] == "" {
+			typ.T = BytesTy
+			break
+		}
+		if varSize == 0 || varSize > 32 {
+			return Type{}, fmt.Errorf("abi: invalid fixed bytes size in %q", t)
+		}
+		typ.T = FixedBytesTy
+		typ.Size = varSize
+	case "function":
+		typ.T = FunctionTy
+		typ.Size = 24
+	default:
+		return Type{}, fmt.Errorf("abi: unsupported arg type: %s", t)
+	}
+	if matches[2] != "" && typ.T != IntTy && typ.T != UintTy && typ.T != FixedBytesTy {
+		return Type{}, fmt.Errorf("abi: invalid type %q", t)
+	}
+	return typ, nil
+}
+
+func newTupleType(internalType string, components []ArgumentMarshaling) (Type, error) {
+	var (
+		typ    = Type{T: TupleTy}
+		fields []reflect.StructField
+		elems  []*Type
+		names  []string
+		kinds  []string
+		used   = make(map[string]bool)
+	)
+	for idx, c := range components {
+		cType, err := NewType(c.Type, c.InternalType, c.Components)
+		if err != nil {
+			return Type{}, err
+		}
+		name := ToCamelCase(c.Name)
+		if name == "" || !isValidFieldName(name) {
+			name = fmt.Sprintf("Field%d", idx)
+		}
+		for used[name] {
+			name += "0"
+		}
+		used[name] = true
+		fields = append(fields, reflect.StructField{
+			Name: name,
+			Type: cType.GetType(),
+			Tag:  reflect.StructTag("json:\"" + c.Name + "\""),
+		})
+		elems = append(elems, &cType)
+		names = append(names, c.Name)
+		kinds = append(kinds, cType.stringKind)
+	}
+	typ.TupleElems = elems
+	typ.TupleRawNames = names
+	typ.TupleType = reflect.StructOf(fields)
+	typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
+	if internalType != "" && strings.HasPrefix(internalType, "struct ") {
+		// Foo.Bar type definition is not allowed in golang,
+		// convert the format to FooBar
+		typ.TupleRawName = strings.Replace(internalType[len("struct "):], ".", "", -1)
+	}
+	return typ, nil
+}
+
+// GetType returns the reflection type of the ABI type.
+func (t Type) GetType() reflect.Type {
+	switch t.T {
+	case IntTy:
+		return reflectIntType(false, t.Size)
+	case UintTy:
+		return reflectIntType(true, t.Size)
+	case BoolTy:
+		return reflect.TypeOf(false)
+	case StringTy:
+		return reflect.TypeOf("")
+	case SliceTy:
+		return reflect.SliceOf(t.Elem.GetType())
+	case ArrayTy:
+		return reflect.ArrayOf(t.Size, t.Elem.GetType())
+	case TupleTy:
+		return t.TupleType
+	case AddressTy:
+		return addressT
+	case FixedBytesTy:
+		return reflect.ArrayOf(t.Size, reflect.TypeOf(byte(0)))
+	case BytesTy:
+		return reflect.SliceOf(reflect.TypeOf(byte(0)))
+	case FunctionTy:
+		return reflect.ArrayOf(24, reflect.TypeOf(byte(0)))
+	default:
+		panic("abi: invalid type")
+	}
+}
+
+// String implements Stringer, returning the canonical type name.
+func (t Type) String() string {
+	return t.stringKind
+}
+
+// reflectIntType returns the reflect type of an integer of the given size,
+// using native types up to 64 bits and *big.Int above.
+func reflectIntType(unsigned bool, size int) reflect.Type {
+	if unsigned {
+		switch size {
+		case 8:
+			return reflect.TypeOf(uint8(0))
+		case 16:
+			return reflect.TypeOf(uint16(0))
+		case 32:
+			return reflect.TypeOf(uint32(0))
+		case 64:
+			return reflect.TypeOf(uint64(0))
+		}
+	}
+	switch size {
+	case 8:
+		return reflect.TypeOf(int8(0))
+	case 16:
+		return reflect.TypeOf(int16(0))
+	case 32:
+		return reflect.TypeOf(int32(0))
+	case 64:
+		return reflect.TypeOf(int64(0))
+	}
+	return bigT
+}
+
+// isDynamicType returns true if the type is dynamic. The following types are
+// called "dynamic": bytes, string, T[] for any T, T[k] for any dynamic T and
+// any k >= 0, and (T1,...,Tk) if Ti is dynamic for some 1 <= i <= k.
+func isDynamicType(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy:
+		return true
+	case ArrayTy:
+		return isDynamicType(*t.Elem)
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getTypeSize returns the size that this type needs to occupy in the head of
+// an encoding. Dynamic types take a single 32 byte offset slot, static arrays
+// and tuples are encoded inline.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return 32
+	}
+	switch t.T {
+	case ArrayTy:
+		return t.Size * getTypeSize(*t.Elem)
+	case TupleTy:
+		total := 0
+		for _, elem := range t.TupleElems {
+			total += getTypeSize(*elem)
+		}
+		return total
+	}
+	return 32
+}
+
+// ToCamelCase converts an under-score string to a camel-case string.
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, s := range parts {
+		if len(s) > 0 {
+			parts[i] = strings.ToUpper(s[:1]) + s[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func isValidFieldName(name string) bool {
+	for i, c := range name {
+		if i == 0 && !unicode.IsLetter(c) {
+			return false
+		}
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
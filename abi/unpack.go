@@ -0,0 +1,237 @@
+package abi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/MOACChain/MoacLib/common"
+)
+
+var (
+	// MaxUint256 is the maximum value that can be represented by a uint256.
+	MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	// MaxInt256 is the maximum value that can be represented by a int256.
+	MaxInt256 = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 255), common.Big1)
+
+	errBadBool = errors.New("abi: improperly encoded boolean value")
+)
+
+// readInteger reads the integer based on its kind and returns the appropriate value.
+func readInteger(typ Type, b []byte) (interface{}, error) {
+	ret := new(big.Int).SetBytes(b)
+	if typ.T == UintTy {
+		if ret.BitLen() > typ.Size {
+			return nil, fmt.Errorf("abi: value %v overflows %v", ret, typ)
+		}
+		switch typ.Size {
+		case 8:
+			return uint8(ret.Uint64()), nil
+		case 16:
+			return uint16(ret.Uint64()), nil
+		case 32:
+			return uint32(ret.Uint64()), nil
+		case 64:
+			return ret.Uint64(), nil
+		}
+		return ret, nil
+	}
+	// Signed integers are encoded in two's complement
+	if ret.Cmp(MaxInt256) > 0 {
+		ret.Sub(ret, MaxUint256)
+		ret.Sub(ret, common.Big1)
+	}
+	limit := new(big.Int).Lsh(common.Big1, uint(typ.Size-1))
+	if ret.Cmp(limit) >= 0 || ret.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return nil, fmt.Errorf("abi: value %v overflows %v", ret, typ)
+	}
+	switch typ.Size {
+	case 8:
+		return int8(ret.Int64()), nil
+	case 16:
+		return int16(ret.Int64()), nil
+	case 32:
+		return int32(ret.Int64()), nil
+	case 64:
+		return ret.Int64(), nil
+	}
+	return ret, nil
+}
+
+// readBool reads a bool.
+func readBool(word []byte) (bool, error) {
+	for _, b := range word[:31] {
+		if b != 0 {
+			return false, errBadBool
+		}
+	}
+	switch word[31] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, errBadBool
+	}
+}
+
+// readFixedBytes copies the left aligned bytes of a word into an array of
+// the type's size.
+func readFixedBytes(t Type, word []byte) (interface{}, error) {
+	array := reflect.New(t.GetType()).Elem()
+	reflect.Copy(array, reflect.ValueOf(word[0:t.Size]))
+	return array.Interface(), nil
+}
+
+// forEachUnpack iteratively unpacks the elements of an array or slice
+// starting at the given offset.
+func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("abi: cannot marshal input to array, size is negative (%d)", size)
+	}
+	if start+32*size > len(output) {
+		return nil, fmt.Errorf("abi: cannot marshal into go array: offset %d would go over slice boundary (len=%d)", start+32*size, len(output))
+	}
+	var refSlice reflect.Value
+	switch t.T {
+	case SliceTy:
+		refSlice = reflect.MakeSlice(t.GetType(), size, size)
+	case ArrayTy:
+		refSlice = reflect.New(t.GetType()).Elem()
+	default:
+		return nil, errors.New("abi: invalid type in array/slice unpacking stage")
+	}
+	elemSize := getTypeSize(*t.Elem)
+	for i, j := start, 0; j < size; i, j = i+elemSize, j+1 {
+		inter, err := toGoType(i, *t.Elem, output)
+		if err != nil {
+			return nil, err
+		}
+		refSlice.Index(j).Set(reflect.ValueOf(inter))
+	}
+	return refSlice.Interface(), nil
+}
+
+// forTupleUnpack unpacks the elements of a tuple into its struct type.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.GetType()).Elem()
+	offset := 0
+	for i, elem := range t.TupleElems {
+		v, err := toGoType(offset, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(*elem)
+		retval.Field(i).Set(reflect.ValueOf(v))
+	}
+	return retval.Interface(), nil
+}
+
+// toGoType parses the output bytes and recursively assigns the value of these
+// bytes into a go type with accordance with the ABI spec.
+func toGoType(index int, t Type, output []byte) (interface{}, error) {
+	if index+32 > len(output) {
+		return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), index+32)
+	}
+	var (
+		returnOutput  []byte
+		begin, length int
+		err           error
+	)
+	// if we require a length prefix, find the beginning word and size returned.
+	if isDynamicType(t) && t.T != TupleTy && t.T != ArrayTy {
+		begin, length, err = lengthPrefixPointsTo(index, output)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		returnOutput = output[index : index+32]
+	}
+
+	switch t.T {
+	case TupleTy:
+		if isDynamicType(t) {
+			begin, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forTupleUnpack(t, output[begin:])
+		}
+		return forTupleUnpack(t, output[index:])
+	case SliceTy:
+		return forEachUnpack(t, output[begin:], 0, length)
+	case ArrayTy:
+		if isDynamicType(*t.Elem) {
+			offset, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forEachUnpack(t, output[offset:], 0, t.Size)
+		}
+		return forEachUnpack(t, output[index:], 0, t.Size)
+	case StringTy:
+		return string(output[begin : begin+length]), nil
+	case IntTy, UintTy:
+		return readInteger(t, returnOutput)
+	case BoolTy:
+		return readBool(returnOutput)
+	case AddressTy:
+		return common.BytesToAddress(returnOutput), nil
+	case BytesTy:
+		return common.CopyBytes(output[begin : begin+length]), nil
+	case FixedBytesTy, FunctionTy:
+		return readFixedBytes(t, returnOutput)
+	default:
+		return nil, fmt.Errorf("abi: unknown type %v", t.T)
+	}
+}
+
+// lengthPrefixPointsTo interprets a 32 byte slice as an offset and then
+// determines which indices to look to decode the type.
+func lengthPrefixPointsTo(index int, output []byte) (start int, length int, err error) {
+	offset, err := readOffset(output[index : index+32])
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset+32 > uint64(len(output)) {
+		return 0, 0, fmt.Errorf("abi: cannot marshal in to go slice: offset %d would go over slice boundary (len=%d)", offset+32, len(output))
+	}
+	size, err := readOffset(output[offset : offset+32])
+	if err != nil {
+		return 0, 0, err
+	}
+	start = int(offset + 32)
+	if uint64(start)+size > uint64(len(output)) {
+		return 0, 0, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), uint64(start)+size)
+	}
+	return start, int(size), nil
+}
+
+// tuplePointsTo resolves the location reference for dynamic tuple and array.
+func tuplePointsTo(index int, output []byte) (int, error) {
+	offset, err := readOffset(output[index : index+32])
+	if err != nil {
+		return 0, err
+	}
+	if offset > uint64(len(output)) {
+		return 0, fmt.Errorf("abi: cannot marshal in to go slice: offset %d would go over slice boundary (len=%d)", offset, len(output))
+	}
+	return int(offset), nil
+}
+
+// readOffset reads a 32 byte word as an offset or length, rejecting values
+// that cannot possibly fit into memory.
+func readOffset(word []byte) (uint64, error) {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, errors.New("abi: offset larger than int64")
+		}
+	}
+	v := binary.BigEndian.Uint64(word[24:])
+	if v > 1<<40 {
+		return 0, errors.New("abi: offset larger than int64")
+	}
+	return v, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package archive implements a streaming export format for chain segments,
+// used to move main chain or subchain history between machines and into test
+// fixtures.
+//
+// An archive starts with an 8 byte magic followed by the RLP encoded Header.
+// The rest of the stream is a sequence of RLP encoded segments. A segment
+// holds up to Header.SegmentSize entries, each entry being a block together
+// with its receipts in storage encoding. The entries of a segment are
+// concatenated, checksummed with keccak256 and then optionally compressed, so
+// a damaged segment is detected before any of its blocks are returned.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// Version is the archive format version written by the Exporter.
+const Version = 1
+
+// DefaultSegmentSize is the number of blocks per segment when none is set.
+const DefaultSegmentSize = 1024
+
+// MaxSegmentData is the maximum size of the uncompressed entries of a
+// segment. Exporters start a new segment early rather than exceed it.
+const MaxSegmentData = 256 * 1024 * 1024
+
+// maxSegmentEncoding bounds the encoded size of a segment: its data, which
+// gzip may grow slightly beyond MaxSegmentData, plus the count and checksum.
+const maxSegmentEncoding = MaxSegmentData + 64*1024
+
+var magic = [8]byte{'M', 'O', 'A', 'C', 'A', 'R', 'C', 'H'}
+
+var (
+	ErrBadMagic       = errors.New("archive: not an archive stream")
+	ErrVersion        = errors.New("archive: unsupported version")
+	ErrCompression    = errors.New("archive: unsupported compression")
+	ErrChainKind      = errors.New("archive: chain kind mismatch")
+	ErrChecksum       = errors.New("archive: segment checksum mismatch")
+	ErrSegmentSize    = errors.New("archive: invalid segment size")
+	ErrExporterClosed = errors.New("archive: exporter closed")
+)
+
+// Kind identifies the chain an archive was exported from.
+type Kind uint8
+
+const (
+	MainChain Kind = iota // blocks are types.Block
+	SubChain              // blocks are scs.Block
+)
+
+func (k Kind) String() string {
+	switch k {
+	case MainChain:
+		return "main chain"
+	case SubChain:
+		return "subchain"
+	default:
+		return fmt.Sprintf("kind(%d)", uint8(k))
+	}
+}
+
+// Compression is the codec applied to segment payloads.
+type Compression uint8
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+)
+
+// Header describes the contents of an archive.
+type Header struct {
+	Version     uint
+	Kind        Kind
+	Compression Compression
+	SegmentSize uint
+}
+
+// segment is the wire form of a run of entries.
+type segment struct {
+	Count    uint
+	Checksum common.Hash
+	Data     []byte
+}
+
+// mainEntry and scsEntry are the entries of main chain and subchain archives.
+type mainEntry struct {
+	Block    *types.Block
+	Receipts []*types.ReceiptForStorage
+}
+
+type scsEntry struct {
+	Block    *scs.Block
+	Receipts []*scs.ReceiptForStorage
+}
+
+// compress applies c to data.
+func compress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, ErrCompression
+	}
+}
+
+// decompress reverses compress, failing if the result exceeds limit bytes.
+func decompress(c Compression, data []byte, limit int) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		if len(data) > limit {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrSegmentSize, limit)
+		}
+		return data, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		out, err := ioutil.ReadAll(io.LimitReader(zr, int64(limit)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(out) > limit {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrSegmentSize, limit)
+		}
+		return out, nil
+	default:
+		return nil, ErrCompression
+	}
+}
+
+// readMagic consumes the archive magic from r.
+func readMagic(r io.Reader) error {
+	var have [len(magic)]byte
+	if _, err := io.ReadFull(r, have[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrBadMagic
+		}
+		return err
+	}
+	if have != magic {
+		return ErrBadMagic
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+var testAddr = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+func testChain(t *testing.T, n int) ([]*types.Block, []types.Receipts) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewPanguSigner(big.NewInt(101))
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		parent   common.Hash
+	)
+	for i := 0; i < n; i++ {
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), testAddr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipt := types.NewReceipt(nil, false, big.NewInt(21000))
+		receipt.TxHash, receipt.GasUsed = tx.Hash(), big.NewInt(21000)
+		receipt.Logs = []*types.Log{{Address: testAddr, Data: []byte{byte(i)}}}
+		header := &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Time: big.NewInt(int64(i * 10)), Difficulty: big.NewInt(1), GasLimit: big.NewInt(9000000), GasUsed: big.NewInt(21000)}
+		block := types.NewBlock(header, []*types.Transaction{tx}, nil, types.Receipts{receipt})
+		blocks, receipts = append(blocks, block), append(receipts, types.Receipts{receipt})
+		parent = block.Hash()
+	}
+	return blocks, receipts
+}
+
+func exportChain(t *testing.T, cfg Config, blocks []*types.Block, receipts []types.Receipts) []byte {
+	var buf bytes.Buffer
+	e, err := NewExporter(&buf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, block := range blocks {
+		if err := e.WriteBlock(block, receipts[i]); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	blocks, receipts := testChain(t, 7)
+	for _, cfg := range []Config{
+		{Kind: MainChain},
+		{Kind: MainChain, SegmentSize: 3},
+		{Kind: MainChain, SegmentSize: 3, Compression: CompressionGzip},
+	} {
+		data := exportChain(t, cfg, blocks, receipts)
+		imp, err := NewImporter(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%+v: %v", cfg, err)
+		}
+		if h := imp.Header(); h.Kind != MainChain || h.Compression != cfg.Compression || h.Version != Version {
+			t.Fatalf("%+v: header mismatch: %+v", cfg, h)
+		}
+		for i := range blocks {
+			block, recs, err := imp.Next()
+			if err != nil {
+				t.Fatalf("%+v: block %d: %v", cfg, i, err)
+			}
+			if block.Hash() != blocks[i].Hash() || len(block.Transactions()) != 1 {
+				t.Fatalf("%+v: block %d mismatch", cfg, i)
+			}
+			if types.DeriveSha(recs) != block.ReceiptHash() {
+				t.Fatalf("%+v: block %d: receipt root mismatch", cfg, i)
+			}
+			if recs[0].TxHash != receipts[i][0].TxHash || recs[0].Logs[0].Data[0] != byte(i) {
+				t.Fatalf("%+v: block %d: receipt fields lost", cfg, i)
+			}
+		}
+		if _, _, err := imp.Next(); err != io.EOF {
+			t.Fatalf("%+v: end of archive error mismatch: have %v, want %v", cfg, err, io.EOF)
+		}
+	}
+}
+
+func TestScsRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tx, err := scs.SignTx(scs.NewTransaction(0, testAddr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil), scs.NewPanguSigner(big.NewInt(101)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := scs.NewReceipt(nil, false, big.NewInt(21000))
+	receipt.TxHash, receipt.GasUsed = tx.Hash(), big.NewInt(21000)
+	header := &scs.Header{Number: big.NewInt(4), Time: big.NewInt(60), Difficulty: big.NewInt(1), GasLimit: big.NewInt(9000000), GasUsed: big.NewInt(21000)}
+	block := scs.NewBlock(header, []*scs.Transaction{tx}, nil, scs.Receipts{receipt})
+
+	var buf bytes.Buffer
+	e, err := NewExporter(&buf, Config{Kind: SubChain, Compression: CompressionGzip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteBlock(nil, nil); !errors.Is(err, ErrChainKind) {
+		t.Fatalf("kind error mismatch: have %v, want %v", err, ErrChainKind)
+	}
+	if err := e.WriteScsBlock(block, scs.Receipts{receipt}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteScsBlock(block, nil); err != ErrExporterClosed {
+		t.Fatalf("closed error mismatch: have %v, want %v", err, ErrExporterClosed)
+	}
+
+	imp, err := NewImporter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := imp.Next(); !errors.Is(err, ErrChainKind) {
+		t.Fatalf("kind error mismatch: have %v, want %v", err, ErrChainKind)
+	}
+	dec, recs, err := imp.NextScs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Hash() != block.Hash() || scs.DeriveSha(recs) != block.ReceiptHash() {
+		t.Fatalf("block mismatch")
+	}
+	if _, _, err := imp.NextScs(); err != io.EOF {
+		t.Fatalf("end of archive error mismatch: have %v, want %v", err, io.EOF)
+	}
+}
+
+func TestCorruption(t *testing.T) {
+	blocks, receipts := testChain(t, 4)
+	data := exportChain(t, Config{Kind: MainChain, SegmentSize: 2}, blocks, receipts)
+
+	if _, err := NewImporter(bytes.NewReader(data[1:])); err != ErrBadMagic {
+		t.Fatalf("magic error mismatch: have %v, want %v", err, ErrBadMagic)
+	}
+	// Damage the last byte, which belongs to the second segment: the first
+	// segment still imports.
+	bad := common.CopyBytes(data)
+	bad[len(bad)-1] ^= 0xff
+	imp, err := NewImporter(bytes.NewReader(bad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := imp.Next(); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+	if _, _, err := imp.Next(); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("checksum error mismatch: have %v, want %v", err, ErrChecksum)
+	}
+	// A truncated stream is an error, not a clean end.
+	imp, _ = NewImporter(bytes.NewReader(data[:len(data)-1]))
+	imp.Next()
+	imp.Next()
+	if _, _, err := imp.Next(); err == nil || err == io.EOF {
+		t.Fatalf("truncated archive error mismatch: have %v", err)
+	}
+}
+
+func TestSegmentLimits(t *testing.T) {
+	// A segment whose data holds more entries than its count is rejected,
+	// even with a valid checksum.
+	blocks, receipts := testChain(t, 2)
+	var entries []byte
+	for i, block := range blocks {
+		enc, err := rlp.EncodeToBytes(&mainEntry{Block: block, Receipts: []*types.ReceiptForStorage{(*types.ReceiptForStorage)(receipts[i][0])}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, enc...)
+	}
+	var buf bytes.Buffer
+	buf.Write(magic[:])
+	rlp.Encode(&buf, &Header{Version: Version, Kind: MainChain, SegmentSize: 2})
+	rlp.Encode(&buf, &segment{Count: 1, Checksum: crypto.Keccak256Hash(entries), Data: entries})
+	imp, err := NewImporter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := imp.Next(); !errors.Is(err, ErrSegmentSize) {
+		t.Fatalf("trailing entry error mismatch: have %v, want %v", err, ErrSegmentSize)
+	}
+
+	// A segment claiming more data than allowed is rejected before the data
+	// is read, also from a reader of unknown length.
+	buf.Reset()
+	buf.Write(magic[:])
+	rlp.Encode(&buf, &Header{Version: Version, Kind: MainChain, SegmentSize: 2})
+	buf.Write([]byte{0xfd, 0x01, 0x00, 0x00, 0x00, 0x00, 0x10}) // list of 1 TB
+	imp, err = NewImporter(io.MultiReader(&buf, bytes.NewReader(make([]byte, 64))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := imp.Next(); !errors.Is(err, ErrSegmentSize) {
+		t.Fatalf("oversized segment error mismatch: have %v, want %v", err, ErrSegmentSize)
+	}
+
+	// Decompression stops at the limit.
+	data, err := compress(CompressionGzip, make([]byte, 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decompress(CompressionGzip, data, 999); !errors.Is(err, ErrSegmentSize) {
+		t.Fatalf("oversized segment error mismatch: have %v, want %v", err, ErrSegmentSize)
+	}
+	if out, err := decompress(CompressionGzip, data, 1000); err != nil || len(out) != 1000 {
+		t.Fatalf("segment at limit: have %d bytes, %v", len(out), err)
+	}
+}
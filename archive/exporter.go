@@ -0,0 +1,160 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// Config sets up an Exporter.
+type Config struct {
+	Kind        Kind
+	Compression Compression
+	SegmentSize uint // blocks per segment, DefaultSegmentSize if zero
+}
+
+// Exporter writes blocks and receipts to an archive stream. Blocks are
+// buffered until a segment is full; Close must be called to write the last
+// segment. The Exporter does not close the underlying writer.
+type Exporter struct {
+	w      io.Writer
+	header Header
+	buf    bytes.Buffer
+	count  uint
+	closed bool
+}
+
+// NewExporter writes the archive header to w and returns an Exporter for the
+// chain kind given in cfg.
+func NewExporter(w io.Writer, cfg Config) (*Exporter, error) {
+	if cfg.Kind != MainChain && cfg.Kind != SubChain {
+		return nil, fmt.Errorf("%w: %v", ErrChainKind, cfg.Kind)
+	}
+	if cfg.Compression != CompressionNone && cfg.Compression != CompressionGzip {
+		return nil, ErrCompression
+	}
+	if cfg.SegmentSize == 0 {
+		cfg.SegmentSize = DefaultSegmentSize
+	}
+	e := &Exporter{
+		w: w,
+		header: Header{
+			Version:     Version,
+			Kind:        cfg.Kind,
+			Compression: cfg.Compression,
+			SegmentSize: cfg.SegmentSize,
+		},
+	}
+	if _, err := w.Write(magic[:]); err != nil {
+		return nil, err
+	}
+	if err := rlp.Encode(w, &e.header); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Header returns the header written to the archive.
+func (e *Exporter) Header() Header {
+	return e.header
+}
+
+// WriteBlock appends a main chain block and its receipts to the archive.
+func (e *Exporter) WriteBlock(block *types.Block, receipts types.Receipts) error {
+	if e.header.Kind != MainChain {
+		return fmt.Errorf("%w: have %v, want %v", ErrChainKind, MainChain, e.header.Kind)
+	}
+	entry := mainEntry{Block: block, Receipts: make([]*types.ReceiptForStorage, len(receipts))}
+	for i, r := range receipts {
+		entry.Receipts[i] = (*types.ReceiptForStorage)(r)
+	}
+	return e.append(&entry)
+}
+
+// WriteScsBlock appends a subchain block and its receipts to the archive.
+func (e *Exporter) WriteScsBlock(block *scs.Block, receipts scs.Receipts) error {
+	if e.header.Kind != SubChain {
+		return fmt.Errorf("%w: have %v, want %v", ErrChainKind, SubChain, e.header.Kind)
+	}
+	entry := scsEntry{Block: block, Receipts: make([]*scs.ReceiptForStorage, len(receipts))}
+	for i, r := range receipts {
+		entry.Receipts[i] = (*scs.ReceiptForStorage)(r)
+	}
+	return e.append(&entry)
+}
+
+func (e *Exporter) append(entry interface{}) error {
+	if e.closed {
+		return ErrExporterClosed
+	}
+	enc, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		return err
+	}
+	if len(enc) > MaxSegmentData {
+		return fmt.Errorf("%w: entry of %d bytes", ErrSegmentSize, len(enc))
+	}
+	if e.buf.Len()+len(enc) > MaxSegmentData {
+		if err := e.Flush(); err != nil {
+			return err
+		}
+	}
+	e.buf.Write(enc)
+	e.count++
+	if e.count == e.header.SegmentSize {
+		return e.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered blocks as a segment, even if it is not full.
+func (e *Exporter) Flush() error {
+	if e.count == 0 {
+		return nil
+	}
+	data, err := compress(e.header.Compression, e.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	seg := segment{
+		Count:    e.count,
+		Checksum: crypto.Keccak256Hash(e.buf.Bytes()),
+		Data:     data,
+	}
+	if err := rlp.Encode(e.w, &seg); err != nil {
+		return err
+	}
+	e.buf.Reset()
+	e.count = 0
+	return nil
+}
+
+// Close flushes the last segment. Further writes fail with ErrExporterClosed.
+func (e *Exporter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.Flush()
+}
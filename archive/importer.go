@@ -0,0 +1,152 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// Importer reads blocks and receipts back from an archive stream, one segment
+// at a time.
+type Importer struct {
+	stream  *rlp.Stream
+	header  Header
+	segment *rlp.Stream // entries of the current segment
+	left    uint        // entries left in the current segment
+	index   uint64      // number of segments read
+}
+
+// NewImporter reads and checks the archive header from r.
+func NewImporter(r io.Reader) (*Importer, error) {
+	if err := readMagic(r); err != nil {
+		return nil, err
+	}
+	i := &Importer{stream: rlp.NewStream(r, 0)}
+	if err := i.stream.Decode(&i.header); err != nil {
+		return nil, fmt.Errorf("archive: invalid header: %v", err)
+	}
+	if i.header.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, i.header.Version)
+	}
+	if i.header.Kind != MainChain && i.header.Kind != SubChain {
+		return nil, fmt.Errorf("%w: %v", ErrChainKind, i.header.Kind)
+	}
+	if i.header.Compression != CompressionNone && i.header.Compression != CompressionGzip {
+		return nil, ErrCompression
+	}
+	if i.header.SegmentSize == 0 {
+		return nil, ErrSegmentSize
+	}
+	return i, nil
+}
+
+// Header returns the archive header.
+func (i *Importer) Header() Header {
+	return i.header
+}
+
+// Next returns the next main chain block and its receipts. It returns io.EOF
+// once the archive is exhausted.
+func (i *Importer) Next() (*types.Block, types.Receipts, error) {
+	if i.header.Kind != MainChain {
+		return nil, nil, fmt.Errorf("%w: have %v, want %v", ErrChainKind, i.header.Kind, MainChain)
+	}
+	var entry mainEntry
+	if err := i.next(&entry); err != nil {
+		return nil, nil, err
+	}
+	receipts := make(types.Receipts, len(entry.Receipts))
+	for j, r := range entry.Receipts {
+		receipts[j] = (*types.Receipt)(r)
+	}
+	return entry.Block, receipts, nil
+}
+
+// NextScs returns the next subchain block and its receipts. It returns
+// io.EOF once the archive is exhausted.
+func (i *Importer) NextScs() (*scs.Block, scs.Receipts, error) {
+	if i.header.Kind != SubChain {
+		return nil, nil, fmt.Errorf("%w: have %v, want %v", ErrChainKind, i.header.Kind, SubChain)
+	}
+	var entry scsEntry
+	if err := i.next(&entry); err != nil {
+		return nil, nil, err
+	}
+	receipts := make(scs.Receipts, len(entry.Receipts))
+	for j, r := range entry.Receipts {
+		receipts[j] = (*scs.Receipt)(r)
+	}
+	return entry.Block, receipts, nil
+}
+
+// next decodes the next entry into val, loading a new segment if needed.
+func (i *Importer) next(val interface{}) error {
+	if i.left == 0 {
+		if err := i.readSegment(); err != nil {
+			return err
+		}
+	}
+	if err := i.segment.Decode(val); err != nil {
+		return fmt.Errorf("archive: segment %d: invalid entry: %v", i.index-1, err)
+	}
+	i.left--
+	return nil
+}
+
+// readSegment reads, decompresses and verifies the next segment.
+func (i *Importer) readSegment() error {
+	// Check the size before decoding, the stream itself is unbounded.
+	kind, size, err := i.stream.Kind()
+	if err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return fmt.Errorf("archive: segment %d: %v", i.index, err)
+	}
+	if kind != rlp.List || size > maxSegmentEncoding {
+		return fmt.Errorf("%w: segment %d of %d bytes", ErrSegmentSize, i.index, size)
+	}
+	var seg segment
+	if err := i.stream.Decode(&seg); err != nil {
+		return fmt.Errorf("archive: segment %d: %v", i.index, err)
+	}
+	if seg.Count == 0 || seg.Count > i.header.SegmentSize {
+		return fmt.Errorf("%w: segment %d holds %d entries", ErrSegmentSize, i.index, seg.Count)
+	}
+	data, err := decompress(i.header.Compression, seg.Data, MaxSegmentData)
+	if err != nil {
+		return fmt.Errorf("archive: segment %d: %w", i.index, err)
+	}
+	if sum := crypto.Keccak256Hash(data); sum != seg.Checksum {
+		return fmt.Errorf("%w: segment %d: have %x, want %x", ErrChecksum, i.index, sum, seg.Checksum)
+	}
+	// The entries must add up to the data, without bytes left over.
+	if n, err := rlp.CountValues(data); err != nil || uint(n) != seg.Count {
+		return fmt.Errorf("%w: segment %d holds %d entries, data has %d (%v)", ErrSegmentSize, i.index, seg.Count, n, err)
+	}
+	i.segment = rlp.NewStream(bytes.NewReader(data), 0)
+	i.left = seg.Count
+	i.index++
+	return nil
+}
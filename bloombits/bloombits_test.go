@@ -0,0 +1,208 @@
+package bloombits
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/mcdb"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// Tests that batched bloom bits are correctly rotated from the input bloom
+// filters.
+func TestGenerator(t *testing.T) {
+	// Generate the input and the rotated output
+	var input, output [types.BloomBitLength][types.BloomByteLength]byte
+
+	for i := 0; i < types.BloomBitLength; i++ {
+		for j := 0; j < types.BloomBitLength; j++ {
+			bit := byte(rand.Int() % 2)
+
+			input[i][j/8] |= bit << byte(7-j%8)
+			output[types.BloomBitLength-1-j][i/8] |= bit << byte(7-i%8)
+		}
+	}
+	// Crunch the input through the generator and verify the result
+	gen, err := NewGenerator(types.BloomBitLength)
+	if err != nil {
+		t.Fatalf("failed to create bloombit generator: %v", err)
+	}
+	for i, bloom := range input {
+		if err := gen.AddBloom(uint(i), bloom); err != nil {
+			t.Fatalf("bloom %d: failed to add: %v", i, err)
+		}
+	}
+	for i, want := range output {
+		have, err := gen.Bitset(uint(i))
+		if err != nil {
+			t.Fatalf("output %d: failed to retrieve bits: %v", i, err)
+		}
+		if !bytes.Equal(have, want[:]) {
+			t.Errorf("output %d: bit vector mismatch have %x, want %x", i, have, want)
+		}
+	}
+}
+
+// testHeaders creates a chain of headers whose blooms contain a few random
+// keys out of a small set.
+func testHeaders(n int, keys [][]byte) []*types.Header {
+	rnd := rand.New(rand.NewSource(1))
+	headers := make([]*types.Header, n)
+	for i := range headers {
+		bin := new(big.Int)
+		for j := 0; j < rnd.Intn(3); j++ {
+			bin.Or(bin, types.Bloom9(keys[rnd.Intn(len(keys))]))
+		}
+		headers[i] = &types.Header{Number: big.NewInt(int64(i)), Bloom: types.BytesToBloom(bin.Bytes())}
+	}
+	return headers
+}
+
+func testKeys() [][]byte {
+	var keys [][]byte
+	for i := 0; i < 16; i++ {
+		keys = append(keys, common.BigToAddress(big.NewInt(int64(i+1))).Bytes())
+	}
+	return keys
+}
+
+// bruteForce returns the blocks between begin and end matching the filter
+// by checking every header bloom.
+func bruteForce(headers []*types.Header, filters [][][]byte, begin, end uint64) []uint64 {
+	var matches []uint64
+	for n := begin; n <= end && n < uint64(len(headers)); n++ {
+		match := true
+		for _, group := range filters {
+			if len(group) == 0 {
+				continue
+			}
+			found := false
+			for _, key := range group {
+				if types.BloomLookup(headers[n].Bloom, bytesBacked(key)) {
+					found = true
+				}
+			}
+			if !found {
+				match = false
+				break
+			}
+		}
+		if match {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+type bytesBacked []byte
+
+func (b bytesBacked) Bytes() []byte { return b }
+
+func TestMatcher(t *testing.T) {
+	const sectionSize = 64
+	keys := testKeys()
+	headers := testHeaders(5*sectionSize+17, keys)
+
+	db, _ := mcdb.NewMemDatabase()
+	index, err := NewIndex(db, sectionSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexer := NewIndexer(index)
+	for _, header := range headers {
+		if err := indexer.Process(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if index.Sections() != 5 {
+		t.Fatalf("have %d sections, want 5", index.Sections())
+	}
+	filters := [][][][]byte{
+		{{keys[0]}},
+		{{keys[1], keys[2]}},
+		{{keys[3]}, {keys[4], keys[5]}},
+		{nil, {keys[6]}},
+		{{keys[7]}, {}},
+		{},
+	}
+	for i, filter := range filters {
+		for _, r := range [][2]uint64{{0, 1000}, {10, 200}, {70, 70}, {130, 319}} {
+			have, indexed, err := NewMatcher(filter).Execute(context.Background(), index, r[0], r[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if indexed != 5*sectionSize {
+				t.Errorf("indexed until %d, want %d", indexed, 5*sectionSize)
+			}
+			end := r[1]
+			if end >= indexed {
+				end = indexed - 1
+			}
+			want := bruteForce(headers, filter, r[0], end)
+			if len(have) != len(want) {
+				t.Errorf("filter %d, range %v: have %d candidates, want %d", i, r, len(have), len(want))
+				continue
+			}
+			for j := range have {
+				if have[j] != want[j] {
+					t.Errorf("filter %d, range %v: candidate %d is %d, want %d", i, r, j, have[j], want[j])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestIndexPersistence(t *testing.T) {
+	const sectionSize = 16
+	headers := testHeaders(3*sectionSize, testKeys())
+
+	db, _ := mcdb.NewMemDatabase()
+	index, _ := NewIndex(db, sectionSize)
+	indexer := NewIndexer(index)
+	for _, header := range headers[:2*sectionSize] {
+		if err := indexer.Process(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reopen the index and continue where it left off
+	index, err := NewIndex(db, sectionSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Sections() != 2 {
+		t.Fatalf("have %d sections after reopening, want 2", index.Sections())
+	}
+	if head, _ := index.SectionHead(1); head != headers[2*sectionSize-1].Hash() {
+		t.Errorf("wrong section head %x", head)
+	}
+	indexer = NewIndexer(index)
+	if indexer.Next() != 2*sectionSize {
+		t.Fatalf("indexer resumes at %d", indexer.Next())
+	}
+	if err := indexer.Process(headers[0]); err == nil {
+		t.Error("expected error for out of order header")
+	}
+	for _, header := range headers[2*sectionSize:] {
+		if err := indexer.Process(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Rewind into the second section and check the index shrinks
+	if err := indexer.Rewind(sectionSize + 3); err != nil {
+		t.Fatal(err)
+	}
+	if index.Sections() != 1 || indexer.Next() != sectionSize {
+		t.Errorf("after rewind: %d sections, next %d", index.Sections(), indexer.Next())
+	}
+	if _, err := index.Bitset(0, 1); err != errSectionOutOfBounds {
+		t.Errorf("expected errSectionOutOfBounds, got %v", err)
+	}
+	if _, err := NewIndex(db, 2*sectionSize); err != errSectionSize {
+		t.Errorf("expected errSectionSize, got %v", err)
+	}
+}
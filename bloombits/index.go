@@ -0,0 +1,233 @@
+package bloombits
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/common/bitutil"
+	"github.com/MOACChain/MoacLib/mcdb"
+	"github.com/MOACChain/MoacLib/params"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// TablePrefix is the prefix of the mcdb table the index is stored in.
+const TablePrefix = "bloombits-"
+
+var (
+	sectionCountKey  = []byte("count") // number of completed sections
+	sectionSizeKey   = []byte("size")  // number of blocks per section
+	sectionHeadKey   = []byte("h")     // h + section (uint64 big endian) -> last header hash of the section
+	sectionVectorKey = []byte("v")     // v + bit (uint16 big endian) + section (uint64 big endian) -> compressed vector
+
+	errSectionSize = errors.New("bloombits: section size mismatch")
+)
+
+// Index is a bloombits index persisted in a table of an mcdb database. The
+// vectors of a section are only written once the section is complete, so
+// the index covers the blocks below Sections()*SectionSize().
+type Index struct {
+	db          mcdb.Database
+	sectionSize uint64
+
+	lock     sync.RWMutex
+	sections uint64
+}
+
+// NewIndex opens the bloombits index stored in db, creating it with the
+// given section size if it does not exist yet. A section size of zero
+// selects params.BloomBitsBlocks.
+func NewIndex(db mcdb.Database, sectionSize uint64) (*Index, error) {
+	if sectionSize == 0 {
+		sectionSize = params.BloomBitsBlocks
+	}
+	if sectionSize%8 != 0 {
+		return nil, errors.New("bloombits: section size not multiple of 8")
+	}
+	index := &Index{db: mcdb.NewTable(db, TablePrefix), sectionSize: sectionSize}
+
+	if blob, _ := index.db.Get(sectionSizeKey); len(blob) == 8 {
+		if binary.BigEndian.Uint64(blob) != sectionSize {
+			return nil, errSectionSize
+		}
+	} else if err := index.db.Put(sectionSizeKey, encodeUint64(sectionSize)); err != nil {
+		return nil, err
+	}
+	if blob, _ := index.db.Get(sectionCountKey); len(blob) == 8 {
+		index.sections = binary.BigEndian.Uint64(blob)
+	}
+	return index, nil
+}
+
+// SectionSize returns the number of blocks in a section.
+func (i *Index) SectionSize() uint64 {
+	return i.sectionSize
+}
+
+// Sections returns the number of completed sections.
+func (i *Index) Sections() uint64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.sections
+}
+
+// SectionHead returns the hash of the last header of a completed section.
+func (i *Index) SectionHead(section uint64) (common.Hash, error) {
+	if section >= i.Sections() {
+		return common.Hash{}, errSectionOutOfBounds
+	}
+	blob, err := i.db.Get(headKey(section))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(blob), nil
+}
+
+// Bitset returns the vector of the given bloom bit for a completed section.
+func (i *Index) Bitset(bit uint, section uint64) ([]byte, error) {
+	if bit >= types.BloomBitLength {
+		return nil, errBloomBitOutOfBounds
+	}
+	if section >= i.Sections() {
+		return nil, errSectionOutOfBounds
+	}
+	blob, err := i.db.Get(vectorKey(bit, section))
+	if err != nil {
+		return nil, fmt.Errorf("bloombits: missing vector %d of section %d: %v", bit, section, err)
+	}
+	return bitutil.DecompressBytes(blob, int(i.sectionSize/8))
+}
+
+// StoreSection writes the vectors of a fully generated section. Sections
+// have to be stored in order; storing a section below the current count
+// replaces it and discards all sections above it.
+func (i *Index) StoreSection(section uint64, head common.Hash, gen *Generator) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if section > i.sections {
+		return errSectionOutOfBounds
+	}
+	if uint64(gen.sections) != i.sectionSize {
+		return errSectionSize
+	}
+	batch := i.db.NewBatch()
+	for bit := uint(0); bit < types.BloomBitLength; bit++ {
+		vector, err := gen.Bitset(bit)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(vectorKey(bit, section), bitutil.CompressBytes(vector)); err != nil {
+			return err
+		}
+	}
+	if err := batch.Put(headKey(section), head.Bytes()); err != nil {
+		return err
+	}
+	if err := batch.Put(sectionCountKey, encodeUint64(section+1)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	i.sections = section + 1
+	return nil
+}
+
+// Truncate discards all sections from the given one on, e.g. when a chain
+// reorganisation rewrote blocks they cover.
+func (i *Index) Truncate(sections uint64) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if sections >= i.sections {
+		return nil
+	}
+	if err := i.db.Put(sectionCountKey, encodeUint64(sections)); err != nil {
+		return err
+	}
+	i.sections = sections
+	return nil
+}
+
+// Indexer builds an Index from a sequence of headers.
+type Indexer struct {
+	index *Index
+	gen   *Generator
+	next  uint64 // number of the next expected header
+}
+
+// NewIndexer creates an indexer continuing after the last completed
+// section of index.
+func NewIndexer(index *Index) *Indexer {
+	return &Indexer{index: index, next: index.Sections() * index.sectionSize}
+}
+
+// Next returns the number of the header the indexer expects next.
+func (ix *Indexer) Next() uint64 {
+	return ix.next
+}
+
+// Process adds a header to the index, committing its section once it is
+// complete. Headers have to be processed in order.
+func (ix *Indexer) Process(header *types.Header) error {
+	if number := header.Number.Uint64(); number != ix.next {
+		return fmt.Errorf("bloombits: unexpected header %d, want %d", number, ix.next)
+	}
+	if ix.gen == nil {
+		gen, err := NewGenerator(uint(ix.index.sectionSize))
+		if err != nil {
+			return err
+		}
+		ix.gen = gen
+	}
+	if err := ix.gen.AddBloom(uint(ix.next%ix.index.sectionSize), header.Bloom); err != nil {
+		return err
+	}
+	ix.next++
+	if ix.next%ix.index.sectionSize == 0 {
+		if err := ix.index.StoreSection(ix.next/ix.index.sectionSize-1, header.Hash(), ix.gen); err != nil {
+			return err
+		}
+		ix.gen = nil
+	}
+	return nil
+}
+
+// Rewind restarts indexing at the section containing the given block,
+// discarding it and all later sections.
+func (ix *Indexer) Rewind(number uint64) error {
+	section := number / ix.index.sectionSize
+	if err := ix.index.Truncate(section); err != nil {
+		return err
+	}
+	if start := section * ix.index.sectionSize; start < ix.next {
+		ix.next = start
+		ix.gen = nil
+	}
+	return nil
+}
+
+func headKey(section uint64) []byte {
+	key := make([]byte, len(sectionHeadKey)+8)
+	copy(key, sectionHeadKey)
+	binary.BigEndian.PutUint64(key[len(sectionHeadKey):], section)
+	return key
+}
+
+func vectorKey(bit uint, section uint64) []byte {
+	key := make([]byte, len(sectionVectorKey)+10)
+	copy(key, sectionVectorKey)
+	binary.BigEndian.PutUint16(key[len(sectionVectorKey):], uint16(bit))
+	binary.BigEndian.PutUint64(key[len(sectionVectorKey)+2:], section)
+	return key
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
@@ -0,0 +1,147 @@
+package bloombits
+
+import (
+	"context"
+
+	"github.com/MOACChain/MoacLib/common/bitutil"
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+// Retriever provides the bit vectors of the completed sections of a
+// bloombits index. *Index implements it.
+type Retriever interface {
+	SectionSize() uint64
+	Sections() uint64
+	Bitset(bit uint, section uint64) ([]byte, error)
+}
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given key.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8)&2047 + uint(b[2*i+1])
+	}
+	return idxs
+}
+
+// Matcher finds the blocks whose header blooms may match a filter, reading
+// only the bit vectors the filter criteria map to.
+type Matcher struct {
+	filters [][]bloomIndexes // Filter the system is matching for
+}
+
+// NewMatcher creates a new matcher for the given filter groups. A block
+// matches if, for every group, its bloom contains at least one of the
+// group's keys; this mirrors log filtering where the first group is usually
+// the addresses and the following ones the topic positions. Empty groups
+// are wildcards.
+func NewMatcher(filters [][][]byte) *Matcher {
+	m := new(Matcher)
+	for _, filter := range filters {
+		// Gather the bit indexes of the filter rule, special casing the nil filter
+		if len(filter) == 0 {
+			continue
+		}
+		bloomBits := make([]bloomIndexes, len(filter))
+		for i, clause := range filter {
+			if clause == nil {
+				bloomBits = nil
+				break
+			}
+			bloomBits[i] = calcBloomIndexes(clause)
+		}
+		// Accumulate the filter rules if no nil rule was within
+		if bloomBits != nil {
+			m.filters = append(m.filters, bloomBits)
+		}
+	}
+	return m
+}
+
+// Execute returns the numbers of the candidate blocks between begin and end,
+// both inclusive, that are covered by the index. It also returns the first
+// block number not covered by the index; blocks from there on have to be
+// checked by other means.
+func (m *Matcher) Execute(ctx context.Context, r Retriever, begin, end uint64) ([]uint64, uint64, error) {
+	size := r.SectionSize()
+	indexed := r.Sections() * size
+	if indexed == 0 || begin >= indexed {
+		return nil, indexed, nil
+	}
+	if end >= indexed {
+		end = indexed - 1
+	}
+	var candidates []uint64
+	for section := begin / size; section <= end/size; section++ {
+		if err := ctx.Err(); err != nil {
+			return nil, indexed, err
+		}
+		vector, err := m.match(r, section)
+		if err != nil {
+			return nil, indexed, err
+		}
+		first := section * size
+		for i := uint64(0); i < size; i++ {
+			number := first + i
+			if number < begin || number > end {
+				continue
+			}
+			if vector == nil || vector[i/8]&(byte(1)<<(7-i%8)) != 0 {
+				candidates = append(candidates, number)
+			}
+		}
+	}
+	return candidates, indexed, nil
+}
+
+// match computes the candidate vector of a section, or nil if every block
+// of the section is a candidate.
+func (m *Matcher) match(r Retriever, section uint64) ([]byte, error) {
+	cache := make(map[uint][]byte)
+	bitset := func(bit uint) ([]byte, error) {
+		if vector, ok := cache[bit]; ok {
+			return vector, nil
+		}
+		vector, err := r.Bitset(bit, section)
+		if err != nil {
+			return nil, err
+		}
+		cache[bit] = vector
+		return vector, nil
+	}
+	var result []byte
+	for _, filter := range m.filters {
+		group := make([]byte, r.SectionSize()/8)
+		for _, idxs := range filter {
+			// A key is present if all three of its bits are set
+			key, err := bitset(idxs[0])
+			if err != nil {
+				return nil, err
+			}
+			key = append([]byte{}, key...)
+			for _, bit := range idxs[1:] {
+				vector, err := bitset(bit)
+				if err != nil {
+					return nil, err
+				}
+				bitutil.ANDBytes(key, key, vector)
+			}
+			bitutil.ORBytes(group, group, key)
+		}
+		if result == nil {
+			result = group
+		} else {
+			bitutil.ANDBytes(result, result, group)
+		}
+		if !bitutil.TestBytes(result) {
+			break // nothing left to match in this section
+		}
+	}
+	return result, nil
+}
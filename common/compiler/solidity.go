@@ -32,8 +32,9 @@ import (
 var versionRegexp = regexp.MustCompile(`([0-9]+)\.([0-9]+)\.([0-9]+)`)
 
 type Contract struct {
-	Code string       `json:"code"`
-	Info ContractInfo `json:"info"`
+	Code        string       `json:"code"`
+	RuntimeCode string       `json:"runtime-code"`
+	Info        ContractInfo `json:"info"`
 }
 
 type ContractInfo struct {
@@ -42,6 +43,8 @@ type ContractInfo struct {
 	LanguageVersion string      `json:"languageVersion"`
 	CompilerVersion string      `json:"compilerVersion"`
 	CompilerOptions string      `json:"compilerOptions"`
+	SrcMap          string      `json:"srcMap"`
+	SrcMapRuntime   string      `json:"srcMapRuntime"`
 	AbiDefinition   interface{} `json:"abiDefinition"`
 	UserDoc         interface{} `json:"userDoc"`
 	DeveloperDoc    interface{} `json:"developerDoc"`
@@ -65,15 +68,24 @@ type solcOutput struct {
 func (s *Solidity) makeArgs() []string {
 	p := []string{
 		"--combined-json", "bin,abi,userdoc,devdoc",
-		"--add-std",  // include standard lib contracts
 		"--optimize", // code optimizer switched on
 	}
 	if s.Major > 0 || s.Minor > 4 || s.Patch > 6 {
 		p[1] += ",metadata"
 	}
+	if !s.standardJSON() {
+		p = append(p, "--add-std") // include standard lib contracts
+	}
 	return p
 }
 
+// standardJSON reports whether the compiler should be driven through the
+// standard-JSON interface. Starting with 0.5.0 the legacy --add-std flag is
+// gone and the combined-json output format keeps changing.
+func (s *Solidity) standardJSON() bool {
+	return s.Major > 0 || s.Minor >= 5
+}
+
 // SolidityVersion runs solc and parses its version output.
 func SolidityVersion(solc string) (*Solidity, error) {
 	if solc == "" {
@@ -112,6 +124,9 @@ func CompileSolidityString(solc, source string) (map[string]*Contract, error) {
 	if err != nil {
 		return nil, err
 	}
+	if s.standardJSON() {
+		return s.compileStandard(map[string]string{"<stdin>": source}, &StandardOptions{Optimize: true})
+	}
 	args := append(s.makeArgs(), "--")
 	cmd := exec.Command(s.Path, append(args, "-")...)
 	cmd.Stdin = strings.NewReader(source)
@@ -123,11 +138,14 @@ func CompileSolidity(solc string, sourcefiles ...string) (map[string]*Contract,
 	if len(sourcefiles) == 0 {
 		return nil, errors.New("solc: no source files")
 	}
-	source, err := slurpFiles(sourcefiles)
+	s, err := SolidityVersion(solc)
 	if err != nil {
 		return nil, err
 	}
-	s, err := SolidityVersion(solc)
+	if s.standardJSON() {
+		return CompileStandardJSONFiles(s.Path, &StandardOptions{Optimize: true}, sourcefiles...)
+	}
+	source, err := slurpFiles(sourcefiles)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,297 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/MOACChain/MoacLib/params"
+)
+
+// EVM versions understood by solc that match the opcode sets of the MOAC
+// forks. Pangu added the byzantium opcodes (REVERT, STATICCALL, RETURNDATA*),
+// Fuxi added the constantinople and istanbul ones (shifts, CREATE2,
+// EXTCODEHASH, CHAINID, SELFBALANCE).
+const (
+	EVMVersionPangu = "byzantium"
+	EVMVersionFuxi  = "istanbul"
+)
+
+// EVMVersionAt returns the solc EVM version to target for contracts deployed
+// at the given block number of the chain.
+func EVMVersionAt(config *params.ChainConfig, num *big.Int) string {
+	if config.IsFuxi(num) {
+		return EVMVersionFuxi
+	}
+	return EVMVersionPangu
+}
+
+// StandardOptions configures a standard-JSON compilation.
+type StandardOptions struct {
+	Remappings    []string // import remappings, e.g. "@openzeppelin/=lib/openzeppelin/"
+	Optimize      bool     // enable the optimizer
+	OptimizerRuns int      // optimizer runs, 200 if zero
+	EVMVersion    string   // target EVM version, solc's default if empty (see EVMVersionAt)
+	AllowPaths    []string // directories imports may be resolved from
+	BasePath      string   // root for resolving relative imports
+}
+
+// StandardInput is the solc standard-JSON input description.
+type StandardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]StandardSource `json:"sources"`
+	Settings StandardSettings          `json:"settings"`
+}
+
+// StandardSource is a single source unit of a standard-JSON input.
+type StandardSource struct {
+	Content string   `json:"content,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+}
+
+// StandardSettings are the compiler settings of a standard-JSON input.
+type StandardSettings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       StandardOptimizer              `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// StandardOptimizer holds the optimizer settings of a standard-JSON input.
+type StandardOptimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"`
+}
+
+// StandardError is an error or warning reported by solc.
+type StandardError struct {
+	Type             string `json:"type"`
+	Component        string `json:"component"`
+	Severity         string `json:"severity"`
+	Message          string `json:"message"`
+	FormattedMessage string `json:"formattedMessage"`
+}
+
+func (e StandardError) Error() string {
+	if e.FormattedMessage != "" {
+		return strings.TrimSpace(e.FormattedMessage)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// standardOutput is the subset of the solc standard-JSON output we use.
+type standardOutput struct {
+	Errors    []StandardError                              `json:"errors"`
+	Contracts map[string]map[string]standardContractOutput `json:"contracts"`
+}
+
+type standardContractOutput struct {
+	ABI      json.RawMessage `json:"abi"`
+	Metadata string          `json:"metadata"`
+	Userdoc  json.RawMessage `json:"userdoc"`
+	Devdoc   json.RawMessage `json:"devdoc"`
+	EVM      struct {
+		Bytecode         standardBytecode `json:"bytecode"`
+		DeployedBytecode standardBytecode `json:"deployedBytecode"`
+	} `json:"evm"`
+}
+
+type standardBytecode struct {
+	Object    string `json:"object"`
+	SourceMap string `json:"sourceMap"`
+}
+
+// standardOutputSelection requests everything needed to fill in a Contract.
+var standardOutputSelection = map[string]map[string][]string{
+	"*": {
+		"*": {
+			"abi", "metadata", "userdoc", "devdoc",
+			"evm.bytecode.object", "evm.bytecode.sourceMap",
+			"evm.deployedBytecode.object", "evm.deployedBytecode.sourceMap",
+		},
+	},
+}
+
+// NewStandardInput assembles the standard-JSON input for the given sources,
+// mapping source unit names to their contents.
+func NewStandardInput(sources map[string]string, opts *StandardOptions) *StandardInput {
+	if opts == nil {
+		opts = new(StandardOptions)
+	}
+	in := &StandardInput{
+		Language: "Solidity",
+		Sources:  make(map[string]StandardSource, len(sources)),
+		Settings: StandardSettings{
+			Remappings: opts.Remappings,
+			Optimizer: StandardOptimizer{
+				Enabled: opts.Optimize,
+				Runs:    opts.OptimizerRuns,
+			},
+			EVMVersion:      opts.EVMVersion,
+			OutputSelection: standardOutputSelection,
+		},
+	}
+	if in.Settings.Optimizer.Runs == 0 {
+		in.Settings.Optimizer.Runs = 200
+	}
+	for name, content := range sources {
+		in.Sources[name] = StandardSource{Content: content}
+	}
+	return in
+}
+
+// CompileStandardJSON compiles the given sources, mapping source unit names to
+// their contents, using solc's standard-JSON interface. Contracts are keyed
+// by "<source unit>:<contract name>".
+func CompileStandardJSON(solc string, sources map[string]string, opts *StandardOptions) (map[string]*Contract, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("solc: no sources")
+	}
+	s, err := SolidityVersion(solc)
+	if err != nil {
+		return nil, err
+	}
+	return s.compileStandard(sources, opts)
+}
+
+// CompileStandardJSONFiles compiles the given Solidity files using solc's
+// standard-JSON interface. The file paths are used as source unit names.
+func CompileStandardJSONFiles(solc string, opts *StandardOptions, sourcefiles ...string) (map[string]*Contract, error) {
+	if len(sourcefiles) == 0 {
+		return nil, errors.New("solc: no source files")
+	}
+	sources := make(map[string]string, len(sourcefiles))
+	for _, file := range sourcefiles {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[file] = string(content)
+	}
+	return CompileStandardJSON(solc, sources, opts)
+}
+
+func (s *Solidity) compileStandard(sources map[string]string, opts *StandardOptions) (map[string]*Contract, error) {
+	if opts == nil {
+		opts = new(StandardOptions)
+	}
+	input := NewStandardInput(sources, opts)
+	blob, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--standard-json"}
+	if len(opts.AllowPaths) > 0 {
+		args = append(args, "--allow-paths", strings.Join(opts.AllowPaths, ","))
+	}
+	if opts.BasePath != "" {
+		args = append(args, "--base-path", opts.BasePath)
+	}
+	var stderr, stdout bytes.Buffer
+	cmd := exec.Command(s.Path, args...)
+	cmd.Stdin = bytes.NewReader(blob)
+	cmd.Stderr = &stderr
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+	}
+	settings, _ := json.Marshal(input.Settings)
+	return ParseStandardJSON(stdout.Bytes(), concatSources(sources), s.Version, string(settings))
+}
+
+// ParseStandardJSON parses the standard-JSON output of solc into contracts.
+// source, compilerVersion and compilerOptions are recorded in the contract
+// infos. Any error reported by the compiler is returned, warnings are ignored.
+func ParseStandardJSON(output []byte, source string, compilerVersion string, compilerOptions string) (map[string]*Contract, error) {
+	var out standardOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, fmt.Errorf("solc: error reading standard json output: %v", err)
+	}
+	var errs []string
+	for _, e := range out.Errors {
+		if e.Severity == "error" {
+			errs = append(errs, e.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("solc: compilation failed\n%s", strings.Join(errs, "\n"))
+	}
+	contracts := make(map[string]*Contract)
+	for file, units := range out.Contracts {
+		for name, info := range units {
+			var abi, userdoc, devdoc interface{}
+			if err := unmarshalOptional(info.ABI, &abi); err != nil {
+				return nil, fmt.Errorf("solc: error reading abi definition (%v)", err)
+			}
+			if err := unmarshalOptional(info.Userdoc, &userdoc); err != nil {
+				return nil, fmt.Errorf("solc: error reading user doc: %v", err)
+			}
+			if err := unmarshalOptional(info.Devdoc, &devdoc); err != nil {
+				return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
+			}
+			contracts[file+":"+name] = &Contract{
+				Code:        "0x" + info.EVM.Bytecode.Object,
+				RuntimeCode: "0x" + info.EVM.DeployedBytecode.Object,
+				Info: ContractInfo{
+					Source:          source,
+					Language:        "Solidity",
+					LanguageVersion: compilerVersion,
+					CompilerVersion: compilerVersion,
+					CompilerOptions: compilerOptions,
+					SrcMap:          info.EVM.Bytecode.SourceMap,
+					SrcMapRuntime:   info.EVM.DeployedBytecode.SourceMap,
+					AbiDefinition:   abi,
+					UserDoc:         userdoc,
+					DeveloperDoc:    devdoc,
+					Metadata:        info.Metadata,
+				},
+			}
+		}
+	}
+	return contracts, nil
+}
+
+func unmarshalOptional(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// concatSources joins the sources in name order, mirroring what the legacy
+// interface records as the contract source.
+func concatSources(sources map[string]string) string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var concat bytes.Buffer
+	for _, name := range names {
+		concat.WriteString(sources[name])
+	}
+	return concat.String()
+}
@@ -0,0 +1,159 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/params"
+)
+
+const testStandardOutput = `{
+  "contracts": {
+    "a.sol": {
+      "A": {
+        "abi": [{"inputs":[],"name":"get","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"pure","type":"function"}],
+        "devdoc": {"kind":"dev","methods":{},"version":1},
+        "userdoc": {"kind":"user","methods":{},"version":1},
+        "metadata": "{\"compiler\":{\"version\":\"0.8.4\"}}",
+        "evm": {
+          "bytecode": {"object": "6080604052", "sourceMap": "0:1:0:-:0"},
+          "deployedBytecode": {"object": "6080", "sourceMap": "0:1:0"}
+        }
+      }
+    },
+    "lib/b.sol": {
+      "B": {
+        "abi": [],
+        "evm": {"bytecode": {"object": ""}, "deployedBytecode": {"object": ""}}
+      }
+    }
+  },
+  "errors": [
+    {"severity": "warning", "type": "Warning", "message": "unused variable", "formattedMessage": "Warning: unused variable"}
+  ]
+}`
+
+func TestParseStandardJSON(t *testing.T) {
+	contracts, err := ParseStandardJSON([]byte(testStandardOutput), "source", "0.8.4", "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contracts) != 2 {
+		t.Fatalf("expected 2 contracts, got %d", len(contracts))
+	}
+	c, ok := contracts["a.sol:A"]
+	if !ok {
+		t.Fatal("contract a.sol:A missing")
+	}
+	if c.Code != "0x6080604052" || c.RuntimeCode != "0x6080" {
+		t.Errorf("wrong code: %s / %s", c.Code, c.RuntimeCode)
+	}
+	if c.Info.SrcMap != "0:1:0:-:0" || c.Info.SrcMapRuntime != "0:1:0" {
+		t.Errorf("wrong source maps: %q / %q", c.Info.SrcMap, c.Info.SrcMapRuntime)
+	}
+	if c.Info.CompilerVersion != "0.8.4" || c.Info.Source != "source" {
+		t.Errorf("wrong contract info: %+v", c.Info)
+	}
+	abi, ok := c.Info.AbiDefinition.([]interface{})
+	if !ok || len(abi) != 1 {
+		t.Errorf("wrong abi definition: %v", c.Info.AbiDefinition)
+	}
+	if _, ok := contracts["lib/b.sol:B"]; !ok {
+		t.Error("contract lib/b.sol:B missing")
+	}
+}
+
+func TestParseStandardJSONErrors(t *testing.T) {
+	output := `{"errors":[{"severity":"error","type":"ParserError","message":"Expected ';'","formattedMessage":"ParserError: Expected ';'\n"}]}`
+	_, err := ParseStandardJSON([]byte(output), "", "", "")
+	if err == nil || !strings.Contains(err.Error(), "ParserError: Expected ';'") {
+		t.Fatalf("expected parser error, got %v", err)
+	}
+}
+
+func TestNewStandardInput(t *testing.T) {
+	in := NewStandardInput(map[string]string{"a.sol": "contract A {}"}, &StandardOptions{
+		Remappings: []string{"lib/=deps/lib/"},
+		Optimize:   true,
+		EVMVersion: EVMVersionPangu,
+	})
+	blob, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec map[string]interface{}
+	if err := json.Unmarshal(blob, &dec); err != nil {
+		t.Fatal(err)
+	}
+	settings := dec["settings"].(map[string]interface{})
+	if settings["evmVersion"] != "byzantium" {
+		t.Errorf("wrong evm version: %v", settings["evmVersion"])
+	}
+	optimizer := settings["optimizer"].(map[string]interface{})
+	if optimizer["enabled"] != true || optimizer["runs"] != float64(200) {
+		t.Errorf("wrong optimizer settings: %v", optimizer)
+	}
+	if in.Sources["a.sol"].Content != "contract A {}" {
+		t.Errorf("wrong source: %v", in.Sources)
+	}
+}
+
+func TestNewStandardInputDefaultEVMVersion(t *testing.T) {
+	// Without a target the version is left to solc instead of assuming Fuxi.
+	in := NewStandardInput(map[string]string{"a.sol": "contract A {}"}, nil)
+	blob, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Settings.EVMVersion != "" || strings.Contains(string(blob), "evmVersion") {
+		t.Errorf("evm version set without a target: %s", blob)
+	}
+}
+
+func TestEVMVersionAt(t *testing.T) {
+	config := &params.ChainConfig{PanguBlock: big.NewInt(0), FuxiBlock: big.NewInt(100)}
+	if v := EVMVersionAt(config, big.NewInt(99)); v != EVMVersionPangu {
+		t.Errorf("block 99: have %s, want %s", v, EVMVersionPangu)
+	}
+	if v := EVMVersionAt(config, big.NewInt(100)); v != EVMVersionFuxi {
+		t.Errorf("block 100: have %s, want %s", v, EVMVersionFuxi)
+	}
+}
+
+func TestCompileStandardJSON(t *testing.T) {
+	skipWithoutSolc(t)
+
+	sources := map[string]string{
+		"lib.sol":  "// SPDX-License-Identifier: MIT\npragma solidity >=0.5.0;\nlibrary Lib { function seven() internal pure returns (uint) { return 7; } }\n",
+		"main.sol": "// SPDX-License-Identifier: MIT\npragma solidity >=0.5.0;\nimport \"./lib.sol\";\ncontract Main { function get() public pure returns (uint) { return Lib.seven(); } }\n",
+	}
+	contracts, err := CompileStandardJSON("", sources, &StandardOptions{Optimize: true, EVMVersion: EVMVersionPangu})
+	if err != nil {
+		t.Skipf("solc does not support standard json: %v", err)
+	}
+	c, ok := contracts["main.sol:Main"]
+	if !ok {
+		t.Fatalf("contract main.sol:Main missing: %v", contracts)
+	}
+	if len(c.Code) <= 2 || len(c.RuntimeCode) <= 2 {
+		t.Error("empty code")
+	}
+}
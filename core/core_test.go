@@ -0,0 +1,187 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/core"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+var (
+	testAddr  = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	testTopic = common.HexToHash("0x01")
+	testLogs  = []*core.Log{{Address: testAddr, Topics: []common.Hash{testTopic}, Data: []byte{1}}}
+)
+
+// totalCost is written once against the shared interfaces.
+func totalCost(txs []core.TxLike) *big.Int {
+	sum := new(big.Int)
+	for _, tx := range txs {
+		sum.Add(sum, tx.Cost())
+	}
+	return sum
+}
+
+func TestTxLike(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chainID := big.NewInt(101)
+
+	mainTx, err := types.SignTx(types.NewTransaction(0, testAddr, big.NewInt(1), big.NewInt(21000), big.NewInt(2), 0, nil, nil), types.NewPanguSigner(chainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subTx, err := scs.SignTx(scs.NewTransaction(0, testAddr, big.NewInt(1), big.NewInt(21000), big.NewInt(2), 0, nil), scs.NewPanguSigner(chainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs := []core.TxLike{mainTx, subTx}
+	if have, want := totalCost(txs), big.NewInt(2*(1+21000*2)); have.Cmp(want) != 0 {
+		t.Errorf("total cost mismatch: have %v, want %v", have, want)
+	}
+	for i, tx := range txs {
+		if !tx.Protected() || tx.ChainId().Cmp(chainID) != 0 {
+			t.Errorf("tx %d: chain id mismatch: have %v, want %v", i, tx.ChainId(), chainID)
+		}
+		if tx.GasLimit().Cmp(big.NewInt(21000)) != 0 {
+			t.Errorf("tx %d: gas limit mismatch: have %v", i, tx.GasLimit())
+		}
+		v, _, _ := tx.RawSignatureValues()
+		if id := core.DeriveChainId(v); id.Cmp(chainID) != 0 {
+			t.Errorf("tx %d: derived chain id mismatch: have %v, want %v", i, id, chainID)
+		}
+	}
+}
+
+func TestHeaderLike(t *testing.T) {
+	bloom := core.BytesToBloom(core.LogsBloom(testLogs).Bytes())
+	mainHeader := &types.Header{Number: big.NewInt(7), Time: big.NewInt(9), Difficulty: big.NewInt(1), GasLimit: big.NewInt(10), GasUsed: big.NewInt(5), Bloom: bloom}
+	subHeader := &scs.Header{Number: big.NewInt(7), Time: big.NewInt(9), Difficulty: big.NewInt(1), GasLimit: big.NewInt(10), GasUsed: big.NewInt(5), Bloom: bloom}
+
+	headers := []core.HeaderLike{mainHeader, subHeader}
+	for i, h := range headers {
+		if h.GetNumber().Int64() != 7 || h.GetTime().Int64() != 9 || h.GetGasUsed().Int64() != 5 {
+			t.Errorf("header %d: field mismatch", i)
+		}
+		if !core.BloomLookup(h.GetBloom(), testTopic) {
+			t.Errorf("header %d: bloom misses topic", i)
+		}
+	}
+	if headers[0].Hash() != headers[1].Hash() {
+		t.Errorf("header hash mismatch: main %x, subchain %x", headers[0].Hash(), headers[1].Hash())
+	}
+}
+
+func TestReceiptLike(t *testing.T) {
+	mainReceipt := types.NewReceipt(nil, false, big.NewInt(21000))
+	mainReceipt.Logs, mainReceipt.GasUsed = testLogs, big.NewInt(21000)
+	mainReceipt.Bloom = types.CreateBloom(types.Receipts{mainReceipt})
+	subReceipt := scs.NewReceipt(nil, false, big.NewInt(21000))
+	subReceipt.Logs, subReceipt.GasUsed = []*scs.Log{(*scs.Log)(testLogs[0])}, big.NewInt(21000)
+	subReceipt.Bloom = scs.CreateBloom(scs.Receipts{subReceipt})
+
+	receipts := []core.ReceiptLike{mainReceipt, subReceipt}
+	if receipts[0].GetBloom() != receipts[1].GetBloom() {
+		t.Fatalf("bloom mismatch")
+	}
+	for i, r := range receipts {
+		if len(r.GetLogs()) != 1 || r.GetLogs()[0].Address != testAddr {
+			t.Errorf("receipt %d: logs mismatch: %v", i, r.GetLogs())
+		}
+		if r.GetFailed() || r.GetGasUsed().Int64() != 21000 {
+			t.Errorf("receipt %d: field mismatch", i)
+		}
+	}
+	if have, want := scs.DeriveSha(scs.Receipts{subReceipt}), types.DeriveSha(types.Receipts{mainReceipt}); have != want {
+		t.Errorf("receipt root mismatch: subchain %x, main %x", have, want)
+	}
+}
+
+func TestNilBigAccessors(t *testing.T) {
+	headers := []core.HeaderLike{new(types.Header), new(scs.Header)}
+	for i, h := range headers {
+		if h.GetNumber().Sign() != 0 || h.GetTime().Sign() != 0 || h.GetGasLimit().Sign() != 0 || h.GetGasUsed().Sign() != 0 {
+			t.Errorf("header %d: nil fields not read as zero", i)
+		}
+	}
+	receipts := []core.ReceiptLike{new(types.Receipt), new(scs.Receipt)}
+	for i, r := range receipts {
+		if r.GetCumulativeGasUsed().Sign() != 0 || r.GetGasUsed().Sign() != 0 {
+			t.Errorf("receipt %d: nil fields not read as zero", i)
+		}
+	}
+}
+
+// Main chain logs are encoded with hex fields, subchain logs keep the plain
+// JSON encoding they always had.
+func TestLogJSON(t *testing.T) {
+	log := core.Log{Address: testAddr, Data: []byte{1}, BlockNumber: 3}
+	tests := []struct {
+		log  interface{}
+		data string
+		num  string
+	}{
+		{&log, `"0x01"`, `"0x3"`},
+		{(*types.Log)(&log), `"0x01"`, `"0x3"`},
+		{(*scs.Log)(&log), `"AQ=="`, `3`},
+	}
+	for i, tt := range tests {
+		enc, err := json.Marshal(tt.log)
+		if err != nil {
+			t.Fatalf("log %d: %v", i, err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(enc, &fields); err != nil {
+			t.Fatalf("log %d: %v", i, err)
+		}
+		if have := string(fields["TxData"]); have != tt.data {
+			t.Errorf("log %d: data mismatch: have %s, want %s", i, have, tt.data)
+		}
+		if have := string(fields["blockNumber"]); have != tt.num {
+			t.Errorf("log %d: block number mismatch: have %s, want %s", i, have, tt.num)
+		}
+	}
+}
+
+func TestSignatureValues(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	hash := crypto.Keccak256Hash([]byte("core"))
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chainID := range []*big.Int{nil, big.NewInt(0), big.NewInt(99)} {
+		r, s, v := core.SignatureValues(chainID, sig)
+		if core.IsProtectedV(v) != (chainID != nil && chainID.Sign() != 0) {
+			t.Errorf("chain %v: protection mismatch for v %v", chainID, v)
+		}
+		plainV := new(big.Int).SetUint64(uint64(sig[64]) + 27)
+		addr, err := core.RecoverPlain(hash, r, s, plainV, true)
+		if err != nil {
+			t.Fatalf("chain %v: %v", chainID, err)
+		}
+		if addr != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("chain %v: recovered %x", chainID, addr)
+		}
+	}
+}
@@ -1,6 +1,6 @@
 // Code generated by github.com/fjl/gencodec. DO NOT EDIT.
 
-package types
+package core
 
 import (
 	"encoding/json"
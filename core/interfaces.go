@@ -0,0 +1,90 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package core holds the chain data model shared by main chain (types) and
+// subchain (scs) blocks: logs, blooms, trie root derivation, signature
+// recovery, and interfaces over transactions, headers and receipts so that
+// tools can handle both kinds of chain data with the same code.
+package core
+
+import (
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/common"
+)
+
+// TxLike is a main chain or subchain transaction.
+type TxLike interface {
+	Hash() common.Hash
+	Nonce() uint64
+	GasPrice() *big.Int
+	GasLimit() *big.Int
+	Value() *big.Int
+	To() *common.Address
+	Data() []byte
+	Cost() *big.Int
+	Size() common.StorageSize
+
+	// MOAC fields
+	SystemFlag() uint64
+	ShardingFlag() uint64
+
+	// Signature
+	ChainId() *big.Int
+	Protected() bool
+	RawSignatureValues() (v, r, s *big.Int)
+}
+
+// MessageLike is a transaction prepared for execution, with its sender
+// recovered.
+type MessageLike interface {
+	From() common.Address
+	To() *common.Address
+	Nonce() uint64
+	CheckNonce() bool
+	GasPrice() *big.Int
+	GasLimit() *big.Int
+	Value() *big.Int
+	Data() []byte
+	ShardFlag() uint64
+}
+
+// HeaderLike is a main chain or subchain block header.
+type HeaderLike interface {
+	Hash() common.Hash
+	GetParentHash() common.Hash
+	GetCoinbase() common.Address
+	GetNumber() *big.Int
+	GetTime() *big.Int
+	GetRoot() common.Hash
+	GetTxHash() common.Hash
+	GetReceiptHash() common.Hash
+	GetBloom() Bloom
+	GetGasLimit() *big.Int
+	GetGasUsed() *big.Int
+	GetExtra() []byte
+}
+
+// ReceiptLike is a main chain or subchain transaction receipt.
+type ReceiptLike interface {
+	GetTxHash() common.Hash
+	GetFailed() bool
+	GetCumulativeGasUsed() *big.Int
+	GetGasUsed() *big.Int
+	GetContractAddress() common.Address
+	GetBloom() Bloom
+	GetLogs() []*Log
+}
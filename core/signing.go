@@ -0,0 +1,153 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+var ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+
+// SignatureValues splits a signature in the [R || S || V] format, where V
+// is 0 or 1, into the R, S, V values of a transaction. V follows the EIP155
+// rules, v = CHAIN_ID * 2 + 35 or 36, unless the chain id is nil or zero in
+// which case it is 27 or 28.
+func SignatureValues(chainId *big.Int, sig []byte) (R, S, V *big.Int) {
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	R = new(big.Int).SetBytes(sig[:32])
+	S = new(big.Int).SetBytes(sig[32:64])
+	V = new(big.Int).SetBytes([]byte{sig[64] + 27})
+	if chainId != nil && chainId.Sign() != 0 {
+		V = big.NewInt(int64(sig[64] + 35))
+		V.Add(V, new(big.Int).Mul(chainId, big.NewInt(2)))
+	}
+	return R, S, V
+}
+
+// RecoverPlain returns the address that signed sighash, where Vb is 27 or
+// 28. With homestead set, signatures with a high S value are rejected.
+func RecoverPlain(sighash common.Hash, R, S, Vb *big.Int, homestead bool) (common.Address, error) {
+	if Vb.BitLen() > 8 {
+		return common.Address{}, ErrInvalidSig
+	}
+	//Compute the actual V value
+	//For EIP155: v = CHAIN_ID * 2 + 35 or v = CHAIN_ID * 2 + 36
+	//then when computing the hash of a transaction for purposes of signing or recovering,
+	//instead of hashing only the first six elements (i.e. nonce, gasprice, startgas, to, value, data),
+	//hash nine elements, with v replaced by CHAIN_ID, r = 0 and s = 0.
+	//The currently existing signature scheme using v = 27 and v = 28,
+	// remains valid and continues to operate under the same rules as it does now.
+	//v is the `recovery id', a 1 byte value specifying the sign and niteness of the curve point; this
+	//value is in the range of [27; 30], however we declare the upper two possibilities, representing innite values, invalid
+	V := byte(Vb.Uint64() - 27)
+
+	//When validate, V need to be either 27 or 28
+	if !crypto.ValidateSignatureValues(V, R, S, homestead) {
+		return common.Address{}, ErrInvalidSig
+	}
+	// encode the snature in uncompressed format
+	r, s := R.Bytes(), S.Bytes()
+	sig := make([]byte, 65)
+	copy(sig[32-len(r):32], r)
+	copy(sig[64-len(s):64], s)
+	sig[64] = V
+
+	// recover the public key from the snature
+	pub, err := crypto.Ecrecover(sighash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(pub) == 0 || pub[0] != 4 {
+		return common.Address{}, errors.New("invalid public key")
+	}
+	//Return the address
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
+	return addr, nil
+}
+
+// DeriveChainId derives the chain id from the given v parameter
+func DeriveChainId(v *big.Int) *big.Int {
+	if v.BitLen() <= 64 {
+		//If v value is a UINT64 number
+		v := v.Uint64()
+		// No chainID is included in the V
+		if v == 27 || v == 28 {
+			//This should not happen in MOAC network
+			return new(big.Int)
+		}
+		//EIP155 compute
+		return new(big.Int).SetUint64((v - 35) / 2)
+	}
+	//If v is really large
+	v = new(big.Int).Sub(v, big.NewInt(35))
+	return v.Div(v, big.NewInt(2))
+}
+
+// IsProtectedV reports whether v is the V value of a replay protected
+// signature.
+func IsProtectedV(V *big.Int) bool {
+	if V.BitLen() <= 8 {
+		v := V.Uint64()
+		return v != 27 && v != 28
+	}
+	// anything not 27 or 28 are considered unprotected
+	return true
+}
+
+// RecoverSenders calls sender for the indexes 0..n-1 using a bounded pool
+// of worker goroutines. The returned slices hold the sender and error of
+// index i at position i.
+func RecoverSenders(n int, sender func(i int) (common.Address, error)) ([]common.Address, []error) {
+	senders, errs := make([]common.Address, n), make([]error, n)
+	if n == 0 {
+		return senders, errs
+	}
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	var (
+		next = make(chan int, n)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				senders[i], errs[i] = sender(i)
+			}
+		}()
+	}
+	wg.Wait()
+	return senders, errs
+}
@@ -39,6 +39,8 @@ import (
 	"hash"
 	"io"
 	"math/big"
+
+	ethcrypto "github.com/MOACChain/MoacLib/crypto"
 )
 
 var (
@@ -126,9 +128,19 @@ func (prv *PrivateKey) GenerateShared(pub *PublicKey, skLen, macLen int) (sk []b
 		return nil, ErrSharedKeyTooBig
 	}
 
-	x, _ := pub.Curve.ScalarMult(pub.X, pub.Y, prv.D.Bytes())
-	if x == nil {
-		return nil, ErrSharedKeyIsPointAtInfinity
+	var x *big.Int
+	if pub.Curve == ethcrypto.S256() {
+		// Use the constant time secp256k1 ECDH of the crypto package.
+		shared, err := ethcrypto.ECDH(prv.ExportECDSA(), pub.ExportECDSA())
+		if err != nil {
+			return nil, ErrSharedKeyIsPointAtInfinity
+		}
+		x = new(big.Int).SetBytes(shared)
+	} else {
+		x, _ = pub.Curve.ScalarMult(pub.X, pub.Y, prv.D.Bytes())
+		if x == nil {
+			return nil, ErrSharedKeyIsPointAtInfinity
+		}
 	}
 
 	sk = make([]byte, skLen+macLen)
@@ -211,6 +223,11 @@ func generateIV(params *ECIESParams, rand io.Reader) (iv []byte, err error) {
 // symEncrypt carries out CTR encryption using the block cipher specified in the
 // parameters.
 func symEncrypt(rand io.Reader, params *ECIESParams, key, m []byte) (ct []byte, err error) {
+	// Streaming suites have no block cipher and only work with the
+	// stream functions.
+	if params.Cipher == nil || params.BlockSize == 0 {
+		return nil, ErrUnsupportedECIESParameters
+	}
 	c, err := params.Cipher(key)
 	if err != nil {
 		return
@@ -231,6 +248,9 @@ func symEncrypt(rand io.Reader, params *ECIESParams, key, m []byte) (ct []byte,
 // symDecrypt carries out CTR decryption using the block cipher specified in
 // the parameters
 func symDecrypt(rand io.Reader, params *ECIESParams, key, ct []byte) (m []byte, err error) {
+	if params.Cipher == nil || params.BlockSize == 0 {
+		return nil, ErrUnsupportedECIESParameters
+	}
 	c, err := params.Cipher(key)
 	if err != nil {
 		return
@@ -256,6 +276,10 @@ func Encrypt(rand io.Reader, pub *PublicKey, m, s1, s2 []byte) (ct []byte, err e
 			return
 		}
 	}
+	if params.Cipher == nil {
+		err = ErrUnsupportedECIESParameters
+		return
+	}
 	R, err := GenerateKey(rand, pub.Curve, params)
 	if err != nil {
 		return
@@ -303,6 +327,10 @@ func (prv *PrivateKey) Decrypt(rand io.Reader, c, s1, s2 []byte) (m []byte, err
 			return
 		}
 	}
+	if params.Cipher == nil {
+		err = ErrUnsupportedECIESParameters
+		return
+	}
 	hash := params.Hash()
 
 	var (
@@ -45,10 +45,10 @@ import (
 
 var dumpEnc bool
 
+// The flag is parsed by the testing package; parsing it here would fail on
+// the test flags not registered yet.
 func init() {
-	flDump := flag.Bool("dump", false, "write encrypted test message to file")
-	flag.Parse()
-	dumpEnc = *flDump
+	flag.BoolVar(&dumpEnc, "dump", false, "write encrypted test message to file")
 }
 
 // Ensure the KDF generates appropriately sized keys.
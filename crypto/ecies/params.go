@@ -43,6 +43,7 @@ import (
 	"hash"
 
 	ethcrypto "github.com/MOACChain/MoacLib/crypto"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 var (
@@ -57,6 +58,10 @@ type ECIESParams struct {
 	Cipher    func([]byte) (cipher.Block, error) // symmetric cipher
 	BlockSize int                                // block size of symmetric cipher
 	KeyLen    int                                // length of symmetric key
+
+	// AEAD and Suite are only set for parameters usable in streaming mode.
+	AEAD  func([]byte) (cipher.AEAD, error) // authenticated cipher
+	Suite StreamSuite                       // suite identifier in the envelope header
 }
 
 // Standard ECIES parameters:
@@ -99,6 +104,49 @@ var (
 	}
 )
 
+// Streaming ECIES parameters (see NewEncryptWriter):
+// * ECIES using AES256-GCM, keys derived with SHA-256
+// * ECIES using ChaCha20-Poly1305, keys derived with SHA-256
+
+var (
+	ECIES_AES256_GCM_SHA256 = &ECIESParams{
+		Hash:      sha256.New,
+		hashAlgo:  crypto.SHA256,
+		Cipher:    aes.NewCipher,
+		BlockSize: aes.BlockSize,
+		KeyLen:    32,
+		AEAD:      newAESGCM,
+		Suite:     SuiteAES256GCM,
+	}
+
+	ECIES_CHACHA20POLY1305_SHA256 = &ECIESParams{
+		Hash:     sha256.New,
+		hashAlgo: crypto.SHA256,
+		KeyLen:   chacha20poly1305.KeySize,
+		AEAD:     chacha20poly1305.New,
+		Suite:    SuiteChaCha20Poly1305,
+	}
+)
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var paramsFromSuite = map[StreamSuite]*ECIESParams{
+	SuiteAES256GCM:        ECIES_AES256_GCM_SHA256,
+	SuiteChaCha20Poly1305: ECIES_CHACHA20POLY1305_SHA256,
+}
+
+// ParamsFromSuite returns the streaming parameters for the given suite
+// identifier, or nil if the suite is unknown.
+func ParamsFromSuite(suite StreamSuite) *ECIESParams {
+	return paramsFromSuite[suite]
+}
+
 var paramsFromCurve = map[elliptic.Curve]*ECIESParams{
 	ethcrypto.S256(): ECIES_AES128_SHA256,
 	elliptic.P256():  ECIES_AES128_SHA256,
@@ -0,0 +1,365 @@
+package ecies
+
+// This file implements a streaming mode for ECIES. The plaintext is split into
+// chunks which are sealed individually with an AEAD cipher, so arbitrarily
+// large payloads can be encrypted to a recipient's key without holding them
+// in memory.
+//
+// An encrypted stream starts with a versioned envelope header:
+//
+//	magic     [4]byte  "MCES"
+//	version   uint8    StreamVersion
+//	suite     uint8    StreamSuite of the AEAD cipher
+//	chunkSize uint32   maximum plaintext bytes per chunk
+//	noncePfx  [4]byte  random nonce prefix
+//	keyLen    uint16   length of the ephemeral public key
+//	key       []byte   ephemeral public key, uncompressed
+//
+// followed by a sequence of chunks:
+//
+//	flag      uint8    chunkData, or chunkFinal for the last chunk
+//	length    uint32   length of the sealed chunk
+//	sealed    []byte   AEAD ciphertext and tag
+//
+// Every chunk is sealed with the nonce noncePfx || counter and the header
+// plus the chunk flag as additional data. Reordering, truncation and
+// header tampering are therefore all detected by the reader.
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StreamSuite identifies the AEAD cipher of an encrypted stream.
+type StreamSuite uint8
+
+const (
+	SuiteAES256GCM        StreamSuite = 1
+	SuiteChaCha20Poly1305 StreamSuite = 2
+)
+
+const (
+	// StreamVersion is the envelope version written by NewEncryptWriter.
+	StreamVersion = 1
+
+	// DefaultChunkSize is the plaintext chunk size used when none is given.
+	DefaultChunkSize = 64 * 1024
+	// MaxChunkSize is the largest chunk size accepted by the reader.
+	MaxChunkSize = 16 * 1024 * 1024
+
+	chunkData  = 0
+	chunkFinal = 1
+
+	streamNonceSize = 12
+)
+
+var streamMagic = [4]byte{'M', 'C', 'E', 'S'}
+
+var (
+	ErrUnsupportedVersion = errors.New("ecies: unsupported stream version")
+	ErrInvalidHeader      = errors.New("ecies: invalid stream header")
+	ErrInvalidChunk       = errors.New("ecies: invalid stream chunk")
+	ErrStreamClosed       = errors.New("ecies: write to closed stream")
+)
+
+// streamHeader is the decoded envelope header of an encrypted stream.
+type streamHeader struct {
+	version   uint8
+	suite     StreamSuite
+	chunkSize uint32
+	noncePfx  [4]byte
+	key       []byte
+}
+
+func (h *streamHeader) encode() []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(streamMagic[:])
+	buf.WriteByte(h.version)
+	buf.WriteByte(byte(h.suite))
+	binary.Write(buf, binary.BigEndian, h.chunkSize)
+	buf.Write(h.noncePfx[:])
+	binary.Write(buf, binary.BigEndian, uint16(len(h.key)))
+	buf.Write(h.key)
+	return buf.Bytes()
+}
+
+func readStreamHeader(r io.Reader) (*streamHeader, []byte, error) {
+	fixed := make([]byte, 4+1+1+4+4+2)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, nil, ErrInvalidHeader
+	}
+	if !bytes.Equal(fixed[:4], streamMagic[:]) {
+		return nil, nil, ErrInvalidHeader
+	}
+	h := &streamHeader{
+		version:   fixed[4],
+		suite:     StreamSuite(fixed[5]),
+		chunkSize: binary.BigEndian.Uint32(fixed[6:10]),
+	}
+	if h.version != StreamVersion {
+		return nil, nil, ErrUnsupportedVersion
+	}
+	if h.chunkSize == 0 || h.chunkSize > MaxChunkSize {
+		return nil, nil, ErrInvalidHeader
+	}
+	copy(h.noncePfx[:], fixed[10:14])
+	h.key = make([]byte, binary.BigEndian.Uint16(fixed[14:16]))
+	if _, err := io.ReadFull(r, h.key); err != nil {
+		return nil, nil, ErrInvalidHeader
+	}
+	return h, append(fixed, h.key...), nil
+}
+
+// streamKey derives the AEAD key for a stream from the ECDH shared secret.
+func streamKey(prv *PrivateKey, pub *PublicKey, params *ECIESParams, s1 []byte) ([]byte, error) {
+	z, err := prv.GenerateShared(pub, params.KeyLen, 0)
+	if err != nil {
+		return nil, err
+	}
+	return concatKDF(params.Hash(), z, s1, params.KeyLen)
+}
+
+// chunkCipher seals and opens the chunks of a stream.
+type chunkCipher struct {
+	aead    cipher.AEAD
+	header  []byte
+	nonce   [streamNonceSize]byte
+	counter uint64
+	ad      []byte
+}
+
+func newChunkCipher(params *ECIESParams, key []byte, h *streamHeader, header []byte) (*chunkCipher, error) {
+	aead, err := params.AEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if aead.NonceSize() != streamNonceSize {
+		return nil, ErrUnsupportedECIESParameters
+	}
+	c := &chunkCipher{aead: aead, header: header}
+	copy(c.nonce[:4], h.noncePfx[:])
+	c.ad = make([]byte, len(header)+1)
+	copy(c.ad, header)
+	return c, nil
+}
+
+// next advances the nonce and additional data for a chunk with the given flag.
+func (c *chunkCipher) next(flag byte) ([]byte, []byte) {
+	binary.BigEndian.PutUint64(c.nonce[4:], c.counter)
+	c.counter++
+	c.ad[len(c.ad)-1] = flag
+	return c.nonce[:], c.ad
+}
+
+// streamWriter encrypts everything written to it into an underlying writer.
+type streamWriter struct {
+	w      io.Writer
+	cipher *chunkCipher
+	buf    []byte
+	sealed []byte
+	closed bool
+	err    error
+}
+
+// NewEncryptWriter returns a writer that encrypts all data written to it for
+// the recipient pub and writes the resulting stream to w. The envelope header
+// is written immediately. Close must be called to seal the final chunk; it
+// does not close w.
+//
+// params selects the AEAD suite and must be one of the streaming parameters
+// (ECIES_AES256_GCM_SHA256 or ECIES_CHACHA20POLY1305_SHA256); nil selects
+// AES-256-GCM. A chunkSize of zero selects DefaultChunkSize. s1 is shared
+// information fed into key derivation, it may be nil.
+func NewEncryptWriter(rand io.Reader, w io.Writer, pub *PublicKey, params *ECIESParams, chunkSize int, s1 []byte) (io.WriteCloser, error) {
+	if params == nil {
+		params = ECIES_AES256_GCM_SHA256
+	}
+	if params.AEAD == nil || ParamsFromSuite(params.Suite) == nil {
+		return nil, ErrUnsupportedECIESParameters
+	}
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if chunkSize < 0 || chunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("ecies: invalid chunk size %d", chunkSize)
+	}
+	ephemeral, err := GenerateKey(rand, pub.Curve, params)
+	if err != nil {
+		return nil, err
+	}
+	h := &streamHeader{
+		version:   StreamVersion,
+		suite:     params.Suite,
+		chunkSize: uint32(chunkSize),
+		key:       elliptic.Marshal(pub.Curve, ephemeral.PublicKey.X, ephemeral.PublicKey.Y),
+	}
+	if _, err := io.ReadFull(rand, h.noncePfx[:]); err != nil {
+		return nil, err
+	}
+	key, err := streamKey(ephemeral, pub, params, s1)
+	if err != nil {
+		return nil, err
+	}
+	header := h.encode()
+	c, err := newChunkCipher(params, key, h, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &streamWriter{
+		w:      w,
+		cipher: c,
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// Write encrypts p, emitting a chunk each time the chunk buffer fills up.
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if sw.closed {
+		return 0, ErrStreamClosed
+	}
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(sw.buf) == cap(sw.buf) {
+			if err := sw.flush(chunkData); err != nil {
+				return n, err
+			}
+		}
+		m := copy(sw.buf[len(sw.buf):cap(sw.buf)], p)
+		sw.buf = sw.buf[:len(sw.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close seals the buffered data as the final chunk of the stream.
+func (sw *streamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.flush(chunkFinal)
+}
+
+func (sw *streamWriter) flush(flag byte) error {
+	nonce, ad := sw.cipher.next(flag)
+	sw.sealed = sw.cipher.aead.Seal(sw.sealed[:0], nonce, sw.buf, ad)
+
+	var frame [5]byte
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(sw.sealed)))
+	if _, err := sw.w.Write(frame[:]); err != nil {
+		sw.err = err
+		return err
+	}
+	if _, err := sw.w.Write(sw.sealed); err != nil {
+		sw.err = err
+		return err
+	}
+	sw.buf = sw.buf[:0]
+	return nil
+}
+
+// streamReader decrypts a stream produced by NewEncryptWriter.
+type streamReader struct {
+	r         io.Reader
+	cipher    *chunkCipher
+	chunkSize int
+	sealed    []byte
+	plain     []byte
+	pending   []byte
+	done      bool
+	err       error
+}
+
+// NewDecryptReader reads the envelope header from r and returns a reader
+// yielding the decrypted plaintext. Every chunk is authenticated before any
+// of its data is returned. A stream that ends before its final chunk results
+// in io.ErrUnexpectedEOF.
+func (prv *PrivateKey) NewDecryptReader(r io.Reader, s1 []byte) (io.Reader, error) {
+	h, header, err := readStreamHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	params := ParamsFromSuite(h.suite)
+	if params == nil {
+		return nil, ErrUnsupportedECIESParameters
+	}
+	x, y := elliptic.Unmarshal(prv.PublicKey.Curve, h.key)
+	if x == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	ephemeral := &PublicKey{X: x, Y: y, Curve: prv.PublicKey.Curve, Params: params}
+	key, err := streamKey(prv, ephemeral, params, s1)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newChunkCipher(params, key, h, header)
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader{r: r, cipher: c, chunkSize: int(h.chunkSize)}, nil
+}
+
+// Read returns decrypted data, opening the next chunk when needed.
+func (sr *streamReader) Read(p []byte) (int, error) {
+	for len(sr.pending) == 0 {
+		if sr.err != nil {
+			return 0, sr.err
+		}
+		if sr.done {
+			return 0, io.EOF
+		}
+		sr.err = sr.readChunk()
+	}
+	n := copy(p, sr.pending)
+	sr.pending = sr.pending[n:]
+	return n, nil
+}
+
+func (sr *streamReader) readChunk() error {
+	var frame [5]byte
+	if _, err := io.ReadFull(sr.r, frame[:]); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	flag := frame[0]
+	size := int(binary.BigEndian.Uint32(frame[1:]))
+	if (flag != chunkData && flag != chunkFinal) || size > sr.chunkSize+sr.cipher.aead.Overhead() {
+		return ErrInvalidChunk
+	}
+	if cap(sr.sealed) < size {
+		sr.sealed = make([]byte, size)
+	}
+	sr.sealed = sr.sealed[:size]
+	if _, err := io.ReadFull(sr.r, sr.sealed); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	nonce, ad := sr.cipher.next(flag)
+	plain, err := sr.cipher.aead.Open(sr.plain[:0], nonce, sr.sealed, ad)
+	if err != nil {
+		return ErrInvalidMessage
+	}
+	sr.plain, sr.pending = plain, plain
+	sr.done = flag == chunkFinal
+	return nil
+}
@@ -0,0 +1,120 @@
+package ecies
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func streamRoundTrip(t *testing.T, prv *PrivateKey, params *ECIESParams, chunkSize int, msg []byte) []byte {
+	var ct bytes.Buffer
+	w, err := NewEncryptWriter(rand.Reader, &ct, &prv.PublicKey, params, chunkSize, []byte("s1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write in odd sized pieces to exercise chunk boundaries.
+	for rest := msg; len(rest) > 0; {
+		n := 7
+		if n > len(rest) {
+			n = len(rest)
+		}
+		if _, err := w.Write(rest[:n]); err != nil {
+			t.Fatal(err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := prv.NewDecryptReader(bytes.NewReader(ct.Bytes()), []byte("s1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt, msg) {
+		t.Fatalf("plaintext mismatch: have %d bytes, want %d", len(pt), len(msg))
+	}
+	return ct.Bytes()
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	prv, err := GenerateKey(rand.Reader, DefaultCurve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 1000)
+	rand.Read(msg)
+
+	for _, params := range []*ECIESParams{ECIES_AES256_GCM_SHA256, ECIES_CHACHA20POLY1305_SHA256} {
+		for _, size := range []int{0, 1, 64, 100, 1000} {
+			streamRoundTrip(t, prv, params, 64, msg[:size])
+		}
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	prv, _ := GenerateKey(rand.Reader, DefaultCurve, nil)
+	msg := make([]byte, 300)
+	rand.Read(msg)
+	ct := streamRoundTrip(t, prv, nil, 100, msg)
+
+	decrypt := func(ct []byte) error {
+		r, err := prv.NewDecryptReader(bytes.NewReader(ct), []byte("s1"))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(ioutil.Discard, r)
+		return err
+	}
+	// Truncating the final chunk must be detected.
+	if err := decrypt(ct[:len(ct)-1]); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated stream: expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	// Flipping a ciphertext bit must be detected.
+	bad := append([]byte{}, ct...)
+	bad[len(bad)-20] ^= 0x01
+	if err := decrypt(bad); err != ErrInvalidMessage {
+		t.Errorf("modified chunk: expected %v, got %v", ErrInvalidMessage, err)
+	}
+	// Changing the header chunk size invalidates all chunks.
+	bad = append([]byte{}, ct...)
+	bad[9]++
+	if err := decrypt(bad); err != ErrInvalidMessage {
+		t.Errorf("modified header: expected %v, got %v", ErrInvalidMessage, err)
+	}
+	// Unknown versions are rejected up front.
+	bad = append([]byte{}, ct...)
+	bad[4] = StreamVersion + 1
+	if err := decrypt(bad); err != ErrUnsupportedVersion {
+		t.Errorf("bad version: expected %v, got %v", ErrUnsupportedVersion, err)
+	}
+	// A different key cannot decrypt.
+	other, _ := GenerateKey(rand.Reader, DefaultCurve, nil)
+	r, err := other.NewDecryptReader(bytes.NewReader(ct), []byte("s1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(ioutil.Discard, r); err != ErrInvalidMessage {
+		t.Errorf("wrong key: expected %v, got %v", ErrInvalidMessage, err)
+	}
+}
+
+func TestStreamUnsupportedParams(t *testing.T) {
+	prv, _ := GenerateKey(rand.Reader, DefaultCurve, nil)
+	if _, err := NewEncryptWriter(rand.Reader, ioutil.Discard, &prv.PublicKey, ECIES_AES128_SHA256, 0, nil); err != ErrUnsupportedECIESParameters {
+		t.Errorf("expected %v, got %v", ErrUnsupportedECIESParameters, err)
+	}
+	// Streaming suites have no block cipher for the one-shot functions.
+	prv, _ = GenerateKey(rand.Reader, DefaultCurve, ECIES_CHACHA20POLY1305_SHA256)
+	if _, err := Encrypt(rand.Reader, &prv.PublicKey, []byte("msg"), nil, nil); err != ErrUnsupportedECIESParameters {
+		t.Errorf("expected %v, got %v", ErrUnsupportedECIESParameters, err)
+	}
+	if _, err := symDecrypt(rand.Reader, ECIES_CHACHA20POLY1305_SHA256, make([]byte, 32), make([]byte, 32)); err != ErrUnsupportedECIESParameters {
+		t.Errorf("expected %v, got %v", ErrUnsupportedECIESParameters, err)
+	}
+}
@@ -14,7 +14,7 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// +build !nacl,!js,!nocgo
+// +build !nacl,!js,!nocgo,cgo
 
 package crypto
 
@@ -79,6 +79,18 @@ func CompressPubkey(pubkey *ecdsa.PublicKey) []byte {
 	return secp256k1.CompressPubkey(pubkey.X, pubkey.Y)
 }
 
+// ECDH computes the shared secret, the x coordinate of prv*pub, as 32 bytes.
+func ECDH(prv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) ([]byte, error) {
+	seckey := math.PaddedBigBytes(prv.D, 32)
+	defer zeroBytes(seckey)
+
+	x, _ := S256().ScalarMult(pub.X, pub.Y, seckey)
+	if x == nil {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	return math.PaddedBigBytes(x, 32), nil
+}
+
 // S256 returns an instance of the secp256k1 curve.
 func S256() elliptic.Curve {
 	return secp256k1.S256()
@@ -14,37 +14,27 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// +build nacl js nocgo
+// +build nacl js nocgo !cgo
 
 package crypto
 
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
-	"errors"
-	"fmt"
-	"math/big"
 
-	"github.com/btcsuite/btcd/btcec"
+	decred "github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
 
 func Ecrecover(hash, sig []byte) ([]byte, error) {
-	pub, err := SigToPub(hash, sig)
-	if err != nil {
-		return nil, err
-	}
-	bytes := (*btcec.PublicKey)(pub).SerializeUncompressed()
-	return bytes, err
+	return pureEcrecover(hash, sig)
 }
 
 func SigToPub(hash, sig []byte) (*ecdsa.PublicKey, error) {
-	// Convert to btcec input format with 'recovery id' v at the beginning.
-	btcsig := make([]byte, 65)
-	btcsig[0] = sig[64] + 27
-	copy(btcsig[1:], sig)
-
-	pub, _, err := btcec.RecoverCompact(btcec.S256(), btcsig, hash)
-	return (*ecdsa.PublicKey)(pub), err
+	pub, err := pureSigToPub(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: S256(), X: pub.X(), Y: pub.Y()}, nil
 }
 
 // Sign calculates an ECDSA signature.
@@ -56,60 +46,32 @@ func SigToPub(hash, sig []byte) (*ecdsa.PublicKey, error) {
 //
 // The produced signature is in the [R || S || V] format where V is 0 or 1.
 func Sign(hash []byte, prv *ecdsa.PrivateKey) ([]byte, error) {
-	if len(hash) != 32 {
-		return nil, fmt.Errorf("hash is required to be exactly 32 bytes (%d)", len(hash))
-	}
-	if prv.Curve != btcec.S256() {
-		return nil, fmt.Errorf("private key curve is not secp256k1")
-	}
-	sig, err := btcec.SignCompact(btcec.S256(), (*btcec.PrivateKey)(prv), hash, false)
-	if err != nil {
-		return nil, err
-	}
-	// Convert to MoacNode signature format with 'recovery id' v at the end.
-	v := sig[0] - 27
-	copy(sig, sig[1:])
-	sig[64] = v
-	return sig, nil
+	return pureSign(hash, prv)
 }
 
 // VerifySignature checks that the given public key created signature over hash.
 // The public key should be in compressed (33 bytes) or uncompressed (65 bytes) format.
 // The signature should have the 64 byte [R || S] format.
 func VerifySignature(pubkey, hash, signature []byte) bool {
-	if len(signature) != 64 {
-		return false
-	}
-	sig := &btcec.Signature{R: new(big.Int).SetBytes(signature[:32]), S: new(big.Int).SetBytes(signature[32:])}
-	key, err := btcec.ParsePubKey(pubkey, btcec.S256())
-	if err != nil {
-		return false
-	}
-	// Reject malleable signatures. libsecp256k1 does this check but btcec doesn't.
-	if sig.S.Cmp(secp256k1_halfN) > 0 {
-		return false
-	}
-	return sig.Verify(hash, key)
+	return pureVerifySignature(pubkey, hash, signature)
 }
 
 // DecompressPubkey parses a public key in the 33-byte compressed format.
 func DecompressPubkey(pubkey []byte) (*ecdsa.PublicKey, error) {
-	if len(pubkey) != 33 {
-		return nil, errors.New("invalid compressed public key length")
-	}
-	key, err := btcec.ParsePubKey(pubkey, btcec.S256())
-	if err != nil {
-		return nil, err
-	}
-	return key.ToECDSA(), nil
+	return pureDecompressPubkey(pubkey)
 }
 
 // CompressPubkey encodes a public key to the 33-byte compressed format.
 func CompressPubkey(pubkey *ecdsa.PublicKey) []byte {
-	return (*btcec.PublicKey)(pubkey).SerializeCompressed()
+	return pureCompressPubkey(pubkey)
+}
+
+// ECDH computes the shared secret, the x coordinate of prv*pub, as 32 bytes.
+func ECDH(prv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) ([]byte, error) {
+	return pureECDH(prv, pub)
 }
 
 // S256 returns an instance of the secp256k1 curve.
 func S256() elliptic.Curve {
-	return btcec.S256()
+	return decred.S256()
 }
@@ -0,0 +1,187 @@
+// +build !nacl,!js,!nocgo,cgo
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+)
+
+// These tests check that the pure Go secp256k1 implementation used by nocgo
+// builds behaves exactly like the libsecp256k1 backed cgo implementation.
+
+const parityRounds = 200
+
+func TestParitySign(t *testing.T) {
+	for i := 0; i < parityRounds; i++ {
+		key, _ := GenerateKey()
+		hash := Keccak256([]byte{byte(i), byte(i >> 8)})
+
+		want, err := Sign(hash, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, err := pureSign(hash, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Both use RFC6979 nonces, so signatures must be identical.
+		if !bytes.Equal(want, have) {
+			t.Fatalf("signature mismatch:\ncgo:  %x\npure: %x", want, have)
+		}
+	}
+}
+
+func TestParityRecoverVerify(t *testing.T) {
+	for i := 0; i < parityRounds; i++ {
+		key, _ := GenerateKey()
+		hash := Keccak256([]byte{byte(i)}, []byte("recover"))
+		sig, err := Sign(hash, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := Ecrecover(hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, err := pureEcrecover(hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want, have) {
+			t.Fatalf("recovered key mismatch:\ncgo:  %x\npure: %x", want, have)
+		}
+		for _, pub := range [][]byte{want, CompressPubkey(&key.PublicKey)} {
+			if VerifySignature(pub, hash, sig[:64]) != pureVerifySignature(pub, hash, sig[:64]) {
+				t.Fatalf("verify mismatch for %x", pub)
+			}
+		}
+		// Tamper with the signature and the hash.
+		bad := make([]byte, 64)
+		copy(bad, sig)
+		bad[10] ^= 0x01
+		if VerifySignature(want, hash, bad) != pureVerifySignature(want, hash, bad) {
+			t.Fatal("verify mismatch for tampered signature")
+		}
+		badHash := Keccak256(hash)
+		if VerifySignature(want, badHash, sig[:64]) != pureVerifySignature(want, badHash, sig[:64]) {
+			t.Fatal("verify mismatch for wrong hash")
+		}
+	}
+}
+
+func TestParityMalleable(t *testing.T) {
+	key, _ := HexToECDSA(testPrivHex)
+	hash := Keccak256([]byte("malleable"))
+	sig, _ := Sign(hash, key)
+	pub := FromECDSAPub(&key.PublicKey)
+
+	// Replace S with N-S, which libsecp256k1 rejects.
+	s := new(big.Int).Sub(secp256k1_N, new(big.Int).SetBytes(sig[32:64]))
+	high := append(append([]byte{}, sig[:32]...), common.LeftPadBytes(s.Bytes(), 32)...)
+	if VerifySignature(pub, hash, high) || pureVerifySignature(pub, hash, high) {
+		t.Fatal("malleable signature accepted")
+	}
+}
+
+func TestParityInvalidInputs(t *testing.T) {
+	hash := Keccak256([]byte("invalid"))
+	inputs := [][]byte{
+		make([]byte, 65),
+		append(bytes.Repeat([]byte{0xff}, 64), 0),
+		append(make([]byte, 64), 4),
+	}
+	for _, sig := range inputs {
+		_, err1 := Ecrecover(hash, sig)
+		_, err2 := pureEcrecover(hash, sig)
+		if (err1 == nil) != (err2 == nil) {
+			t.Fatalf("recover error mismatch for %x: cgo %v, pure %v", sig, err1, err2)
+		}
+	}
+	for _, pub := range [][]byte{make([]byte, 33), append([]byte{0x02}, bytes.Repeat([]byte{0xff}, 32)...)} {
+		_, err1 := DecompressPubkey(pub)
+		_, err2 := pureDecompressPubkey(pub)
+		if (err1 == nil) != (err2 == nil) {
+			t.Fatalf("decompress error mismatch for %x: cgo %v, pure %v", pub, err1, err2)
+		}
+	}
+}
+
+func TestParityCompression(t *testing.T) {
+	for i := 0; i < parityRounds; i++ {
+		key, _ := GenerateKey()
+		want := CompressPubkey(&key.PublicKey)
+		have := pureCompressPubkey(&key.PublicKey)
+		if !bytes.Equal(want, have) {
+			t.Fatalf("compressed key mismatch:\ncgo:  %x\npure: %x", want, have)
+		}
+		dec, err := pureDecompressPubkey(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.X.Cmp(key.X) != 0 || dec.Y.Cmp(key.Y) != 0 {
+			t.Fatal("decompressed key mismatch")
+		}
+	}
+}
+
+func TestParityECDH(t *testing.T) {
+	for i := 0; i < parityRounds; i++ {
+		a, _ := GenerateKey()
+		b, _ := GenerateKey()
+		want, err := ECDH(a, &b.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, err := pureECDH(a, &b.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, _ := pureECDH(b, &a.PublicKey)
+		if !bytes.Equal(want, have) || !bytes.Equal(have, other) {
+			t.Fatalf("shared secret mismatch:\ncgo:  %x\npure: %x\npeer: %x", want, have, other)
+		}
+	}
+}
+
+func benchmarkKey(b *testing.B) (*ecdsa.PrivateKey, []byte, []byte) {
+	key, _ := HexToECDSA(testPrivHex)
+	hash := Keccak256([]byte("benchmark"))
+	sig, err := Sign(hash, key)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return key, hash, sig
+}
+
+func BenchmarkSignCgo(b *testing.B) {
+	key, hash, _ := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		Sign(hash, key)
+	}
+}
+
+func BenchmarkSignPure(b *testing.B) {
+	key, hash, _ := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		pureSign(hash, key)
+	}
+}
+
+func BenchmarkEcrecoverCgo(b *testing.B) {
+	_, hash, sig := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		Ecrecover(hash, sig)
+	}
+}
+
+func BenchmarkEcrecoverPure(b *testing.B) {
+	_, hash, sig := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		pureEcrecover(hash, sig)
+	}
+}
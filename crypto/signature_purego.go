@@ -0,0 +1,162 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/MOACChain/MoacLib/common/math"
+	decred "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	decredecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// This file holds the pure Go secp256k1 implementation. It is always compiled
+// so that the cgo build can be checked against it, but it only backs the
+// exported API when cgo is unavailable (see signature_nocgo.go).
+//
+// Secret key operations (signing, ECDH) use the constant time field and
+// scalar arithmetic of the decred secp256k1 package.
+
+var (
+	errInvalidSignatureLen = errors.New("invalid signature length")
+	errInvalidRecoveryID   = errors.New("invalid signature recovery id")
+	errInvalidPrivateKey   = errors.New("invalid private key")
+	errInvalidCompressed   = errors.New("invalid compressed public key length")
+)
+
+// pureEcrecover returns the uncompressed public key that created the given
+// [R || S || V] signature.
+func pureEcrecover(hash, sig []byte) ([]byte, error) {
+	pub, err := pureSigToPub(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return pub.SerializeUncompressed(), nil
+}
+
+func pureSigToPub(hash, sig []byte) (*decred.PublicKey, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash is required to be exactly 32 bytes (%d)", len(hash))
+	}
+	if len(sig) != 65 {
+		return nil, errInvalidSignatureLen
+	}
+	if sig[64] >= 4 {
+		return nil, errInvalidRecoveryID
+	}
+	// Convert to decred input format with 'recovery id' v at the beginning.
+	dsig := make([]byte, 65)
+	dsig[0] = sig[64] + 27
+	copy(dsig[1:], sig)
+
+	pub, _, err := decredecdsa.RecoverCompact(dsig, hash)
+	return pub, err
+}
+
+// pureSign calculates a deterministic (RFC6979) ECDSA signature in the
+// [R || S || V] format where V is 0 or 1.
+func pureSign(hash []byte, prv *ecdsa.PrivateKey) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash is required to be exactly 32 bytes (%d)", len(hash))
+	}
+	if prv.Curve.Params().N.Cmp(secp256k1_N) != 0 {
+		return nil, fmt.Errorf("private key curve is not secp256k1")
+	}
+	key, err := decredPrivateKey(prv)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+
+	sig := decredecdsa.SignCompact(key, hash, false)
+	// Convert to MoacNode signature format with 'recovery id' v at the end.
+	v := sig[0] - 27
+	copy(sig, sig[1:])
+	sig[64] = v
+	return sig, nil
+}
+
+// pureVerifySignature checks that the given public key created signature over
+// hash. Malleable signatures with S in the upper half of the order are
+// rejected, matching libsecp256k1.
+func pureVerifySignature(pubkey, hash, signature []byte) bool {
+	if len(signature) != 64 || len(hash) != 32 {
+		return false
+	}
+	var r, s decred.ModNScalar
+	if r.SetByteSlice(signature[:32]) || s.SetByteSlice(signature[32:]) {
+		return false
+	}
+	if r.IsZero() || s.IsZero() || s.IsOverHalfOrder() {
+		return false
+	}
+	key, err := decred.ParsePubKey(pubkey)
+	if err != nil {
+		return false
+	}
+	return decredecdsa.NewSignature(&r, &s).Verify(hash, key)
+}
+
+// pureDecompressPubkey parses a public key in the 33-byte compressed format.
+func pureDecompressPubkey(pubkey []byte) (*ecdsa.PublicKey, error) {
+	if len(pubkey) != 33 {
+		return nil, errInvalidCompressed
+	}
+	key, err := decred.ParsePubKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: S256(), X: key.X(), Y: key.Y()}, nil
+}
+
+// pureCompressPubkey encodes a public key to the 33-byte compressed format.
+func pureCompressPubkey(pubkey *ecdsa.PublicKey) []byte {
+	var x, y decred.FieldVal
+	x.SetByteSlice(math.PaddedBigBytes(pubkey.X, 32))
+	y.SetByteSlice(math.PaddedBigBytes(pubkey.Y, 32))
+	return decred.NewPublicKey(&x, &y).SerializeCompressed()
+}
+
+// pureECDH computes the x coordinate of prv*pub in constant time.
+func pureECDH(prv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) ([]byte, error) {
+	key, err := decredPrivateKey(prv)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+
+	dpub, err := decred.ParsePubKey(FromECDSAPub(pub))
+	if err != nil {
+		return nil, err
+	}
+	return decred.GenerateSharedSecret(key, dpub), nil
+}
+
+// decredPrivateKey converts prv, rejecting keys outside [1, N-1].
+func decredPrivateKey(prv *ecdsa.PrivateKey) (*decred.PrivateKey, error) {
+	if prv.D == nil || prv.D.Sign() <= 0 || prv.D.Cmp(secp256k1_N) >= 0 {
+		return nil, errInvalidPrivateKey
+	}
+	seckey := math.PaddedBigBytes(prv.D, 32)
+	defer zeroBytes(seckey)
+
+	var d decred.ModNScalar
+	d.SetByteSlice(seckey)
+	return decred.NewPrivateKey(&d), nil
+}
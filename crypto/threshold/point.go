@@ -0,0 +1,143 @@
+// Package threshold implements t-of-n key generation based on Feldman
+// verifiable secret sharing and FROST-style threshold Schnorr signatures over
+// the secp256k1 curve. It is intended for SCS committees that have to jointly
+// sign messages destined for the main chain.
+package threshold
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/common/math"
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+var (
+	curve = crypto.S256()
+	order = curve.Params().N
+
+	errInvalidPoint = errors.New("threshold: invalid curve point")
+)
+
+// Point is an affine secp256k1 point. The zero value (nil coordinates)
+// represents the point at infinity.
+type Point struct {
+	X, Y *big.Int
+}
+
+// IsInfinity reports whether p is the point at infinity.
+func (p Point) IsInfinity() bool {
+	return p.X == nil || p.Y == nil
+}
+
+// Equal reports whether p and q are the same point.
+func (p Point) Equal(q Point) bool {
+	if p.IsInfinity() || q.IsInfinity() {
+		return p.IsInfinity() && q.IsInfinity()
+	}
+	return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// Bytes returns the 33 byte compressed encoding of p. The point at infinity
+// is encoded as 33 zero bytes.
+func (p Point) Bytes() []byte {
+	out := make([]byte, 33)
+	if p.IsInfinity() {
+		return out
+	}
+	out[0] = 0x02 | byte(p.Y.Bit(0))
+	math.ReadBits(p.X, out[1:])
+	return out
+}
+
+// PointFromBytes decodes a 33 byte compressed point.
+func PointFromBytes(b []byte) (Point, error) {
+	if len(b) != 33 {
+		return Point{}, errInvalidPoint
+	}
+	if b[0] == 0 {
+		for _, c := range b[1:] {
+			if c != 0 {
+				return Point{}, errInvalidPoint
+			}
+		}
+		return Point{}, nil
+	}
+	pub, err := crypto.DecompressPubkey(b)
+	if err != nil {
+		return Point{}, errInvalidPoint
+	}
+	return Point{X: pub.X, Y: pub.Y}, nil
+}
+
+// addPoints returns p+q, handling the identity, doubling and inverse cases
+// which the underlying curve implementation does not cover.
+func addPoints(p, q Point) Point {
+	switch {
+	case p.IsInfinity():
+		return q
+	case q.IsInfinity():
+		return p
+	case p.X.Cmp(q.X) == 0:
+		if p.Y.Cmp(q.Y) == 0 {
+			x, y := curve.Double(p.X, p.Y)
+			return Point{X: x, Y: y}
+		}
+		return Point{}
+	}
+	x, y := curve.Add(p.X, p.Y, q.X, q.Y)
+	return Point{X: x, Y: y}
+}
+
+// mulPoint returns k*p.
+func mulPoint(p Point, k *big.Int) Point {
+	k = new(big.Int).Mod(k, order)
+	if p.IsInfinity() || k.Sign() == 0 {
+		return Point{}
+	}
+	x, y := curve.ScalarMult(p.X, p.Y, math.PaddedBigBytes(k, 32))
+	if x == nil {
+		return Point{}
+	}
+	return Point{X: x, Y: y}
+}
+
+// mulBase returns k*G.
+func mulBase(k *big.Int) Point {
+	k = new(big.Int).Mod(k, order)
+	if k.Sign() == 0 {
+		return Point{}
+	}
+	x, y := curve.ScalarBaseMult(math.PaddedBigBytes(k, 32))
+	if x == nil {
+		return Point{}
+	}
+	return Point{X: x, Y: y}
+}
+
+// randomScalar returns a uniformly random non-zero scalar modulo the curve order.
+func randomScalar() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// hashToScalar hashes the given domain tag and data with Keccak256 and
+// reduces the result modulo the curve order.
+func hashToScalar(tag string, data ...[]byte) *big.Int {
+	in := append([][]byte{[]byte(tag)}, data...)
+	h := new(big.Int).SetBytes(crypto.Keccak256(in...))
+	return h.Mod(h, order)
+}
+
+// scalarBytes returns the 32 byte big endian encoding of k.
+func scalarBytes(k *big.Int) []byte {
+	return math.PaddedBigBytes(k, 32)
+}
@@ -0,0 +1,304 @@
+package threshold
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Domain separation tags for the hashes used by the signing protocol.
+const (
+	tagBinding   = "MOAC-threshold-binding"
+	tagChallenge = "MOAC-threshold-challenge"
+)
+
+// SignatureLength is the length of an encoded Signature: the compressed
+// nonce point R followed by the 32 byte scalar z.
+const SignatureLength = 33 + 32
+
+var (
+	errNonceReused = errors.New("threshold: signing nonces already used")
+	// ErrCommitmentMismatch is returned by Sign if the signer's commitment in
+	// the session is not the one of its nonces. Signing anyway could leak
+	// the secret share to whoever substituted it.
+	ErrCommitmentMismatch = errors.New("threshold: commitment does not match nonces")
+)
+
+// NonceCommitment is the public commitment a signer broadcasts in the first
+// round of signing.
+type NonceCommitment struct {
+	ID   int
+	D, E Point
+}
+
+// Nonces holds the secret single-use nonces behind a NonceCommitment. They
+// are consumed by KeyShare.Sign and must never be reused.
+type Nonces struct {
+	d, e       *big.Int
+	Commitment NonceCommitment
+}
+
+// PartialSignature is a single signer's contribution to a threshold signature.
+type PartialSignature struct {
+	ID int
+	Z  *big.Int
+}
+
+// Signature is a Schnorr signature (R, z) valid under the group key Y when
+// z*G == R + H(R, Y, msg)*Y.
+type Signature struct {
+	R Point
+	Z *big.Int
+}
+
+// Bytes encodes the signature as R (compressed) || z.
+func (sig *Signature) Bytes() []byte {
+	out := make([]byte, 0, SignatureLength)
+	out = append(out, sig.R.Bytes()...)
+	return append(out, scalarBytes(sig.Z)...)
+}
+
+// SignatureFromBytes decodes a signature produced by Signature.Bytes.
+func SignatureFromBytes(b []byte) (*Signature, error) {
+	if len(b) != SignatureLength {
+		return nil, errors.New("threshold: invalid signature length")
+	}
+	r, err := PointFromBytes(b[:33])
+	if err != nil {
+		return nil, err
+	}
+	z := new(big.Int).SetBytes(b[33:])
+	if z.Cmp(order) >= 0 {
+		return nil, errors.New("threshold: signature scalar out of range")
+	}
+	return &Signature{R: r, Z: z}, nil
+}
+
+// Commit generates the signer's nonces for one signing session. The returned
+// commitment is broadcast to the coordinator; the nonces are kept secret.
+func (ks *KeyShare) Commit() (*Nonces, error) {
+	d, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	e, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	return &Nonces{
+		d: d,
+		e: e,
+		Commitment: NonceCommitment{
+			ID: ks.ID,
+			D:  mulBase(d),
+			E:  mulBase(e),
+		},
+	}, nil
+}
+
+// sortCommitments validates the signing set and returns it ordered by id.
+func sortCommitments(params Params, commitments []NonceCommitment) ([]NonceCommitment, []int, error) {
+	if len(commitments) < params.Threshold {
+		return nil, nil, ErrNotEnoughSigners
+	}
+	sorted := make([]NonceCommitment, len(commitments))
+	copy(sorted, commitments)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	set := make([]int, len(sorted))
+	for i, c := range sorted {
+		if !params.validID(c.ID) {
+			return nil, nil, ErrInvalidID
+		}
+		if i > 0 && sorted[i-1].ID == c.ID {
+			return nil, nil, ErrDuplicateSigner
+		}
+		if c.D.IsInfinity() || c.E.IsInfinity() {
+			return nil, nil, errInvalidPoint
+		}
+		set[i] = c.ID
+	}
+	return sorted, set, nil
+}
+
+// encodeCommitments serialises an ordered commitment list for hashing.
+func encodeCommitments(commitments []NonceCommitment) []byte {
+	enc := make([]byte, 0, len(commitments)*(8+33+33))
+	for _, c := range commitments {
+		var id [8]byte
+		binary.BigEndian.PutUint64(id[:], uint64(c.ID))
+		enc = append(enc, id[:]...)
+		enc = append(enc, c.D.Bytes()...)
+		enc = append(enc, c.E.Bytes()...)
+	}
+	return enc
+}
+
+// session holds the values shared by all signers of one signing session.
+type session struct {
+	set      []int
+	bindings map[int]*big.Int
+	R        Point
+	c        *big.Int
+}
+
+func newSession(params Params, groupKey Point, msg []byte, commitments []NonceCommitment) (*session, error) {
+	sorted, set, err := sortCommitments(params, commitments)
+	if err != nil {
+		return nil, err
+	}
+	enc := encodeCommitments(sorted)
+	s := &session{set: set, bindings: make(map[int]*big.Int, len(sorted))}
+	for _, c := range sorted {
+		var id [8]byte
+		binary.BigEndian.PutUint64(id[:], uint64(c.ID))
+		rho := hashToScalar(tagBinding, id[:], msg, enc)
+		s.bindings[c.ID] = rho
+		s.R = addPoints(s.R, addPoints(c.D, mulPoint(c.E, rho)))
+	}
+	if s.R.IsInfinity() {
+		return nil, errInvalidPoint
+	}
+	s.c = challenge(s.R, groupKey, msg)
+	return s, nil
+}
+
+func challenge(r, groupKey Point, msg []byte) *big.Int {
+	return hashToScalar(tagChallenge, r.Bytes(), groupKey.Bytes(), msg)
+}
+
+// Sign produces the signer's partial signature over msg for the session
+// described by the commitments of all participating signers, which must
+// include the signer's own commitment. The nonces are wiped afterwards.
+func (ks *KeyShare) Sign(nonces *Nonces, msg []byte, commitments []NonceCommitment) (*PartialSignature, error) {
+	if nonces == nil || nonces.d == nil || nonces.e == nil {
+		return nil, errNonceReused
+	}
+	s, err := newSession(ks.Params, ks.GroupKey, msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	rho, ok := s.bindings[ks.ID]
+	if !ok {
+		return nil, ErrUnknownSigner
+	}
+	own := nonces.Commitment
+	for _, c := range commitments {
+		if c.ID == ks.ID && (own.ID != ks.ID || !c.D.Equal(own.D) || !c.E.Equal(own.E)) {
+			return nil, ErrCommitmentMismatch
+		}
+	}
+	// z_i = d_i + e_i*rho_i + lambda_i*s_i*c
+	z := new(big.Int).Mul(nonces.e, rho)
+	z.Add(z, nonces.d)
+	term := new(big.Int).Mul(lagrangeCoefficient(ks.ID, s.set), ks.Secret)
+	term.Mul(term, s.c)
+	z.Add(z, term)
+	z.Mod(z, order)
+
+	nonces.d.SetInt64(0)
+	nonces.e.SetInt64(0)
+	nonces.d, nonces.e = nil, nil
+
+	return &PartialSignature{ID: ks.ID, Z: z}, nil
+}
+
+// VerifyPartial checks a single partial signature against the signer's
+// verification share. Coordinators use it to identify misbehaving signers.
+func (ks *KeyShare) VerifyPartial(msg []byte, commitments []NonceCommitment, ps *PartialSignature) error {
+	if ps == nil {
+		return ErrInvalidPartialSig
+	}
+	vs, ok := ks.VerificationShares[ps.ID]
+	if !ok {
+		return ErrInvalidID
+	}
+	s, err := newSession(ks.Params, ks.GroupKey, msg, commitments)
+	if err != nil {
+		return err
+	}
+	return s.verifyPartial(vs, commitments, ps)
+}
+
+func (s *session) verifyPartial(vs Point, commitments []NonceCommitment, ps *PartialSignature) error {
+	rho, ok := s.bindings[ps.ID]
+	if !ok {
+		return ErrUnknownSigner
+	}
+	var com *NonceCommitment
+	for i := range commitments {
+		if commitments[i].ID == ps.ID {
+			com = &commitments[i]
+			break
+		}
+	}
+	// z_i*G == D_i + rho_i*E_i + c*lambda_i*Y_i
+	expected := addPoints(com.D, mulPoint(com.E, rho))
+	k := new(big.Int).Mul(s.c, lagrangeCoefficient(ps.ID, s.set))
+	expected = addPoints(expected, mulPoint(vs, k))
+	if ps.Z == nil || !mulBase(ps.Z).Equal(expected) {
+		return ErrInvalidPartialSig
+	}
+	return nil
+}
+
+// Aggregate combines the partial signatures of every signer in the session
+// into a group signature. Each partial signature is verified first, so an
+// error names the offending signer.
+func (ks *KeyShare) Aggregate(msg []byte, commitments []NonceCommitment, partials []*PartialSignature) (*Signature, error) {
+	s, err := newSession(ks.Params, ks.GroupKey, msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(partials) != len(s.set) {
+		return nil, ErrNotEnoughSigners
+	}
+	seen := make(map[int]bool, len(partials))
+	z := new(big.Int)
+	for _, ps := range partials {
+		if ps == nil {
+			return nil, ErrInvalidPartialSig
+		}
+		if seen[ps.ID] {
+			return nil, ErrDuplicateSigner
+		}
+		seen[ps.ID] = true
+		vs, ok := ks.VerificationShares[ps.ID]
+		if !ok {
+			return nil, ErrInvalidID
+		}
+		if err := s.verifyPartial(vs, commitments, ps); err != nil {
+			return nil, &SignerError{ID: ps.ID, Err: err}
+		}
+		z.Add(z, ps.Z)
+	}
+	sig := &Signature{R: s.R, Z: z.Mod(z, order)}
+	if !Verify(ks.GroupKey, msg, sig) {
+		return nil, ErrInvalidPartialSig
+	}
+	return sig, nil
+}
+
+// SignerError reports which signer produced an invalid contribution.
+type SignerError struct {
+	ID  int
+	Err error
+}
+
+func (e *SignerError) Error() string {
+	return fmt.Sprintf("threshold: signer %d: %v", e.ID, e.Err)
+}
+
+// Verify checks a threshold Schnorr signature over msg against the group key.
+func Verify(groupKey Point, msg []byte, sig *Signature) bool {
+	if sig == nil || sig.Z == nil || sig.R.IsInfinity() || groupKey.IsInfinity() {
+		return false
+	}
+	if sig.Z.Sign() < 0 || sig.Z.Cmp(order) >= 0 {
+		return false
+	}
+	c := challenge(sig.R, groupKey, msg)
+	return mulBase(sig.Z).Equal(addPoints(sig.R, mulPoint(groupKey, c)))
+}
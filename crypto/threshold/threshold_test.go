@@ -0,0 +1,206 @@
+package threshold
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+// runKeygen runs a full distributed key generation among all parties.
+func runKeygen(t *testing.T, params Params) []*KeyShare {
+	dealings := make([]*Dealing, params.Parties)
+	commitments := make(map[int]Commitments)
+	proofs := make(map[int]*Proof)
+	for i := 1; i <= params.Parties; i++ {
+		d, err := Deal(params, i)
+		if err != nil {
+			t.Fatalf("deal %d: %v", i, err)
+		}
+		dealings[i-1] = d
+		commitments[i] = d.Commitments
+		proofs[i] = d.Proof
+	}
+	keys := make([]*KeyShare, params.Parties)
+	for j := 1; j <= params.Parties; j++ {
+		shares := make(map[int]*big.Int)
+		for _, d := range dealings {
+			shares[d.Dealer] = d.Shares[j]
+		}
+		ks, err := Combine(params, j, commitments, proofs, shares)
+		if err != nil {
+			t.Fatalf("combine %d: %v", j, err)
+		}
+		keys[j-1] = ks
+	}
+	for _, ks := range keys[1:] {
+		if !ks.GroupKey.Equal(keys[0].GroupKey) {
+			t.Fatal("participants disagree on the group key")
+		}
+	}
+	return keys
+}
+
+func thresholdSign(t *testing.T, signers []*KeyShare, msg []byte) (*Signature, error) {
+	nonces := make([]*Nonces, len(signers))
+	commitments := make([]NonceCommitment, len(signers))
+	for i, ks := range signers {
+		n, err := ks.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		commitments[i] = n.Commitment
+	}
+	partials := make([]*PartialSignature, len(signers))
+	for i, ks := range signers {
+		ps, err := ks.Sign(nonces[i], msg, commitments)
+		if err != nil {
+			return nil, err
+		}
+		partials[i] = ps
+	}
+	return signers[0].Aggregate(msg, commitments, partials)
+}
+
+func TestThresholdSignVerify(t *testing.T) {
+	params := Params{Threshold: 3, Parties: 5}
+	keys := runKeygen(t, params)
+	msg := crypto.Keccak256([]byte("subchain block 42"))
+
+	for _, set := range [][]int{{1, 2, 3}, {2, 4, 5}, {1, 3, 4, 5}} {
+		var signers []*KeyShare
+		for _, id := range set {
+			signers = append(signers, keys[id-1])
+		}
+		sig, err := thresholdSign(t, signers, msg)
+		if err != nil {
+			t.Fatalf("set %v: %v", set, err)
+		}
+		if !Verify(keys[0].GroupKey, msg, sig) {
+			t.Fatalf("set %v: signature does not verify", set)
+		}
+		dec, err := SignatureFromBytes(sig.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(keys[0].GroupKey, msg, dec) {
+			t.Fatalf("set %v: decoded signature does not verify", set)
+		}
+		if Verify(keys[0].GroupKey, []byte("other message"), sig) {
+			t.Fatalf("set %v: signature verifies for wrong message", set)
+		}
+	}
+}
+
+func TestThresholdNotEnoughSigners(t *testing.T) {
+	params := Params{Threshold: 3, Parties: 4}
+	keys := runKeygen(t, params)
+	if _, err := thresholdSign(t, keys[:2], []byte("msg")); err != ErrNotEnoughSigners {
+		t.Fatalf("expected %v, got %v", ErrNotEnoughSigners, err)
+	}
+}
+
+func TestThresholdBadPartial(t *testing.T) {
+	params := Params{Threshold: 2, Parties: 3}
+	keys := runKeygen(t, params)
+	msg := []byte("msg")
+
+	n1, _ := keys[0].Commit()
+	n2, _ := keys[1].Commit()
+	commitments := []NonceCommitment{n1.Commitment, n2.Commitment}
+	p1, err := keys[0].Sign(n1, msg, commitments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := keys[1].Sign(n2, msg, commitments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys[0].Sign(n1, msg, commitments); err != errNonceReused {
+		t.Fatalf("expected nonce reuse error, got %v", err)
+	}
+	p2.Z = new(big.Int).Add(p2.Z, big.NewInt(1))
+	_, err = keys[0].Aggregate(msg, commitments, []*PartialSignature{p1, p2})
+	if serr, ok := err.(*SignerError); !ok || serr.ID != 2 {
+		t.Fatalf("expected signer 2 to be blamed, got %v", err)
+	}
+}
+
+func TestVerifyShareAndRecover(t *testing.T) {
+	params := Params{Threshold: 3, Parties: 5}
+	secret := big.NewInt(123456789)
+	d, err := DealSecret(params, 1, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, share := range d.Shares {
+		if err := d.Commitments.VerifyShare(id, share); err != nil {
+			t.Fatalf("share %d: %v", id, err)
+		}
+	}
+	bad := new(big.Int).Add(d.Shares[2], big.NewInt(1))
+	if err := d.Commitments.VerifyShare(2, bad); err != ErrInvalidShare {
+		t.Fatalf("expected invalid share, got %v", err)
+	}
+	got, err := RecoverSecret(params, map[int]*big.Int{1: d.Shares[1], 3: d.Shares[3], 5: d.Shares[5]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(secret) != 0 {
+		t.Fatalf("recovered %v, want %v", got, secret)
+	}
+	if !mulBase(secret).Equal(d.Commitments[0]) {
+		t.Fatal("commitment to secret mismatch")
+	}
+	if _, err := RecoverSecret(params, map[int]*big.Int{1: d.Shares[1], 3: nil, 5: d.Shares[5]}); err != ErrInvalidShare {
+		t.Fatalf("nil share: expected invalid share, got %v", err)
+	}
+}
+
+func TestCombineRejectsRogueDealer(t *testing.T) {
+	params := Params{Threshold: 2, Parties: 2}
+	d1, _ := Deal(params, 1)
+	d2, _ := Deal(params, 2)
+	shares := map[int]*big.Int{1: d1.Shares[1], 2: d2.Shares[1]}
+	commitments := map[int]Commitments{1: d1.Commitments, 2: d2.Commitments}
+
+	// A proof is bound to its dealer and can't be replayed by another one.
+	for _, proofs := range []map[int]*Proof{{1: d1.Proof}, {1: d1.Proof, 2: d1.Proof}} {
+		if _, err := Combine(params, 1, commitments, proofs, shares); err == nil {
+			t.Fatal("dealing without a valid proof accepted")
+		}
+	}
+	if _, err := Combine(params, 1, commitments, map[int]*Proof{1: d1.Proof, 2: d2.Proof}, shares); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dealers must be participants.
+	d3, _ := Deal(Params{Threshold: 2, Parties: 3}, 3)
+	commitments[3], shares[3] = d3.Commitments, d3.Shares[1]
+	proofs := map[int]*Proof{1: d1.Proof, 2: d2.Proof, 3: d3.Proof}
+	if _, err := Combine(params, 1, commitments, proofs, shares); err != ErrInvalidID {
+		t.Fatalf("dealer outside the parties: expected invalid id, got %v", err)
+	}
+}
+
+func TestSignRejectsSubstitutedCommitment(t *testing.T) {
+	params := Params{Threshold: 2, Parties: 2}
+	keys := runKeygen(t, params)
+	msg := []byte("msg")
+
+	n1, _ := keys[0].Commit()
+	n2, _ := keys[1].Commit()
+	other, _ := keys[0].Commit()
+	forged := other.Commitment
+	if _, err := keys[0].Sign(n1, msg, []NonceCommitment{forged, n2.Commitment}); err != ErrCommitmentMismatch {
+		t.Fatalf("expected %v, got %v", ErrCommitmentMismatch, err)
+	}
+	commitments := []NonceCommitment{n1.Commitment, n2.Commitment}
+	if err := keys[0].VerifyPartial(msg, commitments, nil); err != ErrInvalidPartialSig {
+		t.Fatalf("expected %v, got %v", ErrInvalidPartialSig, err)
+	}
+	if _, err := keys[0].Aggregate(msg, commitments, []*PartialSignature{nil, nil}); err != ErrInvalidPartialSig {
+		t.Fatalf("expected %v, got %v", ErrInvalidPartialSig, err)
+	}
+}
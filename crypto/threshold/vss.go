@@ -0,0 +1,302 @@
+package threshold
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	ErrInvalidParams     = errors.New("threshold: invalid threshold parameters")
+	ErrInvalidShare      = errors.New("threshold: share does not match commitments")
+	ErrInvalidID         = errors.New("threshold: invalid participant id")
+	ErrMissingDealing    = errors.New("threshold: missing dealing from participant")
+	ErrDuplicateSigner   = errors.New("threshold: duplicate signer")
+	ErrNotEnoughSigners  = errors.New("threshold: not enough signers")
+	ErrUnknownSigner     = errors.New("threshold: signer not part of signing set")
+	ErrInvalidPartialSig = errors.New("threshold: invalid partial signature")
+	ErrInvalidProof      = errors.New("threshold: invalid proof of knowledge")
+)
+
+// Params describes a t-of-n threshold scheme: any Threshold of the Parties
+// participants can sign. Participants are identified by 1..Parties.
+type Params struct {
+	Threshold int
+	Parties   int
+}
+
+func (p Params) validate() error {
+	if p.Threshold < 1 || p.Parties < p.Threshold {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+func (p Params) validID(id int) bool {
+	return id >= 1 && id <= p.Parties
+}
+
+// polynomial is a polynomial over the scalar field, coefficients in
+// ascending order of degree.
+type polynomial []*big.Int
+
+func randomPolynomial(secret *big.Int, degree int) (polynomial, error) {
+	poly := make(polynomial, degree+1)
+	poly[0] = new(big.Int).Mod(secret, order)
+	for i := 1; i <= degree; i++ {
+		c, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		poly[i] = c
+	}
+	return poly, nil
+}
+
+// eval evaluates the polynomial at x using Horner's rule.
+func (poly polynomial) eval(x int) *big.Int {
+	bx := big.NewInt(int64(x))
+	res := new(big.Int)
+	for i := len(poly) - 1; i >= 0; i-- {
+		res.Mul(res, bx)
+		res.Add(res, poly[i])
+		res.Mod(res, order)
+	}
+	return res
+}
+
+// Commitments are the Feldman commitments a_k*G to the coefficients of a
+// dealer's secret polynomial. Commitments[0] commits to the shared secret.
+type Commitments []Point
+
+// Evaluate returns the commitment to the share of participant id, i.e.
+// sum(C_k * id^k).
+func (c Commitments) Evaluate(id int) Point {
+	var (
+		res Point
+		x   = big.NewInt(int64(id))
+		pow = big.NewInt(1)
+	)
+	for _, ck := range c {
+		res = addPoints(res, mulPoint(ck, pow))
+		pow = new(big.Int).Mul(pow, x)
+		pow.Mod(pow, order)
+	}
+	return res
+}
+
+// VerifyShare checks a share received from a dealer against the dealer's
+// published commitments.
+func (c Commitments) VerifyShare(id int, share *big.Int) error {
+	if share == nil || len(c) == 0 {
+		return ErrInvalidShare
+	}
+	if !mulBase(share).Equal(c.Evaluate(id)) {
+		return ErrInvalidShare
+	}
+	return nil
+}
+
+// tagKeyProof is the domain separation tag of dealer proofs of knowledge.
+const tagKeyProof = "MOAC-threshold-keygen"
+
+// Proof is a Schnorr proof of knowledge of the secret a_0 behind
+// Commitments[0], bound to the dealer id. Without it a rogue dealer could
+// choose its commitment after seeing the others' and control the group key.
+type Proof struct {
+	R  Point
+	Mu *big.Int
+}
+
+func proofChallenge(dealer int, c0, r Point) *big.Int {
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], uint64(dealer))
+	return hashToScalar(tagKeyProof, id[:], c0.Bytes(), r.Bytes())
+}
+
+func proveKnowledge(dealer int, secret *big.Int, c0 Point) (*Proof, error) {
+	k, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	r := mulBase(k)
+	// mu = k + a_0*c
+	mu := new(big.Int).Mul(secret, proofChallenge(dealer, c0, r))
+	mu.Add(mu, k)
+	return &Proof{R: r, Mu: mu.Mod(mu, order)}, nil
+}
+
+// VerifyProof checks the dealer's proof of knowledge of the secret behind
+// its commitments.
+func (c Commitments) VerifyProof(dealer int, proof *Proof) error {
+	if len(c) == 0 || proof == nil || proof.Mu == nil || proof.R.IsInfinity() {
+		return ErrInvalidProof
+	}
+	// mu*G == R + c*C_0
+	expected := addPoints(proof.R, mulPoint(c[0], proofChallenge(dealer, c[0], proof.R)))
+	if !mulBase(proof.Mu).Equal(expected) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// Dealing is the output of a single participant's round of the distributed
+// key generation: public commitments to its polynomial and one private share
+// per participant, to be delivered over a confidential channel. The proof of
+// knowledge is broadcast along with the commitments.
+type Dealing struct {
+	Dealer      int
+	Commitments Commitments
+	Proof       *Proof
+	Shares      map[int]*big.Int
+}
+
+// Deal generates a random secret and splits it using Feldman VSS. Every
+// participant of a distributed key generation calls Deal once and sends
+// Shares[j] privately to participant j, broadcasting Commitments and Proof
+// to all.
+func Deal(params Params, dealer int) (*Dealing, error) {
+	secret, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	return DealSecret(params, dealer, secret)
+}
+
+// DealSecret splits the given secret using Feldman VSS. It can be used by a
+// trusted dealer to share an existing key with a committee.
+func DealSecret(params Params, dealer int, secret *big.Int) (*Dealing, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if !params.validID(dealer) {
+		return nil, ErrInvalidID
+	}
+	poly, err := randomPolynomial(secret, params.Threshold-1)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dealing{
+		Dealer:      dealer,
+		Commitments: make(Commitments, len(poly)),
+		Shares:      make(map[int]*big.Int, params.Parties),
+	}
+	for i, coeff := range poly {
+		d.Commitments[i] = mulBase(coeff)
+	}
+	if d.Proof, err = proveKnowledge(dealer, poly[0], d.Commitments[0]); err != nil {
+		return nil, err
+	}
+	for j := 1; j <= params.Parties; j++ {
+		d.Shares[j] = poly.eval(j)
+	}
+	return d, nil
+}
+
+// KeyShare is a participant's long term share of a group key.
+type KeyShare struct {
+	Params Params
+	ID     int
+	Secret *big.Int
+
+	// GroupKey is the joint public key the committee signs for.
+	GroupKey Point
+	// VerificationShares maps every participant to the public counterpart
+	// of its secret share, used to verify partial signatures.
+	VerificationShares map[int]Point
+}
+
+// Combine finalises the distributed key generation for participant id. It
+// takes the commitments and proofs broadcast by every dealer and the shares
+// sent to id, verifies them and returns the resulting key share.
+func Combine(params Params, id int, commitments map[int]Commitments, proofs map[int]*Proof, shares map[int]*big.Int) (*KeyShare, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if !params.validID(id) {
+		return nil, ErrInvalidID
+	}
+	if len(commitments) == 0 {
+		return nil, ErrMissingDealing
+	}
+	ks := &KeyShare{
+		Params:             params,
+		ID:                 id,
+		Secret:             new(big.Int),
+		VerificationShares: make(map[int]Point, params.Parties),
+	}
+	for dealer, c := range commitments {
+		if !params.validID(dealer) {
+			return nil, ErrInvalidID
+		}
+		if len(c) != params.Threshold {
+			return nil, fmt.Errorf("threshold: dealer %d committed to %d coefficients, want %d", dealer, len(c), params.Threshold)
+		}
+		if err := c.VerifyProof(dealer, proofs[dealer]); err != nil {
+			return nil, fmt.Errorf("threshold: dealer %d: %v", dealer, err)
+		}
+		share, ok := shares[dealer]
+		if !ok {
+			return nil, fmt.Errorf("%v %d", ErrMissingDealing, dealer)
+		}
+		if err := c.VerifyShare(id, share); err != nil {
+			return nil, fmt.Errorf("threshold: share from dealer %d: %v", dealer, err)
+		}
+		ks.Secret.Add(ks.Secret, share)
+		ks.GroupKey = addPoints(ks.GroupKey, c[0])
+	}
+	ks.Secret.Mod(ks.Secret, order)
+	for j := 1; j <= params.Parties; j++ {
+		var vs Point
+		for _, c := range commitments {
+			vs = addPoints(vs, c.Evaluate(j))
+		}
+		ks.VerificationShares[j] = vs
+	}
+	return ks, nil
+}
+
+// lagrangeCoefficient returns the Lagrange coefficient of id for
+// interpolating at zero over the given set of participants.
+func lagrangeCoefficient(id int, set []int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, j := range set {
+		if j == id {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		num.Mod(num, order)
+		den.Mul(den, big.NewInt(int64(j-id)))
+		den.Mod(den, order)
+	}
+	den.ModInverse(den, order)
+	return num.Mul(num, den).Mod(num, order)
+}
+
+// RecoverSecret interpolates the shared secret from at least threshold
+// shares. It is mostly useful for testing and key migration, since it
+// reconstructs the group private key in one place.
+func RecoverSecret(params Params, shares map[int]*big.Int) (*big.Int, error) {
+	if len(shares) < params.Threshold {
+		return nil, ErrNotEnoughSigners
+	}
+	set := make([]int, 0, len(shares))
+	for id, share := range shares {
+		if !params.validID(id) {
+			return nil, ErrInvalidID
+		}
+		if share == nil {
+			return nil, ErrInvalidShare
+		}
+		set = append(set, id)
+	}
+	sort.Ints(set)
+	secret := new(big.Int)
+	for _, id := range set {
+		term := new(big.Int).Mul(shares[id], lagrangeCoefficient(id, set))
+		secret.Add(secret, term)
+	}
+	return secret.Mod(secret, order), nil
+}
@@ -0,0 +1,311 @@
+// Package filters implements log queries over a range of blocks. Blocks are
+// pre-screened with their header bloom and the receipts of candidate blocks
+// are then matched against address and topic criteria.
+package filters
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/bloombits"
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// LatestBlockNumber can be used as the begin or end of a range filter to
+// refer to the current head of the chain.
+const LatestBlockNumber int64 = -1
+
+var (
+	errInvalidRange    = errors.New("filters: invalid block range")
+	errUnknownBlock    = errors.New("filters: unknown block")
+	errMissingReceipts = errors.New("filters: receipts missing for block")
+)
+
+// Backend provides the chain data a Filter runs on.
+type Backend interface {
+	// CurrentHeader returns the header of the head of the chain.
+	CurrentHeader(ctx context.Context) (*types.Header, error)
+
+	// HeaderByNumber returns the canonical header with the given number, or
+	// nil if it does not exist.
+	HeaderByNumber(ctx context.Context, number uint64) (*types.Header, error)
+
+	// HeaderByHash returns the header with the given hash, or nil if it does
+	// not exist.
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+
+	// GetReceipts returns the receipts of the block with the given hash, in
+	// transaction order.
+	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+}
+
+// BloomIndexBackend is a Backend that also maintains a bloombits index. Range
+// filters use the index to find candidate blocks and only scan the headers
+// of blocks the index does not cover yet.
+type BloomIndexBackend interface {
+	Backend
+
+	// BloomIndex returns the bloombits index of the chain, or nil if none
+	// is available.
+	BloomIndex() bloombits.Retriever
+}
+
+// Filter can be used to retrieve and filter logs.
+type Filter struct {
+	backend Backend
+
+	addresses []common.Address
+	topics    [][]common.Hash
+
+	block      *common.Hash // Block hash if filtering a single block
+	begin, end int64        // Range interval if filtering multiple blocks
+}
+
+// NewRangeFilter creates a new filter which inspects the blocks from begin
+// to end, both inclusive. LatestBlockNumber may be given for either bound.
+//
+// A log matches if it was emitted by one of the addresses (any address if
+// none are given) and, for every topic position, its topic is one of the
+// alternatives given for that position. An empty position matches any
+// topic.
+func NewRangeFilter(backend Backend, begin, end int64, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{
+		backend:   backend,
+		addresses: addresses,
+		topics:    topics,
+		begin:     begin,
+		end:       end,
+	}
+}
+
+// NewBlockFilter creates a new filter which directly inspects the contents of
+// a block to figure out whether it is interesting or not.
+func NewBlockFilter(backend Backend, block common.Hash, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{
+		backend:   backend,
+		addresses: addresses,
+		topics:    topics,
+		block:     &block,
+	}
+}
+
+// Logs searches the blockchain for matching log entries, returning them in
+// chain order with their derived fields populated.
+func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	// If we're doing singleton block filtering, execute and return
+	if f.block != nil {
+		header, err := f.backend.HeaderByHash(ctx, *f.block)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		return f.blockLogs(ctx, header)
+	}
+	// Figure out the limits of the filter range
+	head, err := f.backend.CurrentHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, nil
+	}
+	begin, end := f.begin, f.end
+	if begin == LatestBlockNumber {
+		begin = head.Number.Int64()
+	}
+	if end == LatestBlockNumber || end > head.Number.Int64() {
+		end = head.Number.Int64()
+	}
+	if begin < 0 || end < 0 {
+		return nil, errInvalidRange
+	}
+	var logs []*types.Log
+	if b, ok := f.backend.(BloomIndexBackend); ok {
+		if index := b.BloomIndex(); index != nil {
+			found, indexed, err := f.indexedLogs(ctx, index, uint64(begin), uint64(end))
+			if err != nil {
+				return found, err
+			}
+			logs = found
+			if int64(indexed) > begin {
+				begin = int64(indexed)
+			}
+		}
+	}
+	for number := begin; number <= end; number++ {
+		if err := ctx.Err(); err != nil {
+			return logs, err
+		}
+		header, err := f.backend.HeaderByNumber(ctx, uint64(number))
+		if err != nil {
+			return logs, err
+		}
+		if header == nil {
+			return logs, errUnknownBlock
+		}
+		found, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, found...)
+	}
+	return logs, nil
+}
+
+// indexedLogs returns the matching logs of the blocks covered by the bloombits
+// index, along with the first block number the index does not cover.
+func (f *Filter) indexedLogs(ctx context.Context, index bloombits.Retriever, begin, end uint64) ([]*types.Log, uint64, error) {
+	criteria := make([][][]byte, 0, len(f.topics)+1)
+	addresses := make([][]byte, len(f.addresses))
+	for i, addr := range f.addresses {
+		addresses[i] = addr.Bytes()
+	}
+	criteria = append(criteria, addresses)
+	for _, sub := range f.topics {
+		topics := make([][]byte, len(sub))
+		for i, topic := range sub {
+			topics[i] = topic.Bytes()
+		}
+		criteria = append(criteria, topics)
+	}
+	candidates, indexed, err := bloombits.NewMatcher(criteria).Execute(ctx, index, begin, end)
+	if err != nil {
+		return nil, indexed, err
+	}
+	var logs []*types.Log
+	for _, number := range candidates {
+		header, err := f.backend.HeaderByNumber(ctx, number)
+		if err != nil {
+			return logs, indexed, err
+		}
+		if header == nil {
+			return logs, indexed, errUnknownBlock
+		}
+		found, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return logs, indexed, err
+		}
+		logs = append(logs, found...)
+	}
+	return logs, indexed, nil
+}
+
+// blockLogs returns the logs matching the filter criteria within a single block.
+func (f *Filter) blockLogs(ctx context.Context, header *types.Header) ([]*types.Log, error) {
+	if !BloomFilter(header.Bloom, f.addresses, f.topics) {
+		return nil, nil
+	}
+	receipts, err := f.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipts == nil && header.ReceiptHash != types.EmptyRootHash {
+		return nil, errMissingReceipts
+	}
+	logs := FilterLogs(deriveLogs(header, receipts), nil, nil, f.addresses, f.topics)
+	return logs, nil
+}
+
+// deriveLogs returns copies of all logs in the receipts of a block with the
+// fields derived from their position in the chain filled in.
+func deriveLogs(header *types.Header, receipts types.Receipts) []*types.Log {
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+		logs   []*types.Log
+		index  uint
+	)
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			derived := *log
+			derived.BlockNumber = number
+			derived.BlockHash = hash
+			derived.TxHash = receipt.TxHash
+			derived.TxIndex = uint(i)
+			derived.Index = index
+			logs = append(logs, &derived)
+			index++
+		}
+	}
+	return logs
+}
+
+// FilterLogs creates a slice of logs matching the given criteria. Nil block
+// bounds are not checked.
+func FilterLogs(logs []*types.Log, fromBlock, toBlock *big.Int, addresses []common.Address, topics [][]common.Hash) []*types.Log {
+	var ret []*types.Log
+Logs:
+	for _, log := range logs {
+		if fromBlock != nil && fromBlock.Int64() >= 0 && fromBlock.Uint64() > log.BlockNumber {
+			continue
+		}
+		if toBlock != nil && toBlock.Int64() >= 0 && toBlock.Uint64() < log.BlockNumber {
+			continue
+		}
+		if len(addresses) > 0 && !includes(addresses, log.Address) {
+			continue
+		}
+		// If the to filtered topics is greater than the amount of topics in logs, skip.
+		if len(topics) > len(log.Topics) {
+			continue
+		}
+		for i, sub := range topics {
+			match := len(sub) == 0 // empty rule set == wildcard
+			for _, topic := range sub {
+				if log.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+// BloomFilter reports whether a block with the given bloom may contain logs
+// matching the criteria. False positives are possible, false negatives are
+// not.
+func BloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, sub := range topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+func includes(addresses []common.Address, a common.Address) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
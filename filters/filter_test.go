@@ -0,0 +1,230 @@
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/bloombits"
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/mcdb"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// testBackend is an in-memory chain of headers and receipts.
+type testBackend struct {
+	headers  []*types.Header
+	receipts map[common.Hash]types.Receipts
+	fetched  int // number of GetReceipts calls
+}
+
+func newTestBackend(blocks [][]*types.Receipt) *testBackend {
+	b := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	for i, receipts := range blocks {
+		for _, r := range receipts {
+			r.Bloom = types.CreateBloom(types.Receipts{r})
+		}
+		header := &types.Header{
+			Number: big.NewInt(int64(i)),
+			Bloom:  types.CreateBloom(receipts),
+			Extra:  []byte{byte(i)},
+		}
+		b.headers = append(b.headers, header)
+		b.receipts[header.Hash()] = receipts
+	}
+	return b
+}
+
+func (b *testBackend) CurrentHeader(ctx context.Context) (*types.Header, error) {
+	return b.headers[len(b.headers)-1], nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number uint64) (*types.Header, error) {
+	if number >= uint64(len(b.headers)) {
+		return nil, nil
+	}
+	return b.headers[number], nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	for _, h := range b.headers {
+		if h.Hash() == hash {
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	b.fetched++
+	return b.receipts[hash], nil
+}
+
+func receipt(tx byte, logs ...*types.Log) *types.Receipt {
+	return &types.Receipt{TxHash: common.BytesToHash([]byte{tx}), Logs: logs}
+}
+
+func mklog(addr common.Address, topics ...common.Hash) *types.Log {
+	return &types.Log{Address: addr, Topics: topics}
+}
+
+var (
+	addr1  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	addr2  = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	topicA = common.HexToHash("0xaaaa")
+	topicB = common.HexToHash("0xbbbb")
+	topicC = common.HexToHash("0xcccc")
+)
+
+func testChain() *testBackend {
+	return newTestBackend([][]*types.Receipt{
+		{receipt(1, mklog(addr1, topicA))},
+		{},
+		{receipt(2, mklog(addr2, topicB)), receipt(3, mklog(addr1, topicA, topicC), mklog(addr1, topicB, topicC))},
+		{receipt(4, mklog(addr2, topicC, topicA))},
+	})
+}
+
+func TestRangeFilter(t *testing.T) {
+	tests := []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+		want       []uint64 // block numbers of the matches
+	}{
+		{0, LatestBlockNumber, nil, nil, []uint64{0, 2, 2, 2, 3}},
+		{0, LatestBlockNumber, []common.Address{addr1}, nil, []uint64{0, 2, 2}},
+		{0, LatestBlockNumber, nil, [][]common.Hash{{topicA}}, []uint64{0, 2}},
+		{0, LatestBlockNumber, nil, [][]common.Hash{{topicA, topicB}}, []uint64{0, 2, 2, 2}},
+		{0, LatestBlockNumber, nil, [][]common.Hash{nil, {topicC}}, []uint64{2, 2}},
+		{0, LatestBlockNumber, nil, [][]common.Hash{{topicC}, {topicA}}, []uint64{3}},
+		{0, LatestBlockNumber, []common.Address{addr2}, [][]common.Hash{{topicA}}, nil},
+		{1, 2, nil, nil, []uint64{2, 2, 2}},
+		{LatestBlockNumber, LatestBlockNumber, nil, nil, []uint64{3}},
+		{2, 100, nil, [][]common.Hash{{topicB}}, []uint64{2, 2}},
+	}
+	for i, tt := range tests {
+		logs, err := NewRangeFilter(testChain(), tt.begin, tt.end, tt.addresses, tt.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if len(logs) != len(tt.want) {
+			t.Errorf("test %d: have %d logs, want %d", i, len(logs), len(tt.want))
+			continue
+		}
+		for j, log := range logs {
+			if log.BlockNumber != tt.want[j] {
+				t.Errorf("test %d, log %d: block %d, want %d", i, j, log.BlockNumber, tt.want[j])
+			}
+		}
+	}
+}
+
+func TestDerivedFields(t *testing.T) {
+	backend := testChain()
+	logs, err := NewRangeFilter(backend, 2, 2, nil, [][]common.Hash{{topicC}}).Logs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := backend.headers[2]
+	for i, log := range logs {
+		if log.BlockHash != header.Hash() || log.TxHash != common.BytesToHash([]byte{3}) || log.TxIndex != 1 {
+			t.Errorf("log %d: wrong derived fields %+v", i, log)
+		}
+		if log.Index != uint(i+1) {
+			t.Errorf("log %d: index %d, want %d", i, log.Index, i+1)
+		}
+	}
+	// The backend's logs must not be modified.
+	if backend.receipts[header.Hash()][1].Logs[0].BlockNumber != 0 {
+		t.Error("filter modified the backend receipts")
+	}
+}
+
+func TestBloomPrescreen(t *testing.T) {
+	backend := testChain()
+	unknown := common.HexToAddress("0x9999999999999999999999999999999999999999")
+	logs, err := NewRangeFilter(backend, 0, LatestBlockNumber, []common.Address{unknown}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 0 || backend.fetched != 0 {
+		t.Errorf("have %d logs after fetching %d receipts, want none", len(logs), backend.fetched)
+	}
+}
+
+func TestBlockFilter(t *testing.T) {
+	backend := testChain()
+	logs, err := NewBlockFilter(backend, backend.headers[3].Hash(), []common.Address{addr2}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].BlockNumber != 3 {
+		t.Errorf("wrong logs %v", logs)
+	}
+	if _, err := NewBlockFilter(backend, common.Hash{1}, nil, nil).Logs(context.Background()); err != errUnknownBlock {
+		t.Errorf("expected errUnknownBlock, got %v", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewRangeFilter(testChain(), 0, LatestBlockNumber, nil, nil).Logs(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// indexedBackend serves a bloombits index covering part of its chain.
+type indexedBackend struct {
+	*testBackend
+	index *bloombits.Index
+}
+
+func (b *indexedBackend) BloomIndex() bloombits.Retriever {
+	return b.index
+}
+
+func TestIndexedRangeFilter(t *testing.T) {
+	// Build a longer chain with a few matching blocks, indexing all but the
+	// last partial section.
+	blocks := make([][]*types.Receipt, 40)
+	for i := range blocks {
+		switch {
+		case i%7 == 3:
+			blocks[i] = []*types.Receipt{receipt(byte(i), mklog(addr1, topicA))}
+		case i%5 == 1:
+			blocks[i] = []*types.Receipt{receipt(byte(i), mklog(addr2, topicB))}
+		}
+	}
+	db, _ := mcdb.NewMemDatabase()
+	index, err := bloombits.NewIndex(db, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &indexedBackend{testBackend: newTestBackend(blocks), index: index}
+	indexer := bloombits.NewIndexer(index)
+	for _, header := range backend.headers {
+		if err := indexer.Process(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range [][2]int64{{0, LatestBlockNumber}, {5, 20}, {33, 39}} {
+		indexed, err := NewRangeFilter(backend, r[0], r[1], []common.Address{addr1}, [][]common.Hash{{topicA}}).Logs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := NewRangeFilter(backend.testBackend, r[0], r[1], []common.Address{addr1}, [][]common.Hash{{topicA}}).Logs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(indexed) == 0 || len(indexed) != len(plain) {
+			t.Fatalf("range %v: indexed filter found %d logs, plain filter %d", r, len(indexed), len(plain))
+		}
+		for i := range indexed {
+			if indexed[i].BlockNumber != plain[i].BlockNumber {
+				t.Errorf("range %v, log %d: block %d, want %d", r, i, indexed[i].BlockNumber, plain[i].BlockNumber)
+			}
+		}
+	}
+}
@@ -6,25 +6,34 @@
 package jsonrpc2
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"net"
 	"net/rpc"
 	"reflect"
+	"strings"
 	"sync"
 )
 
 const seqNotify = math.MaxUint64
 
 type clientCodec struct {
-	dec *json.Decoder // for reading JSON values
-	enc *json.Encoder // for writing JSON values
-	c   io.Closer
+	dec      *json.Decoder // for reading JSON values
+	encmutex sync.Mutex    // protects enc, batching and batch
+	enc      *json.Encoder // for writing JSON values
+	c        io.Closer
 
 	// temporary work space
-	resp clientResponse
+	resp  clientResponse
+	queue []json.RawMessage // responses of a batch reply not read yet
+
+	// Requests are collected in batch while a batch call is being sent.
+	batching int
+	batch    []json.RawMessage
 
 	// JSON-RPC responses include the request id but not the request method.
 	// Package rpc expects both.
@@ -32,6 +41,13 @@ type clientCodec struct {
 	// and then look it up by request ID when filling out the rpc Response.
 	mutex   sync.Mutex        // protects pending
 	pending map[uint64]string // map request id to method name
+	method  string            // method of the response being read
+
+	// Subscriptions are registered as soon as the reply to "*_subscribe"
+	// is read, so that no notification following it is lost.
+	submutex sync.Mutex // protects subs
+	subs     map[string]*ClientSubscription
+	client   *rpc.Client // used by subscriptions to unsubscribe
 }
 
 // NewClientCodec returns a new rpc.ClientCodec using JSON-RPC 2.0 on conn.
@@ -41,6 +57,7 @@ func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
 		enc:     json.NewEncoder(conn),
 		c:       conn,
 		pending: make(map[uint64]string),
+		subs:    make(map[string]*ClientSubscription),
 	}
 }
 
@@ -94,17 +111,53 @@ func (c *clientCodec) WriteRequest(r *rpc.Request, param interface{}) error {
 		c.mutex.Lock()
 		c.pending[r.Seq] = r.ServiceMethod
 		c.mutex.Unlock()
-		req.ID = &r.Seq
+		seq := r.Seq // r is reused by rpc.Client
+		req.ID = &seq
 	}
 	req.Version = "2.0"
 	req.Method = r.ServiceMethod
 	req.Params = param
+
+	c.encmutex.Lock()
+	defer c.encmutex.Unlock()
+	if c.batching > 0 {
+		// Encoded now, so that the call fails rather than the batch.
+		raw, err := json.Marshal(&req)
+		if err != nil {
+			return NewError(errInternal.Code, err.Error())
+		}
+		c.batch = append(c.batch, raw)
+		return nil
+	}
 	if err := c.enc.Encode(&req); err != nil {
 		return NewError(errInternal.Code, err.Error())
 	}
 	return nil
 }
 
+// beginBatch makes WriteRequest collect requests until the matching
+// endBatch, which sends them as one batch.
+func (c *clientCodec) beginBatch() {
+	c.encmutex.Lock()
+	c.batching++
+	c.encmutex.Unlock()
+}
+
+func (c *clientCodec) endBatch() error {
+	c.encmutex.Lock()
+	defer c.encmutex.Unlock()
+	c.batching--
+	if c.batching > 0 || len(c.batch) == 0 {
+		return nil
+	}
+	batch := c.batch
+	c.batch = nil
+	if err := c.enc.Encode(batch); err != nil {
+		return NewError(errInternal.Code, err.Error())
+	}
+	return nil
+}
+
 type clientResponse struct {
 	Version string           `json:"jsonrpc"`
 	ID      *uint64          `json:"id"`
@@ -121,8 +174,8 @@ func (r *clientResponse) reset() {
 
 func (r *clientResponse) UnmarshalJSON(raw []byte) error {
 	r.reset()
-	type resp *clientResponse
-	if err := json.Unmarshal(raw, resp(r)); err != nil {
+	type resp clientResponse
+	if err := json.Unmarshal(raw, (*resp)(r)); err != nil {
 		return errors.New("bad response: " + string(raw))
 	}
 
@@ -171,11 +224,34 @@ func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
 	// - it will be returned as is for all pending calls
 	// - client will be shutdown
 	// So, return io.EOF as is, return *Error for all other errors.
-	if err := c.dec.Decode(&c.resp); err != nil {
-		if err == io.EOF {
-			return err
+	for {
+		var raw json.RawMessage
+		var err error
+		if len(c.queue) > 0 {
+			raw, c.queue = c.queue[0], c.queue[1:]
+		} else {
+			err = c.dec.Decode(&raw)
 		}
-		return NewError(errInternal.Code, err.Error())
+		if err == nil && len(raw) > 0 && raw[0] == '[' {
+			// Batch reply, its responses are read one by one.
+			if err = json.Unmarshal(raw, &c.queue); err == nil {
+				continue
+			}
+		}
+		if err == nil && c.handleNotification(raw) {
+			continue
+		}
+		if err == nil {
+			err = json.Unmarshal(raw, &c.resp)
+		}
+		if err != nil {
+			c.closeSubscriptions(err)
+			if err == io.EOF {
+				return err
+			}
+			return NewError(errInternal.Code, err.Error())
+		}
+		break
 	}
 	if c.resp.ID == nil {
 		return c.resp.Error
@@ -185,6 +261,7 @@ func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
 	r.ServiceMethod = c.pending[*c.resp.ID]
 	delete(c.pending, *c.resp.ID)
 	c.mutex.Unlock()
+	c.method = r.ServiceMethod
 
 	r.Error = ""
 	r.Seq = *c.resp.ID
@@ -208,10 +285,66 @@ func (c *clientCodec) ReadResponseBody(x interface{}) error {
 		e.Data = NewError(errInternal.Code, "some other Call failed to unmarshal Reply")
 		return e
 	}
+	if id, ok := x.(*string); ok && strings.HasSuffix(c.method, subscribeSuffix) {
+		namespace := strings.TrimSuffix(c.method, subscribeSuffix)
+		c.submutex.Lock()
+		c.subs[*id] = newClientSubscription(c, namespace, *id)
+		c.submutex.Unlock()
+	}
 	return nil
 }
 
+type clientNotification struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// handleNotification delivers raw to its subscription if it is a
+// notification. Notifications for unknown subscriptions are dropped.
+func (c *clientCodec) handleNotification(raw json.RawMessage) bool {
+	var n clientNotification
+	if json.Unmarshal(raw, &n) != nil || n.ID != nil || n.Method == "" {
+		return false
+	}
+	if strings.HasSuffix(n.Method, notificationSuffix) {
+		if sub := c.subscription(n.Params.Subscription); sub != nil {
+			sub.deliver(n.Params.Result)
+		}
+	}
+	return true
+}
+
+func (c *clientCodec) subscription(id string) *ClientSubscription {
+	c.submutex.Lock()
+	defer c.submutex.Unlock()
+	return c.subs[id]
+}
+
+func (c *clientCodec) forget(id string) {
+	c.submutex.Lock()
+	delete(c.subs, id)
+	c.submutex.Unlock()
+}
+
+// closeSubscriptions ends all subscriptions with err.
+func (c *clientCodec) closeSubscriptions(err error) {
+	c.submutex.Lock()
+	subs := make([]*ClientSubscription, 0, len(c.subs))
+	for _, sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	c.submutex.Unlock()
+	for _, sub := range subs {
+		sub.close(err)
+	}
+}
+
 func (c *clientCodec) Close() error {
+	c.closeSubscriptions(rpc.ErrShutdown)
 	return c.c.Close()
 }
 
@@ -236,6 +369,128 @@ func (c Client) Notify(serviceMethod string, args interface{}) error {
 	return c.codec.WriteRequest(req, args)
 }
 
+// Subscribe calls "<namespace>_subscribe" with args and sends the results
+// of the notifications for the returned subscription on channel, which must
+// be a writable channel of a type the results can be decoded into.
+// Notifications are buffered, but a subscription whose channel falls too
+// far behind is dropped with ErrSubscriptionQueueOverflow.
+//
+// Subscriptions need a transport that can push notifications, such as
+// Dial, DialIPC or DialWebSocket.
+func (c Client) Subscribe(namespace string, channel interface{}, args interface{}) (*ClientSubscription, error) {
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		panic(fmt.Sprintf("jsonrpc2: Subscribe needs a writable channel, have %T", channel))
+	}
+	codec, ok := c.codec.(*clientCodec)
+	if !ok {
+		return nil, ErrNotificationsUnsupported
+	}
+	var id string
+	if err := c.Call(namespace+subscribeSuffix, args, &id); err != nil {
+		return nil, err
+	}
+	sub := codec.subscription(id)
+	if sub == nil {
+		// The connection was closed after the reply was read.
+		return nil, rpc.ErrShutdown
+	}
+	go sub.forward(ch)
+	return sub, nil
+}
+
+// positional returns args as params, omitting empty params.
+func positional(args []interface{}) interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
+
+// CallContext invokes method with the positional args and waits for it to
+// complete or ctx to be done, whichever happens first. The result is
+// unmarshaled into result unless it is nil. Errors are those of Call, or
+// ctx.Err() if ctx is done first; the call itself isn't withdrawn from the
+// server, but its late reply is discarded.
+func (c Client) CallContext(ctx context.Context, method string, result interface{}, args ...interface{}) error {
+	var raw json.RawMessage
+	call := c.Go(method, positional(args), &raw, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if call.Error != nil {
+		return call.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, result)
+}
+
+// BatchElem is a request in a batch call.
+type BatchElem struct {
+	Method string
+	Args   []interface{}
+	// The result is unmarshaled into Result unless it is nil.
+	Result interface{}
+	// Error is set if the server returned an error for this request or
+	// unmarshaling the result failed. I/O errors are returned by
+	// BatchCallContext instead.
+	Error error
+}
+
+// BatchCallContext sends all requests of b as a single JSON-RPC 2.0 batch
+// and waits for all replies or ctx to be done. Server errors are reported
+// per element as *Error in BatchElem.Error.
+//
+// If sending the batch fails the client is closed, failing all its pending
+// calls, as the connection is in an unknown state. If ctx is done first,
+// ctx.Err() is returned; like with CallContext the requests aren't
+// withdrawn and stay pending until their late replies are discarded, or
+// the client is closed.
+func (c Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
+	codec, batching := c.codec.(*clientCodec)
+	if batching {
+		codec.beginBatch()
+	}
+	var (
+		raws  = make([]json.RawMessage, len(b))
+		calls = make([]*rpc.Call, len(b))
+		done  = make(chan *rpc.Call, len(b))
+	)
+	for i := range b {
+		calls[i] = c.Go(b[i].Method, positional(b[i].Args), &raws[i], done)
+	}
+	if batching {
+		if err := codec.endBatch(); err != nil {
+			c.Close()
+			return err
+		}
+	}
+	for range b {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for i, call := range calls {
+		switch err := call.Error.(type) {
+		case nil:
+			if b[i].Result != nil {
+				b[i].Error = json.Unmarshal(raws[i], b[i].Result)
+			}
+		case rpc.ServerError:
+			b[i].Error = ServerError(err)
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
 // NewClient returns a new Client to handle requests to the
 // set of services at the other end of the connection.
 func NewClient(conn io.ReadWriteCloser) *Client {
@@ -245,6 +500,9 @@ func NewClient(conn io.ReadWriteCloser) *Client {
 // NewClientWithCodec returns a new Client using the given rpc.ClientCodec.
 func NewClientWithCodec(codec rpc.ClientCodec) *Client {
 	client := rpc.NewClientWithCodec(codec)
+	if c, ok := codec.(*clientCodec); ok {
+		c.client = client
+	}
 	return &Client{client, codec}
 }
 
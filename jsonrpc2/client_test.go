@@ -0,0 +1,236 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type slowService struct{}
+
+func (slowService) Sleep(ms int) int {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	return ms
+}
+
+func TestCallContext(t *testing.T) {
+	srv := newRegistryServer(t)
+	NewRegistry(srv).RegisterName("slow", slowService{})
+	cli, conn := net.Pipe()
+	go serveConn(context.Background(), conn, srv)
+	client := NewClient(cli)
+	defer client.Close()
+
+	var sum int
+	if err := client.CallContext(context.Background(), "calc_add", &sum, 1, 2); err != nil || sum != 3 {
+		t.Fatalf("call: have %d, %v", sum, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var slept int
+	if err := client.CallContext(ctx, "slow_sleep", &slept, 500); err != context.DeadlineExceeded {
+		t.Fatalf("error mismatch: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	if slept != 0 {
+		t.Fatalf("late reply written to result")
+	}
+	// The client stays usable.
+	if err := client.CallContext(context.Background(), "calc_add", &sum, 2, 2); err != nil || sum != 4 {
+		t.Fatalf("call after timeout: have %d, %v", sum, err)
+	}
+}
+
+func TestBatchCallContext(t *testing.T) {
+	var posts int32
+	handler := HTTPHandler(newRegistryServer(t))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		handler.ServeHTTP(w, req)
+	}))
+	defer ts.Close()
+	client := NewHTTPClient(ts.URL)
+	defer client.Close()
+
+	var sum1, sum2 int
+	var greeting string
+	batch := []BatchElem{
+		{Method: "calc_add", Args: []interface{}{1, 2}, Result: &sum1},
+		{Method: "calc_greet", Args: []interface{}{"moac"}, Result: &greeting},
+		{Method: "calc_fail"},
+		{Method: "calc_add", Args: []interface{}{3, 4}, Result: &sum2},
+	}
+	if err := client.BatchCallContext(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if sum1 != 3 || sum2 != 7 || greeting != "hi moac;" {
+		t.Fatalf("results mismatch: %d %d %q", sum1, sum2, greeting)
+	}
+	if e, ok := batch[2].Error.(*Error); !ok || e.Code != errServer.Code {
+		t.Fatalf("error mismatch: have %v", batch[2].Error)
+	}
+	if n := atomic.LoadInt32(&posts); n != 1 {
+		t.Fatalf("batch sent in %d requests", n)
+	}
+}
+
+// failingConn fails all writes, reads block until it is closed.
+type failingConn struct {
+	net.Conn
+}
+
+func (failingConn) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestBatchCallContextSendFailure(t *testing.T) {
+	cli, _ := net.Pipe()
+	client := NewClient(failingConn{cli})
+	defer client.Close()
+
+	batch := []BatchElem{{Method: "calc_add", Args: []interface{}{1, 2}}, {Method: "calc_add", Args: []interface{}{3, 4}}}
+	errc := make(chan error, 1)
+	go func() { errc <- client.BatchCallContext(context.Background(), batch) }()
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatal("failed batch succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed batch left pending")
+	}
+	// The client is closed rather than left with the calls pending.
+	if err := client.Call("calc_add", []int{1, 2}, nil); err != rpc.ErrShutdown {
+		t.Fatalf("error mismatch: have %v, want %v", err, rpc.ErrShutdown)
+	}
+}
+
+func TestBatchCallContextMarshalFailure(t *testing.T) {
+	srv := newRegistryServer(t)
+	cli, conn := net.Pipe()
+	go serveConn(context.Background(), conn, srv)
+	client := NewClient(cli)
+	defer client.Close()
+
+	batch := []BatchElem{{Method: "calc_add", Args: []interface{}{make(chan int)}}, {Method: "calc_add", Args: []interface{}{3, 4}}}
+	if err := client.BatchCallContext(context.Background(), batch); err == nil {
+		t.Fatal("unencodable batch succeeded")
+	}
+	// Only the unencodable call failed, the client stays usable.
+	var sum int
+	if err := client.CallContext(context.Background(), "calc_add", &sum, 2, 2); err != nil || sum != 4 {
+		t.Fatalf("call after failure: have %d, %v", sum, err)
+	}
+}
+
+func TestHTTPRetry(t *testing.T) {
+	var attempts int32
+	handler := HTTPHandler(newRegistryServer(t))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if atomic.AddInt32(&attempts, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	defer ts.Close()
+
+	auth := func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer token")
+		return nil
+	}
+	client := NewHTTPClientWithOptions(ts.URL, HTTPOptions{
+		Doer: AuthDoer(nil, auth),
+		Retry: RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			Idempotent:     func(method string) bool { return method == "calc_add" },
+		},
+	})
+	defer client.Close()
+
+	var sum int
+	if err := client.CallContext(context.Background(), "calc_add", &sum, 1, 1); err != nil || sum != 2 {
+		t.Fatalf("call: have %d, %v", sum, err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("attempts mismatch: have %d, want 3", n)
+	}
+	atomic.StoreInt32(&attempts, 0)
+	if err := client.CallContext(context.Background(), "calc_greet", nil, "moac"); err == nil {
+		t.Fatal("non-idempotent call retried")
+	}
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Fatalf("attempts mismatch: have %d, want 1", n)
+	}
+
+	// Failing auth doesn't send the request.
+	failing := NewHTTPClientWithOptions(ts.URL, HTTPOptions{
+		Doer: AuthDoer(nil, func(*http.Request) error { return errors.New("no token") }),
+	})
+	defer failing.Close()
+	if err := failing.CallContext(context.Background(), "calc_add", &sum, 1, 1); err == nil {
+		t.Fatal("call without credentials succeeded")
+	}
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	srv := newRegistryServer(t)
+	NewRegistry(srv).RegisterName("slow", slowService{})
+	ts := httptest.NewServer(HTTPHandler(srv))
+	defer ts.Close()
+	client := NewHTTPClientWithOptions(ts.URL, HTTPOptions{Timeout: 20 * time.Millisecond})
+	defer client.Close()
+
+	var slept int
+	err := client.CallContext(context.Background(), "slow_sleep", &slept, 500)
+	if e := ServerError(err); e == nil || e.Code != errInternal.Code {
+		t.Fatalf("error mismatch: have %v", err)
+	}
+	if err := client.CallContext(context.Background(), "slow_sleep", &slept, 1); err != nil || slept != 1 {
+		t.Fatalf("call after timeout: have %d, %v", slept, err)
+	}
+}
+
+func TestHTTPTimeoutReadingBody(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req clientRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", contentType)
+		if req.Method == "slow_body" {
+			// Send part of the reply, then stall past the timeout.
+			w.Write([]byte(`{"jsonrpc":"2.0",`))
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":1}`, *req.ID)
+	}))
+	defer ts.Close()
+	client := NewHTTPClientWithOptions(ts.URL, HTTPOptions{Timeout: 50 * time.Millisecond})
+	defer client.Close()
+
+	// A stalled reply fails its own call, the client keeps working.
+	var res int
+	err := client.CallContext(context.Background(), "slow_body", &res)
+	if e := ServerError(err); e == nil || e.Code != errInternal.Code {
+		t.Fatalf("error mismatch: have %v", err)
+	}
+	if err := client.CallContext(context.Background(), "fast_body", &res); err != nil || res != 1 {
+		t.Fatalf("call after timeout: have %d, %v", res, err)
+	}
+}
@@ -2,14 +2,19 @@ package jsonrpc2
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime"
+	"net"
 	"net/http"
 	"net/rpc"
+	"strings"
+	"sync"
+	"time"
 )
 
 const contentType = "application/json"
@@ -25,9 +30,34 @@ func HTTPRequestFromContext(ctx context.Context) *http.Request {
 	return req
 }
 
+// DefaultMaxBodySize is the request body limit used when
+// HTTPServerOptions.MaxBodySize is zero.
+const DefaultMaxBodySize = 5 * 1024 * 1024
+
+// HTTPServerOptions configures a handler created by
+// HTTPHandlerWithOptions.
+type HTTPServerOptions struct {
+	// CORSOrigins lists the origins browsers may call from, "*" allows
+	// any. Browsers can't read replies to other origins.
+	CORSOrigins []string
+	// VirtualHosts lists the accepted Host header names, "*" allows any.
+	// Any host is accepted if it is empty. Requests addressed by IP are
+	// always accepted; the list guards against DNS rebinding.
+	VirtualHosts []string
+	// MaxBodySize limits request bodies, DefaultMaxBodySize if zero.
+	MaxBodySize int64
+	// Gzip compresses replies for clients which accept it.
+	Gzip bool
+	// Health enables GET requests reporting the status, which succeed if it
+	// returns nil. GET requests are not allowed if it is nil.
+	Health func() error
+}
+
 type httpServerConn struct {
 	req     io.Reader
-	res     io.Writer
+	res     http.ResponseWriter
+	gzip    bool // compress the reply
+	gz      *gzip.Writer
 	replied bool
 }
 
@@ -36,7 +66,15 @@ func (conn *httpServerConn) Read(buf []byte) (int, error) {
 }
 
 func (conn *httpServerConn) Write(buf []byte) (int, error) {
+	if !conn.replied && conn.gzip {
+		// Set up lazily, an empty reply must stay empty.
+		conn.res.Header().Set("Content-Encoding", "gzip")
+		conn.gz = gzip.NewWriter(conn.res)
+	}
 	conn.replied = true
+	if conn.gz != nil {
+		return conn.gz.Write(buf)
+	}
 	return conn.res.Write(buf)
 }
 
@@ -45,7 +83,8 @@ func (conn *httpServerConn) Close() error {
 }
 
 type httpHandler struct {
-	rpc *rpc.Server
+	rpc  *rpc.Server
+	opts HTTPServerOptions
 }
 
 // HTTPHandler returns handler for HTTP requests which will execute
@@ -55,33 +94,158 @@ type httpHandler struct {
 //
 // Specification: http://www.simple-is-better.org/json-rpc/transport_http.html
 func HTTPHandler(srv *rpc.Server) http.Handler {
+	return HTTPHandlerWithOptions(srv, HTTPServerOptions{})
+}
+
+// HTTPHandlerWithOptions is HTTPHandler with CORS, virtual host, body size,
+// compression and health check settings.
+func HTTPHandlerWithOptions(srv *rpc.Server, opts HTTPServerOptions) http.Handler {
 	if srv == nil {
 		srv = rpc.DefaultServer
 	}
-	return &httpHandler{srv}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	return &httpHandler{srv, opts}
 }
 
 func (h *httpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", contentType)
 
-	if req.Method != "POST" {
+	if !h.validHost(req.Host) {
+		http.Error(w, "invalid host specified", http.StatusForbidden)
+		return
+	}
+	// Browsers enforce CORS, so replies to other origins just lack the
+	// headers allowing them to be read.
+	if origin := req.Header.Get("Origin"); origin != "" {
+		w.Header().Add("Vary", "Origin")
+		if allowedOrigin(h.opts.CORSOrigins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		} else if req.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	switch {
+	case req.Method == "POST":
+	case req.Method == "OPTIONS":
+		if h.opts.Health != nil {
+			w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+		} else {
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		}
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization")
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case (req.Method == "GET" || req.Method == "HEAD") && h.opts.Health != nil:
+		h.serveHealth(w)
+		return
+	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
-	if mediaType != contentType || req.Header.Get("Accept") != contentType {
+	if mediaType != contentType || !acceptsJSON(req.Header.Get("Accept")) {
 		w.WriteHeader(http.StatusUnsupportedMediaType)
 		return
 	}
+	if req.ContentLength > h.opts.MaxBodySize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
 
 	ctx := context.WithValue(context.Background(), httpRequestContextKey, req)
-	conn := &httpServerConn{req: req.Body, res: w}
+	conn := &httpServerConn{
+		req:  http.MaxBytesReader(w, req.Body, h.opts.MaxBodySize),
+		res:  w,
+		gzip: h.opts.Gzip && acceptsGzip(req.Header.Get("Accept-Encoding")),
+	}
+	if h.opts.Gzip {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
 	h.rpc.ServeRequest(NewServerCodecContext(ctx, conn, h.rpc))
+	if conn.gz != nil {
+		conn.gz.Close()
+	}
 	if !conn.replied {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
+func (h *httpHandler) serveHealth(w http.ResponseWriter) {
+	status := struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}{Status: "ok"}
+	code := http.StatusOK
+	if err := h.opts.Health(); err != nil {
+		status.Status, status.Error = "unavailable", err.Error()
+		code = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&status)
+}
+
+// validHost reports whether host (which may include a port) is in the
+// virtual host list.
+func (h *httpHandler) validHost(host string) bool {
+	if len(h.opts.VirtualHosts) == 0 {
+		return true
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return true
+	}
+	for _, vhost := range h.opts.VirtualHosts {
+		if vhost == "*" || strings.EqualFold(vhost, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedOrigin(allowed []string, origin string) bool {
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsJSON reports whether the Accept header allows a JSON reply. A
+// missing header accepts anything.
+func acceptsJSON(accept string) bool {
+	if accept == "" {
+		return true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case contentType, "application/*", "*/*":
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(encoding string) bool {
+	for _, part := range strings.Split(encoding, ",") {
+		if name := strings.TrimSpace(strings.SplitN(part, ";", 2)[0]); strings.EqualFold(name, "gzip") {
+			return true
+		}
+	}
+	return false
+}
+
 // Doer is an interface for doing HTTP requests.
 type Doer interface {
 	Do(req *http.Request) (resp *http.Response, err error)
@@ -97,12 +261,92 @@ func (f DoerFunc) Do(req *http.Request) (resp *http.Response, err error) {
 	return f(req)
 }
 
+// AuthDoer returns a Doer which lets auth add credentials, e.g. an
+// Authorization header, to every request before passing it to doer. auth
+// is called for each attempt, so it may refresh expiring tokens; if it
+// fails the request isn't sent.
+//
+// If doer is nil then &http.Client{} will be used.
+func AuthDoer(doer Doer, auth func(req *http.Request) error) Doer {
+	if doer == nil {
+		doer = &http.Client{}
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if err := auth(req); err != nil {
+			return nil, err
+		}
+		return doer.Do(req)
+	})
+}
+
+// RetryPolicy controls how HTTP requests are retried after network errors,
+// timeouts, 5xx replies or 429 Too Many Requests. Only requests whose
+// methods are all idempotent are retried.
+type RetryPolicy struct {
+	MaxAttempts    int                      // attempts per request including the first, no retries if <= 1
+	InitialBackoff time.Duration            // delay before the first retry, 100ms if zero
+	MaxBackoff     time.Duration            // upper bound of the doubling delay, 10s if zero
+	Idempotent     func(method string) bool // reports whether method is safe to retry
+}
+
+// backoff returns the delay before the given retry (starting at 1).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay, max := p.InitialBackoff, p.MaxBackoff
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	for i := 1; i < retry && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// allows reports whether the request or batch in body may be retried.
+func (p RetryPolicy) allows(body []byte) bool {
+	if p.MaxAttempts <= 1 || p.Idempotent == nil {
+		return false
+	}
+	var reqs []clientRequest
+	if len(body) > 0 && body[0] == '[' {
+		if json.Unmarshal(body, &reqs) != nil {
+			return false
+		}
+	} else {
+		reqs = make([]clientRequest, 1)
+		if json.Unmarshal(body, &reqs[0]) != nil {
+			return false
+		}
+	}
+	for _, req := range reqs {
+		if !p.Idempotent(req.Method) {
+			return false
+		}
+	}
+	return true
+}
+
+// HTTPOptions configures a Client created by NewHTTPClientWithOptions.
+type HTTPOptions struct {
+	Doer    Doer          // &http.Client{} if nil, see also AuthDoer
+	Timeout time.Duration // per attempt, including reading the reply; none if zero
+	Retry   RetryPolicy
+}
+
 type httpClientConn struct {
-	url   string
-	doer  Doer
-	ready chan io.ReadCloser
-	body  io.ReadCloser
-	close chan struct{}
+	url    string
+	opts   HTTPOptions
+	ready  chan io.ReadCloser
+	body   io.ReadCloser
+	close  chan struct{}
+	once   sync.Once
+	ctx    context.Context // cancelled by Close
+	cancel context.CancelFunc
 }
 
 func (conn *httpClientConn) Read(buf []byte) (int, error) {
@@ -128,57 +372,129 @@ func (conn *httpClientConn) Read(buf []byte) (int, error) {
 func (conn *httpClientConn) Write(buf []byte) (int, error) {
 	b := make([]byte, len(buf))
 	copy(b, buf)
-	go func() {
-		req, err := http.NewRequest("POST", conn.url, bytes.NewReader(b))
+	go conn.do(b)
+	return len(buf), nil
+}
+
+// do posts b, retrying if allowed, and queues the reply for Read.
+func (conn *httpClientConn) do(b []byte) {
+	retry := conn.opts.Retry.allows(b)
+	for attempt := 1; ; attempt++ {
+		body, temporary, err := conn.post(b)
 		if err == nil {
-			req.Header.Add("Content-Type", contentType)
-			req.Header.Add("Accept", contentType)
-			var resp *http.Response
-			resp, err = conn.doer.Do(req)
-			const maxBodySlurpSize = 32 * 1024
-
-			if err == nil {
-				mediaType, _, err2 := mime.ParseMediaType(resp.Header.Get("Content-Type"))
-				if mediaType != contentType || err2 != nil {
-					err = fmt.Errorf("bad HTTP Content-Type: %s", resp.Header.Get("Content-Type"))
-				} else if resp.StatusCode == http.StatusOK {
-					conn.ready <- resp.Body
-					return
-				} else if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusAccepted {
-					// Read the body if small so underlying TCP connection will be re-used.
-					// No need to check for errors: if it fails, Transport won't reuse it anyway.
-					if resp.ContentLength == -1 || resp.ContentLength <= maxBodySlurpSize {
-						io.CopyN(ioutil.Discard, resp.Body, maxBodySlurpSize)
-					}
-					resp.Body.Close()
-					return
-				} else {
-					err = fmt.Errorf("bad HTTP Status: %s", resp.Status)
-				}
-			}
-			if resp != nil {
-				// Read the body if small so underlying TCP connection will be re-used.
-				// No need to check for errors: if it fails, Transport won't reuse it anyway.
-				if resp.ContentLength == -1 || resp.ContentLength <= maxBodySlurpSize {
-					io.CopyN(ioutil.Discard, resp.Body, maxBodySlurpSize)
-				}
-				resp.Body.Close()
+			if body != nil {
+				conn.deliver(body)
 			}
+			return
 		}
-		var res clientResponse
-		if json.Unmarshal(b, &res) == nil && res.ID == nil {
-			return // ignore error from Notification
+		if !retry || !temporary || attempt >= conn.opts.Retry.MaxAttempts {
+			conn.fail(b, err)
+			return
 		}
-		res.Error = NewError(errInternal.Code, err.Error())
-		buf := &bytes.Buffer{}
-		json.NewEncoder(buf).Encode(res)
-		conn.ready <- ioutil.NopCloser(buf)
-	}()
-	return len(buf), nil
+		select {
+		case <-time.After(conn.opts.Retry.backoff(attempt)):
+		case <-conn.close:
+			conn.fail(b, err)
+			return
+		}
+	}
+}
+
+// post makes one attempt. It returns the reply body, or nil if there is
+// none, and whether a failure may go away on retry. The body is read
+// within the attempt, so a timeout fails this request only rather than
+// the stream shared by all requests of the client.
+func (conn *httpClientConn) post(b []byte) (io.ReadCloser, bool, error) {
+	ctx, cancel := conn.ctx, context.CancelFunc(func() {})
+	if conn.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, conn.opts.Timeout)
+	}
+	req, err := http.NewRequest("POST", conn.url, bytes.NewReader(b))
+	if err != nil {
+		cancel()
+		return nil, false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Content-Type", contentType)
+	req.Header.Add("Accept", contentType)
+	resp, err := conn.opts.Doer.Do(req)
+	if err != nil {
+		cancel()
+		return nil, conn.ctx.Err() == nil, err
+	}
+	const maxBodySlurpSize = 32 * 1024
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case resp.StatusCode == http.StatusOK && mediaType == contentType && err == nil:
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		if err != nil {
+			return nil, conn.ctx.Err() == nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(data)), false, nil
+	case resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusAccepted:
+		err = nil
+	case resp.StatusCode == http.StatusOK:
+		err = fmt.Errorf("bad HTTP Content-Type: %s", resp.Header.Get("Content-Type"))
+	default:
+		err = fmt.Errorf("bad HTTP Status: %s", resp.Status)
+	}
+	// Read the body if small so underlying TCP connection will be re-used.
+	// No need to check for errors: if it fails, Transport won't reuse it anyway.
+	if resp.ContentLength == -1 || resp.ContentLength <= maxBodySlurpSize {
+		io.CopyN(ioutil.Discard, resp.Body, maxBodySlurpSize)
+	}
+	resp.Body.Close()
+	cancel()
+	temporary := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return nil, temporary, err
+}
+
+func (conn *httpClientConn) deliver(body io.ReadCloser) {
+	select {
+	case conn.ready <- body:
+	case <-conn.close:
+		body.Close()
+	}
+}
+
+// fail replies err to every request in b. Notifications get no reply.
+func (conn *httpClientConn) fail(b []byte, err error) {
+	var (
+		reqs  []clientRequest
+		batch = len(b) > 0 && b[0] == '['
+	)
+	if batch {
+		json.Unmarshal(b, &reqs)
+	} else {
+		reqs = make([]clientRequest, 1)
+		json.Unmarshal(b, &reqs[0])
+	}
+	var resps []clientResponse
+	for _, req := range reqs {
+		if req.ID != nil {
+			resps = append(resps, clientResponse{Version: "2.0", ID: req.ID, Error: NewError(errInternal.Code, err.Error())})
+		}
+	}
+	if len(resps) == 0 {
+		return
+	}
+	buf := &bytes.Buffer{}
+	if batch {
+		json.NewEncoder(buf).Encode(resps)
+	} else {
+		json.NewEncoder(buf).Encode(resps[0])
+	}
+	conn.deliver(ioutil.NopCloser(buf))
 }
 
 func (conn *httpClientConn) Close() error {
-	close(conn.close)
+	conn.once.Do(func() {
+		conn.cancel()
+		close(conn.close)
+	})
 	return nil
 }
 
@@ -196,13 +512,23 @@ func NewHTTPClient(url string) *Client {
 // request (it method Do() will receive already configured POST request
 // with url, all required headers and body set according to specification).
 func NewCustomHTTPClient(url string, doer Doer) *Client {
-	if doer == nil {
-		doer = &http.Client{}
+	return NewHTTPClientWithOptions(url, HTTPOptions{Doer: doer})
+}
+
+// NewHTTPClientWithOptions returns a new Client to handle requests to the
+// set of services at the given url, with the timeout and retry policy
+// given in opts. Closing the Client aborts requests in flight.
+func NewHTTPClientWithOptions(url string, opts HTTPOptions) *Client {
+	if opts.Doer == nil {
+		opts.Doer = &http.Client{}
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return NewClient(&httpClientConn{
-		url:   url,
-		doer:  doer,
-		ready: make(chan io.ReadCloser, 16),
-		close: make(chan struct{}),
+		url:    url,
+		opts:   opts,
+		ready:  make(chan io.ReadCloser, 16),
+		close:  make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
 	})
 }
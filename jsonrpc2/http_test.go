@@ -0,0 +1,161 @@
+package jsonrpc2
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const addRequest = `{"jsonrpc":"2.0","id":1,"method":"calc_add","params":[1,2]}`
+
+func postRPC(t *testing.T, h http.Handler, mutate func(req *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "http://localhost/", strings.NewReader(addRequest))
+	req.Header.Set("Content-Type", contentType)
+	if mutate != nil {
+		mutate(req)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPHandlerAccept(t *testing.T) {
+	h := HTTPHandler(newRegistryServer(t))
+	for accept, code := range map[string]int{
+		"":                           http.StatusOK,
+		contentType:                  http.StatusOK,
+		"text/plain, application/*":  http.StatusOK,
+		"application/json; q=0.9":    http.StatusOK,
+		"*/*":                        http.StatusOK,
+		"text/html, application/xml": http.StatusUnsupportedMediaType,
+	} {
+		rec := postRPC(t, h, func(req *http.Request) { req.Header.Set("Accept", accept) })
+		if rec.Code != code {
+			t.Errorf("accept %q: status mismatch: have %d, want %d", accept, rec.Code, code)
+		}
+	}
+}
+
+func TestHTTPHandlerHosts(t *testing.T) {
+	h := HTTPHandlerWithOptions(newRegistryServer(t), HTTPServerOptions{VirtualHosts: []string{"rpc.moac.io"}})
+	for host, code := range map[string]int{
+		"rpc.moac.io":      http.StatusOK,
+		"RPC.moac.io:8545": http.StatusOK,
+		"127.0.0.1:8545":   http.StatusOK,
+		"[::1]:8545":       http.StatusOK,
+		"evil.com":         http.StatusForbidden,
+	} {
+		rec := postRPC(t, h, func(req *http.Request) { req.Host = host })
+		if rec.Code != code {
+			t.Errorf("host %q: status mismatch: have %d, want %d", host, rec.Code, code)
+		}
+	}
+}
+
+func TestHTTPHandlerCORS(t *testing.T) {
+	h := HTTPHandlerWithOptions(newRegistryServer(t), HTTPServerOptions{CORSOrigins: []string{"https://dapp.moac.io"}})
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", "http://localhost/", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := preflight("https://dapp.moac.io"); rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://dapp.moac.io" {
+		t.Errorf("allowed preflight mismatch: %d %v", rec.Code, rec.Header())
+	}
+	if rec := preflight("https://evil.com"); rec.Code != http.StatusForbidden {
+		t.Errorf("disallowed preflight status mismatch: have %d", rec.Code)
+	}
+	rec := postRPC(t, h, func(req *http.Request) { req.Header.Set("Origin", "https://evil.com") })
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed origin got CORS header")
+	}
+	rec = postRPC(t, h, func(req *http.Request) { req.Header.Set("Origin", "https://dapp.moac.io") })
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://dapp.moac.io" {
+		t.Errorf("allowed origin mismatch: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestHTTPHandlerBodyLimit(t *testing.T) {
+	h := HTTPHandlerWithOptions(newRegistryServer(t), HTTPServerOptions{MaxBodySize: 16})
+	if rec := postRPC(t, h, nil); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status mismatch: have %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	// Without Content-Length the body is cut off while reading.
+	rec := postRPC(t, h, func(req *http.Request) { req.ContentLength = -1 })
+	if !strings.Contains(rec.Body.String(), `"code":-32700`) {
+		t.Errorf("truncated body not rejected: %s", rec.Body)
+	}
+}
+
+func TestHTTPHandlerGzip(t *testing.T) {
+	h := HTTPHandlerWithOptions(newRegistryServer(t), HTTPServerOptions{Gzip: true})
+	rec := postRPC(t, h, func(req *http.Request) { req.Header.Set("Accept-Encoding", "deflate, gzip;q=1.0") })
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("reply not compressed: %v", rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(zr)
+	if !strings.Contains(string(body), `"result":3`) {
+		t.Fatalf("reply mismatch: %s", body)
+	}
+	// Notifications get an empty reply.
+	rec = postRPC(t, h, func(req *http.Request) {
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Body = ioutil.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","method":"calc_add","params":[1,2]}`))
+		req.ContentLength = -1
+	})
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 || rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("notification reply mismatch: %d %v %q", rec.Code, rec.Header(), rec.Body)
+	}
+
+	// The client side decompresses transparently.
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	client := NewHTTPClient(ts.URL)
+	defer client.Close()
+	var sum int
+	if err := client.Call("calc_add", []int{2, 2}, &sum); err != nil || sum != 4 {
+		t.Fatalf("call: have %d, %v", sum, err)
+	}
+}
+
+func TestHTTPHandlerHealth(t *testing.T) {
+	var health error
+	h := HTTPHandlerWithOptions(newRegistryServer(t), HTTPServerOptions{Health: func() error { return health }})
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/", nil))
+		return rec
+	}
+	if rec := get(); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ok"`) {
+		t.Errorf("healthy mismatch: %d %s", rec.Code, rec.Body)
+	}
+	health = errors.New("syncing")
+	if rec := get(); rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "syncing") {
+		t.Errorf("unhealthy mismatch: %d %s", rec.Code, rec.Body)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "http://localhost/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status mismatch: have %d", rec.Code)
+	}
+	// Without a health check GET is not allowed.
+	for _, h := range []http.Handler{HTTPHandler(newRegistryServer(t)), HTTPHandlerWithOptions(newRegistryServer(t), HTTPServerOptions{})} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status mismatch without health check: have %d", rec.Code)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package jsonrpc2
+
+import (
+	"context"
+	"net"
+	"net/rpc"
+	"os"
+)
+
+// ListenIPC listens on the Unix domain socket at path. A stale socket file
+// left at path is removed, and the new one is only accessible by the
+// current user.
+func ListenIPC(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// ServeListener accepts connections on l and runs the JSON-RPC 2.0 server
+// srv on each of them, with notification support. It blocks until Accept
+// fails, e.g. because l was closed, and returns that error.
+//
+// If srv is nil then rpc.DefaultServer will be used.
+func ServeListener(l net.Listener, srv *rpc.Server) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConn(context.Background(), conn, srv)
+	}
+}
+
+// DialIPC connects to a JSON-RPC 2.0 server on the Unix domain socket at
+// path.
+func DialIPC(path string) (*Client, error) {
+	return Dial("unix", path)
+}
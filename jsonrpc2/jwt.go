@@ -0,0 +1,130 @@
+package jsonrpc2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jwtLeeway is the clock skew tolerated when checking token times.
+const jwtLeeway = time.Minute
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type jwtClaims struct {
+	Subject   string `json:"sub,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+}
+
+// SignJWT returns a bearer token for subject signed with the shared secret
+// using HS256. The token expires after ttl, which must be positive.
+func SignJWT(secret []byte, subject string, ttl time.Duration) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("empty token secret")
+	}
+	if ttl <= 0 {
+		return "", errors.New("token ttl must be positive")
+	}
+	now := time.Now()
+	claims := jwtClaims{Subject: subject, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()}
+	payload, err := json.Marshal(&claims)
+	if err != nil {
+		return "", err
+	}
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, signed)), nil
+}
+
+// BearerAuth returns an auth function for AuthDoer which sends token as
+// bearer token.
+func BearerAuth(token string) func(req *http.Request) error {
+	return func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+func jwtSignature(secret []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+// verifyJWT checks the signature and times of token and returns its claims.
+// Tokens must expire.
+func verifyJWT(secret []byte, token string, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil || header.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, jwtSignature(secret, parts[0]+"."+parts[1])) {
+		return nil, errors.New("invalid token signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	if claims.ExpiresAt == 0 {
+		return nil, errors.New("token without expiry")
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0).Add(-jwtLeeway)) {
+		return nil, errors.New("token not valid yet")
+	}
+	return &claims, nil
+}
+
+// JWTAuth returns middleware accepting only requests carrying a bearer
+// token signed with the shared secret using HS256 in the Authorization
+// header of their HTTP request, or of the upgrade request for WebSocket.
+// Requests on other transports are refused. The caller is set to the "sub"
+// claim of the token, or "jwt" if it has none. It panics if secret is
+// empty, since anyone could sign tokens with it.
+func JWTAuth(secret []byte) Middleware {
+	if len(secret) == 0 {
+		panic("jsonrpc2: JWTAuth needs a non-empty secret")
+	}
+	return func(req *Request) error {
+		httpReq := HTTPRequestFromContext(req.Context)
+		if httpReq == nil {
+			return NewError(ErrUnauthorized.Code, "unauthorized: no bearer token")
+		}
+		auth := httpReq.Header.Get("Authorization")
+		if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+			return NewError(ErrUnauthorized.Code, "unauthorized: no bearer token")
+		}
+		claims, err := verifyJWT(secret, strings.TrimSpace(auth[7:]), time.Now())
+		if err != nil {
+			return NewError(ErrUnauthorized.Code, "unauthorized: "+err.Error())
+		}
+		req.Caller = claims.Subject
+		if req.Caller == "" {
+			req.Caller = "jwt"
+		}
+		return nil
+	}
+}
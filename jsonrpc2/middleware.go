@@ -0,0 +1,255 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/rpc"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MOACChain/MoacLib/log"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+var (
+	// ErrUnauthorized is returned for requests failing authentication.
+	ErrUnauthorized = NewError(-32006, "unauthorized")
+	// ErrMethodNotAllowed is returned for methods refused by MethodFilter.
+	ErrMethodNotAllowed = NewError(-32004, "method not allowed")
+	// ErrRateLimited is returned for requests refused by RateLimiter.
+	ErrRateLimited = NewError(-32005, "rate limit exceeded")
+)
+
+var (
+	remoteAddrContextKey contextKey = 2
+	callerContextKey     contextKey = 3
+)
+
+// Request is an incoming call as seen by middleware.
+type Request struct {
+	Method       string          // as sent by the client
+	Params       json.RawMessage // nil if omitted
+	Notification bool
+	Context      context.Context // of the connection
+	RemoteAddr   string          // see RemoteAddrFromContext
+	Caller       string          // identity set by authentication middleware
+
+	started  time.Time
+	complete []func(req *Request, err *Error)
+}
+
+// OnComplete registers fn to be called once the reply to the request was
+// written, or would have been for notifications. err is nil on success.
+func (r *Request) OnComplete(fn func(req *Request, err *Error)) {
+	r.complete = append(r.complete, fn)
+}
+
+// Duration returns the time since the request was read.
+func (r *Request) Duration() time.Duration {
+	return time.Since(r.started)
+}
+
+func (r *Request) finish(err *Error) {
+	for _, fn := range r.complete {
+		fn(r, err)
+	}
+}
+
+// Middleware inspects a request before it is dispatched. A non-nil error
+// rejects the request and is sent to the client instead of a result.
+type Middleware func(req *Request) error
+
+// chains maps rpc servers to their middleware.
+var (
+	chainsMu sync.Mutex
+	chains   sync.Map
+)
+
+// Use appends mw to the middleware run, in order, for every request served
+// by srv, including each request of a batch. The first rejection stops the
+// chain.
+//
+// If srv is nil then rpc.DefaultServer will be used.
+func Use(srv *rpc.Server, mw ...Middleware) {
+	if srv == nil {
+		srv = rpc.DefaultServer
+	}
+	srv.Register(JSONRPC2{})
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	var chain []Middleware
+	if old, ok := chains.Load(srv); ok {
+		chain = append(chain, old.([]Middleware)...)
+	}
+	chains.Store(srv, append(chain, mw...))
+}
+
+func chainOf(srv *rpc.Server) []Middleware {
+	if chain, ok := chains.Load(srv); ok {
+		return chain.([]Middleware)
+	}
+	return nil
+}
+
+// RemoteAddrFromContext returns the address of the client: the remote
+// address of the HTTP request if there is one, otherwise the one of the
+// connection, or "" if unknown.
+func RemoteAddrFromContext(ctx context.Context) string {
+	if req := HTTPRequestFromContext(ctx); req != nil {
+		return req.RemoteAddr
+	}
+	addr, _ := ctx.Value(remoteAddrContextKey).(string)
+	return addr
+}
+
+// CallerFromContext returns the caller resolved by middleware for the
+// request, or "" if none was.
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerContextKey).(string)
+	return caller
+}
+
+// RejectArg is a param for internal RPC JSONRPC2.Reject.
+type RejectArg struct {
+	err error
+}
+
+// Reject is an internal RPC method used to reply to requests rejected by
+// middleware or calling the internal service.
+func (JSONRPC2) Reject(arg RejectArg, reply *bool) error {
+	if arg.err == nil {
+		return errMethod
+	}
+	return toError(arg.err)
+}
+
+// MethodFilter rejects methods not matching allow, if it isn't empty, or
+// matching deny. Patterns are method names, or namespaces followed by "_*"
+// (or ".*" for net/rpc names) to match all methods of a namespace.
+func MethodFilter(allow, deny []string) Middleware {
+	return func(req *Request) error {
+		if matchMethod(deny, req.Method) || (len(allow) > 0 && !matchMethod(allow, req.Method)) {
+			return ErrMethodNotAllowed
+		}
+		return nil
+	}
+}
+
+func matchMethod(patterns []string, method string) bool {
+	for _, p := range patterns {
+		if p == method || p == "*" {
+			return true
+		}
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(method, p[:len(p)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimit is a token bucket: Rate tokens per second are added up to
+// Burst, and each request takes one.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter holds the buckets of one RateLimit.
+type limiter struct {
+	limit   RateLimit
+	buckets *simplelru.LRU
+}
+
+// maxBuckets is the number of buckets kept. The least recently used bucket
+// is dropped to make room for a new one.
+const maxBuckets = 10000
+
+func newLimiter(limit RateLimit) *limiter {
+	buckets, _ := simplelru.NewLRU(maxBuckets, nil)
+	return &limiter{limit: limit, buckets: buckets}
+}
+
+func (l *limiter) allow(key string, now time.Time) bool {
+	var b *bucket
+	if v, ok := l.buckets.Get(key); ok {
+		b = v.(*bucket)
+	} else {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets.Add(key, b)
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.limit.Rate
+	if max := float64(l.limit.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimiter limits requests per client IP with perIP, and additionally
+// the requests of each client IP to a method with perMethod[method]. A
+// zero perIP doesn't limit.
+func RateLimiter(perIP RateLimit, perMethod map[string]RateLimit) Middleware {
+	var (
+		mu      sync.Mutex
+		ip      = newLimiter(perIP)
+		methods = make(map[string]*limiter)
+	)
+	for method, limit := range perMethod {
+		methods[method] = newLimiter(limit)
+	}
+	return func(req *Request) error {
+		host := req.RemoteAddr
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		now := time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		if perIP.Rate > 0 || perIP.Burst > 0 {
+			if !ip.allow(host, now) {
+				return ErrRateLimited
+			}
+		}
+		if l, ok := methods[req.Method]; ok && !l.allow(host, now) {
+			return ErrRateLimited
+		}
+		return nil
+	}
+}
+
+// AuditLog logs a record of every request once it completes, including
+// rejected ones, so it should come first in the chain. If logger is nil
+// the root logger is used.
+func AuditLog(logger log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Root()
+	}
+	return func(req *Request) error {
+		req.OnComplete(func(req *Request, err *Error) {
+			ctx := []interface{}{
+				"method", req.Method,
+				"caller", req.Caller,
+				"remote", req.RemoteAddr,
+				"notification", req.Notification,
+				"elapsed", req.Duration(),
+			}
+			if err != nil {
+				logger.Warn("RPC request failed", append(ctx, "code", err.Code, "err", err.Message)...)
+			} else {
+				logger.Info("RPC request served", ctx...)
+			}
+		})
+		return nil
+	}
+}
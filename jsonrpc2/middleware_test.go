@@ -0,0 +1,210 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MOACChain/MoacLib/log"
+)
+
+type whoService struct{}
+
+func (whoService) Ami(ctx context.Context) string {
+	return CallerFromContext(ctx)
+}
+
+func newMiddlewareServer(t *testing.T, mw ...Middleware) *rpc.Server {
+	srv := newRegistryServer(t)
+	if err := NewRegistry(srv).RegisterName("who", whoService{}); err != nil {
+		t.Fatal(err)
+	}
+	Use(srv, mw...)
+	return srv
+}
+
+func TestJWTAuth(t *testing.T) {
+	secret := []byte("secret")
+	ts := httptest.NewServer(HTTPHandler(newMiddlewareServer(t, JWTAuth(secret))))
+	defer ts.Close()
+
+	call := func(token string) (string, error) {
+		opts := HTTPOptions{}
+		if token != "" {
+			opts.Doer = AuthDoer(nil, BearerAuth(token))
+		}
+		client := NewHTTPClientWithOptions(ts.URL, opts)
+		defer client.Close()
+		var caller string
+		err := client.CallContext(context.Background(), "who_ami", &caller)
+		return caller, err
+	}
+	valid, _ := SignJWT(secret, "alice", time.Hour)
+	if caller, err := call(valid); err != nil || caller != "alice" {
+		t.Fatalf("valid token: have %q, %v", caller, err)
+	}
+	expired, _ := SignJWT(secret, "alice", time.Nanosecond)
+	claims, _ := verifyJWT(secret, expired, time.Now())
+	if _, err := verifyJWT(secret, expired, time.Unix(claims.ExpiresAt, 0).Add(2*jwtLeeway)); err == nil {
+		t.Fatal("expired token accepted")
+	}
+	if _, err := SignJWT(secret, "alice", 0); err == nil {
+		t.Fatal("token without expiry signed")
+	}
+	if _, err := SignJWT(nil, "alice", time.Hour); err == nil {
+		t.Fatal("token signed with empty secret")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("JWTAuth accepted empty secret")
+			}
+		}()
+		JWTAuth(nil)
+	}()
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`))
+	eternal := unsigned + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, unsigned))
+	forged, _ := SignJWT([]byte("other"), "alice", time.Hour)
+	for name, token := range map[string]string{"missing": "", "forged": forged, "malformed": "abc", "eternal": eternal} {
+		_, err := call(token)
+		if e := ServerError(err); e == nil || e.Code != ErrUnauthorized.Code {
+			t.Errorf("%s token: error mismatch: have %v", name, err)
+		}
+	}
+}
+
+func TestInternalMethods(t *testing.T) {
+	for name, h := range map[string]http.Handler{
+		"plain":      HTTPHandler(newRegistryServer(t)),
+		"middleware": HTTPHandler(newMiddlewareServer(t, MethodFilter(nil, nil))),
+	} {
+		for _, method := range []string{"JSONRPC2.Reject", "JSONRPC2.Call", "JSONRPC2.Batch"} {
+			body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[{}]}`
+			rec := postRPC(t, h, func(req *http.Request) {
+				req.Body = ioutil.NopCloser(strings.NewReader(body))
+				req.ContentLength = -1
+			})
+			if !strings.Contains(rec.Body.String(), `"code":-32601`) {
+				t.Errorf("%s %s: response mismatch: %s", name, method, rec.Body)
+			}
+		}
+	}
+}
+
+func TestMethodFilter(t *testing.T) {
+	srv := newMiddlewareServer(t, MethodFilter([]string{"calc_*", "who_ami"}, []string{"calc_crash"}))
+	ts := httptest.NewServer(HTTPHandler(srv))
+	defer ts.Close()
+	client := NewHTTPClient(ts.URL)
+	defer client.Close()
+
+	for method, allowed := range map[string]bool{
+		"calc_add":   true,
+		"who_ami":    true,
+		"calc_crash": false,
+		"slow_sleep": false,
+	} {
+		err := client.CallContext(context.Background(), method, nil, 1, 2)
+		if e := ServerError(err); allowed == (e != nil && e.Code == ErrMethodNotAllowed.Code) {
+			t.Errorf("%s: error mismatch: have %v", method, err)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limit := RateLimiter(RateLimit{Rate: 0.001, Burst: 5}, map[string]RateLimit{"calc_add": {Rate: 0.001, Burst: 2}})
+	h := HTTPHandler(newMiddlewareServer(t, limit))
+
+	limited := func(remote string) bool {
+		rec := postRPC(t, h, func(req *http.Request) { req.RemoteAddr = remote })
+		return strings.Contains(rec.Body.String(), `"code":-32005`)
+	}
+	for i, want := range []bool{false, false, true} {
+		if have := limited("10.0.0.1:1000"); have != want {
+			t.Fatalf("call %d: limited mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	// Other clients have their own buckets, ports don't matter.
+	if limited("10.0.0.2:1000") {
+		t.Fatal("other client limited")
+	}
+	if !limited("10.0.0.1:2000") {
+		t.Fatal("client not limited on new port")
+	}
+
+	// Batches count each request.
+	rec := postRPC(t, h, func(req *http.Request) {
+		req.RemoteAddr = "10.0.0.3:1000"
+		req.Body = ioutil.NopCloser(strings.NewReader(`[` + addRequest + `,` + addRequest + `,` + addRequest + `]`))
+		req.ContentLength = -1
+	})
+	if n := strings.Count(rec.Body.String(), `"code":-32005`); n != 1 {
+		t.Fatalf("batch limited mismatch: have %d, want 1: %s", n, rec.Body)
+	}
+}
+
+func TestLimiterBound(t *testing.T) {
+	l := newLimiter(RateLimit{Rate: 0.001, Burst: 1})
+	now := time.Now()
+	if !l.allow("first", now) || l.allow("first", now) {
+		t.Fatal("first client not limited")
+	}
+	for i := 0; i < 2*maxBuckets; i++ {
+		l.allow(strconv.Itoa(i), now)
+	}
+	if n := l.buckets.Len(); n != maxBuckets {
+		t.Fatalf("bucket count mismatch: have %d, want %d", n, maxBuckets)
+	}
+	// The least recently used bucket was dropped.
+	if !l.allow("first", now) {
+		t.Fatal("evicted client still limited")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		records []*log.Record
+	)
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		mu.Lock()
+		records = append(records, r)
+		mu.Unlock()
+		return nil
+	}))
+	secret := []byte("secret")
+	h := HTTPHandler(newMiddlewareServer(t, AuditLog(logger), JWTAuth(secret)))
+
+	token, _ := SignJWT(secret, "bob", time.Hour)
+	postRPC(t, h, func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) })
+	postRPC(t, h, nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 2 {
+		t.Fatalf("records mismatch: have %d, want 2", len(records))
+	}
+	ctx := func(r *log.Record) map[string]interface{} {
+		m := make(map[string]interface{})
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			m[r.Ctx[i].(string)] = r.Ctx[i+1]
+		}
+		return m
+	}
+	served, failed := ctx(records[0]), ctx(records[1])
+	if records[0].Lvl != log.LvlInfo || served["method"] != "calc_add" || served["caller"] != "bob" || served["remote"] == "" {
+		t.Errorf("served record mismatch: %v %v", records[0].Msg, served)
+	}
+	if records[1].Lvl != log.LvlWarn || failed["caller"] != "" || failed["code"] != ErrUnauthorized.Code {
+		t.Errorf("failed record mismatch: %v %v", records[1].Msg, failed)
+	}
+}
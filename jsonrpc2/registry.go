@@ -0,0 +1,302 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/rpc"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	contextType      = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+	subscriptionType = reflect.TypeOf((*Subscription)(nil))
+)
+
+// CodedError is an error which is sent with its own JSON-RPC 2.0 error code
+// when returned by a Registry method. Other errors are sent with code
+// -32000.
+type CodedError interface {
+	error
+	ErrorCode() int
+}
+
+// DataError is an error which carries additional "data" when returned by a
+// Registry method.
+type DataError interface {
+	error
+	ErrorData() interface{}
+}
+
+// registries maps rpc servers to the Registry mounted on them.
+var registries sync.Map
+
+func registryOf(srv *rpc.Server) *Registry {
+	if r, ok := registries.Load(srv); ok {
+		return r.(*Registry)
+	}
+	return nil
+}
+
+// Registry serves methods which don't fit net/rpc. Every exported method of
+// a registered receiver is served as "namespace_method" (first letter of
+// the method lower-cased) if it has the form
+//
+//	func (T) Method([ctx context.Context,] a A, b B, ...) [([result R,] [err error])]
+//
+// Params are passed as a positional JSON array. Trailing params of pointer,
+// slice, map or interface type are optional and are nil when omitted. The
+// context is the one of the connection (see WithContext), so it carries the
+// HTTP request or the Notifier. Methods returning a *Subscription reply
+// with its id.
+//
+// Requests for registered names are dispatched by the codec before the
+// net/rpc service lookup, so registry and net/rpc services can be mixed on
+// one server.
+type Registry struct {
+	mu      sync.RWMutex
+	methods map[string]*callback
+}
+
+type callback struct {
+	rcvr     reflect.Value
+	fn       reflect.Value
+	argTypes []reflect.Type
+	hasCtx   bool
+	errPos   int // index of the error result, -1 if none
+	hasRes   bool
+}
+
+// NewRegistry returns the Registry mounted on srv, creating it on first use.
+//
+// If srv is nil then rpc.DefaultServer will be used.
+func NewRegistry(srv *rpc.Server) *Registry {
+	if srv == nil {
+		srv = rpc.DefaultServer
+	}
+	srv.Register(JSONRPC2{})
+	r, _ := registries.LoadOrStore(srv, &Registry{methods: make(map[string]*callback)})
+	return r.(*Registry)
+}
+
+// RegisterName registers the suitable methods of rcvr under namespace.
+// Methods already registered under the same name are replaced.
+func (r *Registry) RegisterName(namespace string, rcvr interface{}) error {
+	if namespace == "" || strings.Contains(namespace, ".") {
+		return fmt.Errorf("jsonrpc2: invalid namespace %q", namespace)
+	}
+	val := reflect.ValueOf(rcvr)
+	typ := val.Type()
+	methods := make(map[string]*callback)
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if method.PkgPath != "" {
+			continue // unexported
+		}
+		if cb := newCallback(val, method); cb != nil {
+			methods[namespace+"_"+formatName(method.Name)] = cb
+		}
+	}
+	if len(methods) == 0 {
+		return fmt.Errorf("jsonrpc2: %T has no suitable methods", rcvr)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, cb := range methods {
+		r.methods[name] = cb
+	}
+	return nil
+}
+
+// Methods returns the sorted names of the registered methods.
+func (r *Registry) Methods() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.methods))
+	for name := range r.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) callback(method string) *callback {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.methods[method]
+}
+
+// formatName lower-cases the first letter of name.
+func formatName(name string) string {
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(first)) + name[size:]
+}
+
+// newCallback returns nil if method doesn't have a suitable signature.
+func newCallback(rcvr reflect.Value, method reflect.Method) *callback {
+	fntype := method.Type
+	if fntype.IsVariadic() {
+		return nil
+	}
+	cb := &callback{rcvr: rcvr, fn: method.Func, errPos: -1}
+	for i := 1; i < fntype.NumIn(); i++ {
+		if i == 1 && fntype.In(i) == contextType {
+			cb.hasCtx = true
+			continue
+		}
+		cb.argTypes = append(cb.argTypes, fntype.In(i))
+	}
+	switch fntype.NumOut() {
+	case 0:
+	case 1:
+		if fntype.Out(0) == errorType {
+			cb.errPos = 0
+		} else {
+			cb.hasRes = true
+		}
+	case 2:
+		if fntype.Out(0) == errorType || fntype.Out(1) != errorType {
+			return nil
+		}
+		cb.hasRes, cb.errPos = true, 1
+	default:
+		return nil
+	}
+	return cb
+}
+
+func isOptional(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// parseArgs decodes the positional params.
+func (cb *callback) parseArgs(params *json.RawMessage) ([]reflect.Value, error) {
+	var raw []json.RawMessage
+	if params != nil {
+		if len(*params) == 0 || (*params)[0] != '[' {
+			return nil, NewError(errParams.Code, "non-array params")
+		}
+		if err := json.Unmarshal(*params, &raw); err != nil {
+			return nil, NewError(errParams.Code, err.Error())
+		}
+	}
+	if len(raw) > len(cb.argTypes) {
+		return nil, NewError(errParams.Code, fmt.Sprintf("too many arguments, want at most %d", len(cb.argTypes)))
+	}
+	args := make([]reflect.Value, len(cb.argTypes))
+	for i, typ := range cb.argTypes {
+		if i >= len(raw) {
+			if !isOptional(typ) {
+				return nil, NewError(errParams.Code, fmt.Sprintf("missing value for required argument %d", i))
+			}
+			args[i] = reflect.Zero(typ)
+			continue
+		}
+		val := reflect.New(typ)
+		if err := json.Unmarshal(raw[i], val.Interface()); err != nil {
+			return nil, NewError(errParams.Code, fmt.Sprintf("invalid argument %d: %v", i, err))
+		}
+		args[i] = val.Elem()
+	}
+	return args, nil
+}
+
+// call runs the method and returns its JSON encoded result.
+func (cb *callback) call(ctx context.Context, params *json.RawMessage) (result json.RawMessage, err error) {
+	args, err := cb.parseArgs(params)
+	if err != nil {
+		return nil, err
+	}
+	in := make([]reflect.Value, 0, len(args)+2)
+	in = append(in, cb.rcvr)
+	if cb.hasCtx {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		in = append(in, reflect.ValueOf(ctx))
+	}
+	in = append(in, args...)
+
+	defer func() {
+		if recover() != nil {
+			result, err = nil, NewError(errInternal.Code, "method handler crashed")
+		}
+	}()
+	out := cb.fn.Call(in)
+	if cb.errPos >= 0 && !out[cb.errPos].IsNil() {
+		return nil, toError(out[cb.errPos].Interface().(error))
+	}
+	if !cb.hasRes {
+		return null, nil
+	}
+	res := out[0].Interface()
+	if out[0].Type() == subscriptionType {
+		if out[0].IsNil() {
+			return null, nil
+		}
+		res = res.(*Subscription).ID
+	}
+	if result, err = json.Marshal(res); err != nil {
+		return nil, NewError(errInternal.Code, err.Error())
+	}
+	return result, nil
+}
+
+// toError converts an error returned by a method into an Error, nil stays
+// nil.
+func toError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	e = NewError(errServer.Code, err.Error())
+	var coded CodedError
+	if errors.As(err, &coded) {
+		e.Code = coded.ErrorCode()
+	}
+	var data DataError
+	if errors.As(err, &data) {
+		e.Data = data.ErrorData()
+	}
+	return e
+}
+
+// CallArg is a param for internal RPC JSONRPC2.Call.
+type CallArg struct {
+	srv    *rpc.Server
+	method string
+	params *json.RawMessage
+	Ctx
+}
+
+// Call is an internal RPC method used to process requests for Registry
+// methods.
+func (JSONRPC2) Call(arg CallArg, reply *json.RawMessage) error {
+	var cb *callback
+	if reg := registryOf(arg.srv); reg != nil {
+		cb = reg.callback(arg.method)
+	}
+	if cb == nil {
+		return NewError(errMethod.Code, "method not found: "+arg.method)
+	}
+	result, err := cb.call(arg.Context(), arg.params)
+	if err != nil {
+		return err
+	}
+	*reply = result
+	return nil
+}
@@ -0,0 +1,141 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"net/rpc"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type codedError struct{}
+
+func (codedError) Error() string          { return "coded" }
+func (codedError) ErrorCode() int         { return -32099 }
+func (codedError) ErrorData() interface{} { return "extra" }
+
+type calcService struct{}
+
+func (calcService) Add(a, b int) int { return a + b }
+
+func (calcService) Greet(ctx context.Context, name string, times *int) (string, error) {
+	if ctx == nil {
+		return "", errors.New("no context")
+	}
+	n := 1
+	if times != nil {
+		n = *times
+	}
+	s := ""
+	for i := 0; i < n; i++ {
+		s += "hi " + name + ";"
+	}
+	return s, nil
+}
+
+func (calcService) Fail() error  { return errors.New("failed") }
+func (calcService) Coded() error { return codedError{} }
+func (calcService) Crash() int   { panic("boom") }
+
+func (calcService) Subscribe(ctx context.Context, n int) (*Subscription, error) {
+	notifier, ok := NotifierFromContext(ctx)
+	if !ok {
+		return nil, ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription("calc")
+	for i := 0; i < n; i++ {
+		notifier.Notify(sub.ID, i)
+	}
+	return sub, nil
+}
+
+func (calcService) unexported() {}
+
+func newRegistryServer(t *testing.T) *rpc.Server {
+	srv := rpc.NewServer()
+	if err := NewRegistry(srv).RegisterName("calc", calcService{}); err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestRegistryMethods(t *testing.T) {
+	srv := newRegistryServer(t)
+	want := []string{"calc_add", "calc_coded", "calc_crash", "calc_fail", "calc_greet", "calc_subscribe"}
+	if have := NewRegistry(srv).Methods(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("methods mismatch: have %v, want %v", have, want)
+	}
+	if err := NewRegistry(srv).RegisterName("bad.ns", calcService{}); err == nil {
+		t.Fatal("invalid namespace accepted")
+	}
+}
+
+func TestRegistryCall(t *testing.T) {
+	ts := httptest.NewServer(HTTPHandler(newRegistryServer(t)))
+	defer ts.Close()
+	client := NewHTTPClient(ts.URL)
+	defer client.Close()
+
+	var sum int
+	if err := client.Call("calc_add", []int{2, 3}, &sum); err != nil || sum != 5 {
+		t.Fatalf("add: have %d, %v", sum, err)
+	}
+	var greeting string
+	if err := client.Call("calc_greet", []interface{}{"moac"}, &greeting); err != nil || greeting != "hi moac;" {
+		t.Fatalf("greet: have %q, %v", greeting, err)
+	}
+	if err := client.Call("calc_greet", []interface{}{"moac", 2}, &greeting); err != nil || greeting != "hi moac;hi moac;" {
+		t.Fatalf("greet twice: have %q, %v", greeting, err)
+	}
+
+	for _, test := range []struct {
+		method string
+		params interface{}
+		code   int
+		data   interface{}
+	}{
+		{"calc_add", []int{1}, errParams.Code, nil},
+		{"calc_add", []int{1, 2, 3}, errParams.Code, nil},
+		{"calc_add", []string{"a", "b"}, errParams.Code, nil},
+		{"calc_add", map[string]int{"a": 1}, errParams.Code, nil},
+		{"calc_fail", nil, errServer.Code, nil},
+		{"calc_coded", nil, -32099, "extra"},
+		{"calc_crash", nil, errInternal.Code, nil},
+		{"calc_missing", nil, errMethod.Code, nil},
+		{"calc_subscribe", []int{1}, ErrNotificationsUnsupported.Code, nil},
+	} {
+		err := client.Call(test.method, test.params, &sum)
+		e := ServerError(err)
+		if e == nil || e.Code != test.code || e.Data != test.data {
+			t.Errorf("%s %v: error mismatch: have %v, want code %d", test.method, test.params, err, test.code)
+		}
+	}
+}
+
+func TestRegistrySubscription(t *testing.T) {
+	srv := newRegistryServer(t)
+	cli, conn := net.Pipe()
+	go serveConn(context.Background(), conn, srv)
+	client := NewClient(cli)
+	defer client.Close()
+
+	ch := make(chan int)
+	sub, err := client.Subscribe("calc", ch, []int{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	for i := 0; i < 2; i++ {
+		select {
+		case v := <-ch:
+			if v != i {
+				t.Fatalf("notification %d mismatch: have %d", i, v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("notification %d timed out", i)
+		}
+	}
+}
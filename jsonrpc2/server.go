@@ -10,8 +10,11 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"net/rpc"
+	"strings"
 	"sync"
+	"time"
 )
 
 type serverCodec struct {
@@ -31,9 +34,15 @@ type serverCodec struct {
 	// but save the original request ID in the pending map.
 	// When rpc responds, we use the sequence number in
 	// the response to find the original request ID.
-	mutex   sync.Mutex // protects seq, pending
-	seq     uint64
-	pending map[uint64]*json.RawMessage
+	mutex     sync.Mutex // protects seq, pending, requests, notifiers
+	seq       uint64
+	pending   map[uint64]*json.RawMessage
+	requests  map[uint64]*Request  // seen by middleware
+	notifiers map[uint64]*Notifier // creating the subscriptions of requests
+
+	// middleware outcome for the request being read
+	rejected error
+	reqCtx   context.Context
 }
 
 // NewServerCodec returns a new rpc.ServerCodec using JSON-RPC 2.0 on conn,
@@ -52,12 +61,14 @@ func NewServerCodec(conn io.ReadWriteCloser, srv *rpc.Server) rpc.ServerCodec {
 	}
 	srv.Register(JSONRPC2{})
 	return &serverCodec{
-		dec:     json.NewDecoder(conn),
-		enc:     json.NewEncoder(conn),
-		c:       conn,
-		srv:     srv,
-		ctx:     context.Background(),
-		pending: make(map[uint64]*json.RawMessage),
+		dec:       json.NewDecoder(conn),
+		enc:       json.NewEncoder(conn),
+		c:         conn,
+		srv:       srv,
+		ctx:       context.Background(),
+		pending:   make(map[uint64]*json.RawMessage),
+		requests:  make(map[uint64]*Request),
+		notifiers: make(map[uint64]*Notifier),
 	}
 }
 
@@ -85,8 +96,8 @@ func (r *serverRequest) reset() {
 
 func (r *serverRequest) UnmarshalJSON(raw []byte) error {
 	r.reset()
-	type req *serverRequest
-	if err := json.Unmarshal(raw, req(r)); err != nil {
+	type req serverRequest
+	if err := json.Unmarshal(raw, (*req)(r)); err != nil {
 		return errors.New("bad request")
 	}
 
@@ -150,7 +161,8 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
 		return err
 	}
 
-	if len(raw) > 0 && raw[0] == '[' {
+	batch := len(raw) > 0 && raw[0] == '['
+	if batch {
 		c.req.Version = "2.0"
 		c.req.Method = "JSONRPC2.Batch"
 		c.req.Params = &raw
@@ -164,7 +176,41 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
 		return err
 	}
 
-	r.ServiceMethod = c.req.Method
+	c.rejected, c.reqCtx = nil, c.ctx
+	if reg := registryOf(c.srv); reg != nil && reg.callback(c.req.Method) != nil {
+		r.ServiceMethod = "JSONRPC2.Call"
+	} else if !batch && strings.HasPrefix(c.req.Method, "JSONRPC2.") {
+		// The internal service is only reachable through the rewrites here.
+		r.ServiceMethod = "JSONRPC2.Reject"
+		c.rejected = NewError(errMethod.Code, "rpc: can't find service "+c.req.Method)
+	} else {
+		r.ServiceMethod = serviceMethod(c.req.Method)
+	}
+
+	// Batches are passed through, middleware sees each of their requests.
+	var req *Request
+	if chain := chainOf(c.srv); len(chain) > 0 && !batch && c.rejected == nil {
+		req = &Request{
+			Method:       c.req.Method,
+			Notification: c.req.ID == nil,
+			Context:      c.ctx,
+			RemoteAddr:   RemoteAddrFromContext(c.ctx),
+			started:      time.Now(),
+		}
+		if c.req.Params != nil {
+			req.Params = *c.req.Params
+		}
+		for _, mw := range chain {
+			if err := mw(req); err != nil {
+				r.ServiceMethod = "JSONRPC2.Reject"
+				c.rejected = err
+				break
+			}
+		}
+		if req.Caller != "" {
+			c.reqCtx = context.WithValue(c.ctx, callerContextKey, req.Caller)
+		}
+	}
 
 	// JSON request id can be any JSON value;
 	// RPC package expects uint64.  Translate to
@@ -172,6 +218,14 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
 	c.mutex.Lock()
 	c.seq++
 	c.pending[c.seq] = c.req.ID
+	if req != nil {
+		c.requests[c.seq] = req
+	}
+	if n, ok := NotifierFromContext(c.ctx); ok {
+		n = n.request()
+		c.notifiers[c.seq] = n
+		c.reqCtx = context.WithValue(c.reqCtx, notifierContextKey, n)
+	}
 	c.req.ID = nil
 	r.Seq = c.seq
 	c.mutex.Unlock()
@@ -186,7 +240,20 @@ func (c *serverCodec) ReadRequestBody(x interface{}) error {
 		return nil
 	}
 	if x, ok := x.(WithContext); ok {
-		x.SetContext(c.ctx)
+		x.SetContext(c.reqCtx)
+	}
+	if arg, ok := x.(*UnsubscribeArg); ok {
+		arg.namespace = strings.TrimSuffix(c.req.Method, unsubscribeSuffix)
+	}
+	if arg, ok := x.(*RejectArg); ok {
+		arg.err = c.rejected
+		return nil
+	}
+	if arg, ok := x.(*CallArg); ok {
+		arg.srv = c.srv
+		arg.method = c.req.Method
+		arg.params = c.req.Params
+		return nil
 	}
 	if c.req.Params == nil {
 		return nil
@@ -220,8 +287,19 @@ func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
 		return errors.New("invalid sequence number in response")
 	}
 	delete(c.pending, r.Seq)
+	req := c.requests[r.Seq]
+	delete(c.requests, r.Seq)
+	n := c.notifiers[r.Seq]
+	delete(c.notifiers, r.Seq)
 	c.mutex.Unlock()
 
+	if req != nil {
+		req.finish(responseError(r.Error))
+	}
+	if n != nil {
+		defer n.release()
+	}
+
 	if replies, ok := x.(*[]*json.RawMessage); r.ServiceMethod == "JSONRPC2.Batch" && ok {
 		if len(*replies) == 0 {
 			return nil
@@ -254,8 +332,45 @@ func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
 		resp.Error = &raw
 	}
 	c.encmutex.Lock()
-	defer c.encmutex.Unlock()
-	return c.enc.Encode(resp)
+	err := c.enc.Encode(resp)
+	c.encmutex.Unlock()
+	if err != nil {
+		return err
+	}
+	// Notifications of a new subscription are held back until the client
+	// has its id.
+	if id, ok := subscriptionID(x); ok && r.Error == "" && n != nil {
+		n.activate(id)
+	}
+	return nil
+}
+
+// responseError returns the error sent for r.Error, nil if there is none.
+func responseError(msg string) *Error {
+	if msg == "" {
+		return nil
+	}
+	if msg[0] == '{' && msg[len(msg)-1] == '}' {
+		e := new(Error)
+		if json.Unmarshal([]byte(msg), e) == nil {
+			return e
+		}
+	}
+	return newError(msg)
+}
+
+// subscriptionID returns the string reply x, which may be a subscription id.
+func subscriptionID(x interface{}) (string, bool) {
+	switch x := x.(type) {
+	case *string:
+		return *x, true
+	case *json.RawMessage:
+		var id string
+		if len(*x) > 0 && (*x)[0] == '"' && json.Unmarshal(*x, &id) == nil {
+			return id, true
+		}
+	}
+	return "", false
 }
 
 func (c *serverCodec) Close() error {
@@ -266,11 +381,27 @@ func (c *serverCodec) Close() error {
 // ServeConn blocks, serving the connection until the client hangs up.
 // The caller typically invokes ServeConn in a go statement.
 func ServeConn(conn io.ReadWriteCloser) {
-	rpc.ServeCodec(NewServerCodec(conn, nil))
+	ServeConnContext(context.Background(), conn)
 }
 
 // ServeConnContext is ServeConn with given context provided
 // within parameters for compatible RPC methods.
 func ServeConnContext(ctx context.Context, conn io.ReadWriteCloser) {
-	rpc.ServeCodec(NewServerCodecContext(ctx, conn, nil))
+	serveConn(ctx, conn, nil)
+}
+
+// serveConn runs srv on a persistent connection. Unlike a single HTTP
+// request, such a connection can carry notifications, so a Notifier is
+// provided within the context.
+func serveConn(ctx context.Context, conn io.ReadWriteCloser, srv *rpc.Server) {
+	codec := NewServerCodec(conn, srv).(*serverCodec)
+	n := newNotifier(codec)
+	// WebSocket connections have their HTTP request, whose RemoteAddr is
+	// the one of the client.
+	if c, ok := conn.(net.Conn); ok && HTTPRequestFromContext(ctx) == nil {
+		ctx = context.WithValue(ctx, remoteAddrContextKey, c.RemoteAddr().String())
+	}
+	codec.ctx = context.WithValue(ctx, notifierContextKey, n)
+	codec.srv.ServeCodec(codec)
+	n.close()
 }
@@ -0,0 +1,404 @@
+package jsonrpc2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/rpc"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	subscribeSuffix    = "_subscribe"
+	unsubscribeSuffix  = "_unsubscribe"
+	notificationSuffix = "_subscription"
+
+	// maxClientSubscriptionBuffer is the number of notifications a client
+	// subscription buffers before it is dropped.
+	maxClientSubscriptionBuffer = 20000
+	// maxSubscriptionBuffer is the number of notifications a server
+	// subscription holds back until activation before it is dropped.
+	maxSubscriptionBuffer = 10000
+)
+
+var (
+	// ErrNotificationsUnsupported is returned when the transport can't
+	// push notifications, e.g. HTTP.
+	ErrNotificationsUnsupported = NewError(errServer.Code, "notifications not supported")
+	// ErrSubscriptionNotFound is returned for an unknown subscription id.
+	ErrSubscriptionNotFound = NewError(errServer.Code, "subscription not found")
+	// ErrSubscriptionQueueOverflow is sent on ClientSubscription.Err when
+	// the channel given to Subscribe isn't drained fast enough, and returned
+	// by Notifier.Notify when too many notifications are held back.
+	ErrSubscriptionQueueOverflow = errors.New("jsonrpc2: subscription queue overflow")
+)
+
+var notifierContextKey contextKey = 1
+
+// serviceMethod maps JSON-RPC 2.0 subscription method names onto net/rpc
+// names: "ns_subscribe" is served by method Subscribe of service "ns", and
+// "ns_unsubscribe" by the internal JSONRPC2.Unsubscribe.
+func serviceMethod(method string) string {
+	if strings.Contains(method, ".") {
+		return method
+	}
+	switch {
+	case strings.HasSuffix(method, unsubscribeSuffix):
+		return "JSONRPC2.Unsubscribe"
+	case strings.HasSuffix(method, subscribeSuffix):
+		return strings.TrimSuffix(method, subscribeSuffix) + ".Subscribe"
+	}
+	return method
+}
+
+// Notifier sends notifications to the client of one connection. It is
+// available from the context of RPC methods (see WithContext) served on
+// persistent connections: ServeConn, ServeListener, DialIPC and WebSocket.
+//
+// A typical Subscribe method looks like:
+//
+//	func (s *Service) Subscribe(arg Arg, id *string) error {
+//		n, ok := jsonrpc2.NotifierFromContext(arg.Context())
+//		if !ok {
+//			return jsonrpc2.ErrNotificationsUnsupported
+//		}
+//		sub := n.CreateSubscription("mc")
+//		go func() {
+//			for {
+//				select {
+//				case block := <-s.blocks:
+//					n.Notify(sub.ID, block)
+//				case <-sub.Err():
+//					return
+//				}
+//			}
+//		}()
+//		*id = sub.ID
+//		return nil
+//	}
+//
+// Subscriptions are dropped if the request creating them fails or doesn't
+// reply with their id.
+type Notifier struct {
+	*connNotifier // shared by all requests of the connection
+}
+
+type connNotifier struct {
+	codec *serverCodec
+
+	mu     sync.Mutex // protects subs, closed and sends
+	subs   map[string]*Subscription
+	closed bool
+}
+
+// Subscription is a server side subscription created by a Notifier.
+type Subscription struct {
+	ID        string
+	namespace string
+	creator   *Notifier     // notifier of the request creating it
+	active    bool          // set once the subscribe reply was sent
+	buffer    []interface{} // notifications sent before activation
+	err       chan error
+}
+
+// Err returns a channel which is closed when the client unsubscribes or the
+// connection is closed.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+type notification struct {
+	Version string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  subscriptionResult `json:"params"`
+}
+
+type subscriptionResult struct {
+	ID     string      `json:"subscription"`
+	Result interface{} `json:"result"`
+}
+
+func newNotifier(codec *serverCodec) *Notifier {
+	return &Notifier{&connNotifier{codec: codec, subs: make(map[string]*Subscription)}}
+}
+
+// request returns the notifier for a request on the connection of n.
+func (n *Notifier) request() *Notifier {
+	return &Notifier{n.connNotifier}
+}
+
+// NotifierFromContext returns the Notifier of the connection the request
+// came in on, or false if the transport doesn't support notifications.
+func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	n, ok := ctx.Value(notifierContextKey).(*Notifier)
+	return n, ok
+}
+
+// CreateSubscription returns a new subscription whose notifications are sent
+// as "<namespace>_subscription". Notifications are held back until the
+// reply carrying the subscription id has been written, so the Subscribe
+// method must set that id as its reply.
+func (n *Notifier) CreateSubscription(namespace string) *Subscription {
+	sub := &Subscription{ID: newSubscriptionID(), namespace: namespace, creator: n, err: make(chan error)}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		close(sub.err)
+		return sub
+	}
+	n.subs[sub.ID] = sub
+	return sub
+}
+
+// Notify sends data to the client of subscription id. Notifications are
+// held back until the subscription is active; a subscription holding back
+// too many is dropped.
+func (n *Notifier) Notify(id string, data interface{}) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sub, ok := n.subs[id]
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+	if !sub.active {
+		if len(sub.buffer) >= maxSubscriptionBuffer {
+			n.drop(sub)
+			return ErrSubscriptionQueueOverflow
+		}
+		sub.buffer = append(sub.buffer, data)
+		return nil
+	}
+	return n.send(sub, data)
+}
+
+// drop ends sub, n.mu must be held.
+func (n *connNotifier) drop(sub *Subscription) {
+	delete(n.subs, sub.ID)
+	close(sub.err)
+}
+
+// release drops the subscriptions created by the request of n which
+// weren't activated by its reply.
+func (n *Notifier) release() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, sub := range n.subs {
+		if sub.creator == n && !sub.active {
+			n.drop(sub)
+		}
+	}
+}
+
+// send writes a notification, n.mu must be held.
+func (n *connNotifier) send(sub *Subscription, data interface{}) error {
+	msg := notification{
+		Version: "2.0",
+		Method:  sub.namespace + notificationSuffix,
+		Params:  subscriptionResult{ID: sub.ID, Result: data},
+	}
+	n.codec.encmutex.Lock()
+	defer n.codec.encmutex.Unlock()
+	return n.codec.enc.Encode(&msg)
+}
+
+// activate flushes the notifications held back for id.
+func (n *connNotifier) activate(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sub, ok := n.subs[id]
+	if !ok || sub.active {
+		return
+	}
+	sub.active = true
+	for _, data := range sub.buffer {
+		if n.send(sub, data) != nil {
+			break
+		}
+	}
+	sub.buffer = nil
+}
+
+// unsubscribe ends subscription id if it belongs to namespace.
+func (n *connNotifier) unsubscribe(namespace, id string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sub, ok := n.subs[id]
+	if !ok || sub.namespace != namespace {
+		return false
+	}
+	n.drop(sub)
+	return true
+}
+
+// close ends all subscriptions once the connection is gone.
+func (n *connNotifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	for _, sub := range n.subs {
+		n.drop(sub)
+	}
+}
+
+func newSubscriptionID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic("jsonrpc2: can't read random subscription id: " + err.Error())
+	}
+	return "0x" + hex.EncodeToString(id[:])
+}
+
+// UnsubscribeArg is a param for internal RPC JSONRPC2.Unsubscribe.
+type UnsubscribeArg struct {
+	ID        string
+	namespace string
+	Ctx
+}
+
+// UnmarshalJSON decodes the positional param list [id].
+func (arg *UnsubscribeArg) UnmarshalJSON(raw []byte) error {
+	var params []string
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+	if len(params) != 1 {
+		return errors.New("expected subscription id")
+	}
+	arg.ID = params[0]
+	return nil
+}
+
+// Unsubscribe is an internal RPC method used to process "*_unsubscribe"
+// requests.
+func (JSONRPC2) Unsubscribe(arg UnsubscribeArg, ok *bool) error {
+	n, found := NotifierFromContext(arg.Context())
+	if !found {
+		return ErrNotificationsUnsupported
+	}
+	if !n.unsubscribe(arg.namespace, arg.ID) {
+		return ErrSubscriptionNotFound
+	}
+	*ok = true
+	return nil
+}
+
+// ClientSubscription is a subscription established with Client.Subscribe.
+type ClientSubscription struct {
+	client    *rpc.Client
+	codec     *clientCodec
+	namespace string
+	id        string
+
+	mu    sync.Mutex // protects queue
+	queue []json.RawMessage
+	wake  chan struct{}
+	quit  chan struct{}
+	err   chan error
+	once  sync.Once
+}
+
+func newClientSubscription(codec *clientCodec, namespace, id string) *ClientSubscription {
+	return &ClientSubscription{
+		client:    codec.client,
+		codec:     codec,
+		namespace: namespace,
+		id:        id,
+		wake:      make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+		err:       make(chan error, 1),
+	}
+}
+
+// ID returns the subscription id assigned by the server.
+func (s *ClientSubscription) ID() string {
+	return s.id
+}
+
+// Err returns the subscription error channel. It receives the error that
+// ended the subscription, if any, and is closed once the subscription ends,
+// including after Unsubscribe.
+func (s *ClientSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe stops delivery and cancels the subscription on the server.
+func (s *ClientSubscription) Unsubscribe() {
+	s.close(nil)
+	s.unsubscribe()
+}
+
+func (s *ClientSubscription) unsubscribe() {
+	var ok bool
+	s.client.Call(s.namespace+unsubscribeSuffix, []string{s.id}, &ok)
+}
+
+// deliver queues a notification result, it must not block the read loop.
+func (s *ClientSubscription) deliver(result json.RawMessage) {
+	s.mu.Lock()
+	overflow := len(s.queue) >= maxClientSubscriptionBuffer
+	if !overflow {
+		s.queue = append(s.queue, result)
+	}
+	s.mu.Unlock()
+	if overflow {
+		s.close(ErrSubscriptionQueueOverflow)
+		go s.unsubscribe()
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *ClientSubscription) close(err error) {
+	s.once.Do(func() {
+		s.codec.forget(s.id)
+		if err != nil {
+			s.err <- err
+		}
+		close(s.err)
+		close(s.quit)
+	})
+}
+
+// forward decodes queued results into values of ch's element type and sends
+// them on ch until the subscription ends.
+func (s *ClientSubscription) forward(ch reflect.Value) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-s.quit:
+				return
+			}
+		}
+		result := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		val := reflect.New(ch.Type().Elem())
+		if err := json.Unmarshal(result, val.Interface()); err != nil {
+			s.close(err)
+			s.unsubscribe()
+			return
+		}
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: ch, Send: val.Elem()},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.quit)},
+		}
+		if chosen, _, _ := reflect.Select(cases); chosen == 1 {
+			return
+		}
+	}
+}
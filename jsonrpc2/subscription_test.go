@@ -0,0 +1,250 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type CountArg struct {
+	N int `json:"n"`
+	Ctx
+}
+
+type testService struct {
+	subs chan *Subscription
+}
+
+func (s *testService) Echo(arg []string, reply *string) error {
+	*reply = strings.Join(arg, " ")
+	return nil
+}
+
+// Subscribe sends the numbers 0..N-1, then waits for the unsubscription.
+func (s *testService) Subscribe(arg CountArg, id *string) error {
+	n, ok := NotifierFromContext(arg.Context())
+	if !ok {
+		return ErrNotificationsUnsupported
+	}
+	sub := n.CreateSubscription("test")
+	for i := 0; i < arg.N; i++ {
+		if err := n.Notify(sub.ID, i); err != nil {
+			return err
+		}
+	}
+	s.subs <- sub
+	*id = sub.ID
+	return nil
+}
+
+// Broken creates a subscription, then fails.
+func (s *testService) Broken(arg CountArg, id *string) error {
+	n, ok := NotifierFromContext(arg.Context())
+	if !ok {
+		return ErrNotificationsUnsupported
+	}
+	sub := n.CreateSubscription("test")
+	n.Notify(sub.ID, 0)
+	s.subs <- sub
+	return errors.New("broken")
+}
+
+func newTestServer() (*rpc.Server, *testService) {
+	srv := rpc.NewServer()
+	svc := &testService{subs: make(chan *Subscription, 1)}
+	srv.RegisterName("test", svc)
+	return srv, svc
+}
+
+func testSubscription(t *testing.T, client *Client, svc *testService) {
+	var reply string
+	if err := client.Call("test.Echo", []string{"a", "b"}, &reply); err != nil || reply != "a b" {
+		t.Fatalf("call failed: %q, %v", reply, err)
+	}
+
+	ch := make(chan int)
+	sub, err := client.Subscribe("test", ch, CountArg{N: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := <-svc.subs
+	if sub.ID() != server.ID {
+		t.Fatalf("subscription id mismatch: have %s, want %s", sub.ID(), server.ID)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case v := <-ch:
+			if v != i {
+				t.Fatalf("notification %d mismatch: have %d", i, v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("notification %d timed out", i)
+		}
+	}
+	sub.Unsubscribe()
+	select {
+	case <-server.Err():
+	case <-time.After(5 * time.Second):
+		t.Fatal("server subscription not cancelled")
+	}
+	if _, ok := <-sub.Err(); ok {
+		t.Fatal("error on unsubscribed subscription")
+	}
+
+	// Closing the connection ends the subscription on both sides.
+	sub, err = client.Subscribe("test", ch, CountArg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = <-svc.subs
+	client.Close()
+	select {
+	case <-server.Err():
+	case <-time.After(5 * time.Second):
+		t.Fatal("server subscription not closed")
+	}
+	select {
+	case <-sub.Err():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client subscription not closed")
+	}
+}
+
+func TestIPCSubscription(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonrpc2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.ipc")
+
+	srv, svc := newTestServer()
+	l, err := ListenIPC(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go ServeListener(l, srv)
+
+	client, err := DialIPC(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSubscription(t, client, svc)
+}
+
+func TestWebSocketSubscription(t *testing.T) {
+	srv, svc := newTestServer()
+	ts := httptest.NewServer(WebSocketHandler(srv, "http://example.com"))
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	if _, err := DialWebSocket(url, "http://evil.com"); err == nil {
+		t.Fatal("disallowed origin accepted")
+	}
+	client, err := DialWebSocket(url, "http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSubscription(t, client, svc)
+}
+
+func TestWebSocketDefaultOrigins(t *testing.T) {
+	srv, _ := newTestServer()
+	ts := httptest.NewServer(WebSocketHandler(srv))
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	for origin, allowed := range map[string]bool{
+		"":                      true,
+		"http://localhost:8080": true,
+		"http://127.0.0.1":      true,
+		"https://[::1]:8545":    true,
+		"http://example.com":    false,
+		"http://localhost.evil": false,
+	} {
+		client, err := DialWebSocket(url, origin)
+		if (err == nil) != allowed {
+			t.Errorf("origin %q: allowed mismatch: have %v, want %v", origin, err == nil, allowed)
+		}
+		if client != nil {
+			client.Close()
+		}
+	}
+}
+
+func TestSubscriptionDropped(t *testing.T) {
+	srv, svc := newTestServer()
+	cli, conn := net.Pipe()
+	go serveConn(context.Background(), conn, srv)
+	client := NewClient(cli)
+	defer client.Close()
+
+	// A failing subscribe call drops its subscription.
+	var id string
+	if err := client.Call("test.Broken", CountArg{}, &id); err == nil {
+		t.Fatal("broken subscribe succeeded")
+	}
+	broken := <-svc.subs
+	select {
+	case <-broken.Err():
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription of failed call not dropped")
+	}
+
+	// Subscriptions are only cancelled within their namespace.
+	sub, err := client.Subscribe("test", make(chan int), CountArg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := <-svc.subs
+	var ok bool
+	err = client.Call("other_unsubscribe", []string{sub.ID()}, &ok)
+	if e := ServerError(err); e == nil || e.Message != ErrSubscriptionNotFound.Message {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSubscriptionNotFound)
+	}
+	if err := client.Call("test_unsubscribe", []string{sub.ID()}, &ok); err != nil || !ok {
+		t.Fatalf("unsubscribe failed: %v, %v", ok, err)
+	}
+	<-server.Err()
+}
+
+func TestNotifyOverflow(t *testing.T) {
+	n := newNotifier(nil)
+	sub := n.CreateSubscription("test")
+	for i := 0; i < maxSubscriptionBuffer; i++ {
+		if err := n.Notify(sub.ID, i); err != nil {
+			t.Fatalf("notification %d: %v", i, err)
+		}
+	}
+	if err := n.Notify(sub.ID, 0); err != ErrSubscriptionQueueOverflow {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSubscriptionQueueOverflow)
+	}
+	if _, ok := <-sub.Err(); ok {
+		t.Fatal("overflowed subscription not dropped")
+	}
+	if err := n.Notify(sub.ID, 0); err != ErrSubscriptionNotFound {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSubscriptionNotFound)
+	}
+}
+
+func TestHTTPSubscription(t *testing.T) {
+	srv, _ := newTestServer()
+	ts := httptest.NewServer(HTTPHandler(srv))
+	defer ts.Close()
+
+	client := NewHTTPClient(ts.URL)
+	defer client.Close()
+	_, err := client.Subscribe("test", make(chan int), CountArg{})
+	if e := ServerError(err); e == nil || e.Message != ErrNotificationsUnsupported.Message {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNotificationsUnsupported)
+	}
+}
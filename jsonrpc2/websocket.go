@@ -0,0 +1,72 @@
+package jsonrpc2
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/rpc"
+	"net/url"
+
+	"golang.org/x/net/websocket"
+)
+
+const defaultWebSocketOrigin = "http://localhost"
+
+// WebSocketHandler returns handler for HTTP requests which will upgrade
+// them to WebSocket and execute incoming JSON-RPC 2.0 using srv, one JSON
+// value per text frame. Unlike HTTPHandler the connection is persistent,
+// so RPC methods can push notifications (see Notifier).
+//
+// Browsers are only allowed to connect from the given origins ("*" allows
+// any); with no origins given only from localhost. Requests without an
+// Origin header, which are not sent by browsers, are always accepted.
+//
+// If srv is nil then rpc.DefaultServer will be used.
+func WebSocketHandler(srv *rpc.Server, origins ...string) http.Handler {
+	return websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			return checkOrigin(origins, req.Header.Get("Origin"))
+		},
+		Handler: func(conn *websocket.Conn) {
+			conn.PayloadType = websocket.TextFrame
+			ctx := context.WithValue(context.Background(), httpRequestContextKey, conn.Request())
+			serveConn(ctx, conn, srv)
+		},
+	}
+}
+
+func checkOrigin(allowed []string, origin string) error {
+	if origin == "" || allowedOrigin(allowed, origin) || (len(allowed) == 0 && localOrigin(origin)) {
+		return nil
+	}
+	return fmt.Errorf("origin %q not allowed", origin)
+}
+
+// localOrigin reports whether origin is a page served from localhost.
+func localOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// DialWebSocket connects to a JSON-RPC 2.0 server at the specified
+// WebSocket url ("ws://" or "wss://"). If origin is empty
+// "http://localhost" is sent.
+func DialWebSocket(url, origin string) (*Client, error) {
+	if origin == "" {
+		origin = defaultWebSocketOrigin
+	}
+	conn, err := websocket.Dial(url, "", origin)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
@@ -61,6 +61,7 @@ type ChainConfig struct {
 	DiffBombDefuseBlock   *big.Int      `json:"diffBombDefuseBlock,omitempty"`   // Fuxi switch block for defusing difficulty bomb
 	EnableClassicTx       *big.Int      `json:"enableClassicTx,omitempty"`       // Enable tx signed by ethereum tool chain
 	EnableFuxiPrecompiled *big.Int      `json:"enableFuxiPrecompiled,omitempty"` // Enable new precompiled contracts in fuxi
+	TypedTxBlock          *big.Int      `json:"typedTxBlock,omitempty"`          // Enable access-list and dynamic-fee transactions (nil = not scheduled)
 	RemoveEmptyAccount    bool          `json:"removeEmptyAccount,omitempty"`    //Replace EIP158 check and should be set to true
 	Ethash                *EthashConfig `json:"ethash,omitempty"`
 }
@@ -100,6 +101,7 @@ var (
 		big.NewInt(0),
 		big.NewInt(0),
 		big.NewInt(0),
+		big.NewInt(0),
 		true,
 		new(EthashConfig),
 	}
@@ -139,9 +141,9 @@ func (c *ChainConfig) String() string {
 		engine = "unknown"
 	}
 	return fmt.Sprintf(
-		"{ChainID: %v Pangu: %v Nuwa: %v Fuxi: %v DiffBomb: %v, ClassicTx: %v, FuxiPrecompiled: %v, Engine: %v}",
+		"{ChainID: %v Pangu: %v Nuwa: %v Fuxi: %v DiffBomb: %v, ClassicTx: %v, FuxiPrecompiled: %v, TypedTx: %v, Engine: %v}",
 		c.ChainId, c.PanguBlock, c.NuwaBlock, c.FuxiBlock, c.DiffBombDefuseBlock,
-		c.EnableClassicTx, c.EnableFuxiPrecompiled, engine,
+		c.EnableClassicTx, c.EnableFuxiPrecompiled, c.TypedTxBlock, engine,
 	)
 }
 
@@ -166,6 +168,18 @@ func (c *ChainConfig) IsFuxi(num *big.Int) bool {
 	return flag
 }
 
+// IsClassicTx returns whether num is either equal to the block enabling
+// transactions signed by the ethereum tool chain or greater.
+func (c *ChainConfig) IsClassicTx(num *big.Int) bool {
+	return isForked(c.EnableClassicTx, num)
+}
+
+// IsTypedTx returns whether num is either equal to the block enabling typed
+// transactions or greater.
+func (c *ChainConfig) IsTypedTx(num *big.Int) bool {
+	return isForked(c.TypedTxBlock, num)
+}
+
 //Remove the Empty account
 //To replace the EIP158Block check
 //Default is to remove all empty Accounts
@@ -215,6 +229,10 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 		return newCompatError("Fuxi fork block", c.FuxiBlock, newcfg.FuxiBlock)
 	}
 
+	if isForkIncompatible(c.TypedTxBlock, newcfg.TypedTxBlock, head) {
+		return newCompatError("typed transaction fork block", c.TypedTxBlock, newcfg.TypedTxBlock)
+	}
+
 	return nil
 }
 
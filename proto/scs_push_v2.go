@@ -0,0 +1,168 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package moac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Push stream versions: ScsPushMsg on Vnode.ScsPush, and ScsPushMsgV2 on
+// VnodeV2.ScsPush.
+const (
+	PushVersion1 uint32 = 1
+	PushVersion2 uint32 = 2
+)
+
+// CapPushV2 is the handshake capability bit announcing support of
+// PushVersion2.
+const CapPushV2 uint32 = 1 << 0
+
+// legacyNone is the legacy status of messages without one.
+const legacyNone = -1
+
+var (
+	ErrLegacyType      = errors.New("unknown legacy scs message type")
+	ErrLegacyStatus    = errors.New("unknown legacy scs message status")
+	ErrLegacyEncoding  = errors.New("invalid legacy scs message integer")
+	ErrPayloadMismatch = errors.New("scs message payload doesn't match its type")
+)
+
+// NegotiatePushVersion returns the push stream version to use between peers
+// with the local and remote handshake capabilities.
+func NegotiatePushVersion(local, remote uint32) uint32 {
+	if local&remote&CapPushV2 != 0 {
+		return PushVersion2
+	}
+	return PushVersion1
+}
+
+// legacyInt decodes a big endian int32 as written by common.IntToBytes.
+// Empty input is legacyNone.
+func legacyInt(b []byte) (int, error) {
+	switch len(b) {
+	case 0:
+		return legacyNone, nil
+	case 4:
+		return int(int32(binary.BigEndian.Uint32(b))), nil
+	}
+	return 0, fmt.Errorf("%w: %x", ErrLegacyEncoding, b)
+}
+
+func legacyBytes(n int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(int32(n)))
+	return b
+}
+
+// UpgradeScsPushMsg converts msg to the version 2 format.
+func UpgradeScsPushMsg(msg *ScsPushMsg) (*ScsPushMsgV2, error) {
+	v2 := &ScsPushMsgV2{
+		Requestid:   msg.GetRequestid(),
+		Requestflag: msg.GetRequestflag(),
+		Scsid:       msg.GetScsid(),
+		Subchainid:  msg.GetSubchainid(),
+		Sender:      msg.GetSender(),
+		Receiver:    msg.GetReceiver(),
+	}
+	switch ts := msg.GetTimestamp(); len(ts) {
+	case 0:
+	case 8:
+		v2.Timestamp = int64(binary.BigEndian.Uint64(ts))
+	default:
+		return nil, fmt.Errorf("%w: timestamp %x", ErrLegacyEncoding, ts)
+	}
+	typ, err := legacyInt(msg.GetType())
+	if err != nil {
+		return nil, err
+	}
+	status, err := legacyInt(msg.GetStatus())
+	if err != nil {
+		return nil, err
+	}
+
+	hash := msg.GetMsghash()
+	switch ScsMsgType(typ) {
+	case ScsMsgType_SCS_MSG_DIRECT_CALL:
+		v2.Payload = &ScsPushMsgV2_DirectCall{&ScsDirectCall{Msghash: hash}}
+	case ScsMsgType_SCS_MSG_BROADCAST:
+		if _, ok := BroadcastStatus_name[int32(status)]; !ok {
+			return nil, fmt.Errorf("%w: broadcast %d", ErrLegacyStatus, status)
+		}
+		v2.Payload = &ScsPushMsgV2_Broadcast{&ScsBroadcast{Status: BroadcastStatus(status), Msghash: hash}}
+	case ScsMsgType_SCS_MSG_CONTROL:
+		if _, ok := ControlStatus_name[int32(status+1)]; !ok {
+			return nil, fmt.Errorf("%w: control %d", ErrLegacyStatus, status)
+		}
+		v2.Payload = &ScsPushMsgV2_Control{&ScsControl{Status: ControlStatus(status + 1), Msghash: hash}}
+	case ScsMsgType_SCS_MSG_SHAKE_HAND:
+		v2.Payload = &ScsPushMsgV2_ShakeHand{&ScsShakeHand{Msghash: hash}}
+	case ScsMsgType_SCS_MSG_PING:
+		v2.Payload = &ScsPushMsgV2_Ping{&ScsPing{Msghash: hash}}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrLegacyType, typ)
+	}
+	v2.Type = ScsMsgType(typ)
+	return v2, nil
+}
+
+// Legacy converts m to the version 1 format. The payload may be missing, but
+// if present it must match the type.
+func (m *ScsPushMsgV2) Legacy() (*ScsPushMsg, error) {
+	msg := &ScsPushMsg{
+		Requestid:   m.GetRequestid(),
+		Requestflag: m.GetRequestflag(),
+		Scsid:       m.GetScsid(),
+		Subchainid:  m.GetSubchainid(),
+		Sender:      m.GetSender(),
+		Receiver:    m.GetReceiver(),
+		Type:        legacyBytes(int(m.GetType())),
+		Status:      legacyBytes(legacyNone),
+	}
+	if m.GetTimestamp() != 0 {
+		msg.Timestamp = make([]byte, 8)
+		binary.BigEndian.PutUint64(msg.Timestamp, uint64(m.GetTimestamp()))
+	}
+
+	var match bool
+	switch m.GetType() {
+	case ScsMsgType_SCS_MSG_DIRECT_CALL:
+		_, match = m.GetPayload().(*ScsPushMsgV2_DirectCall)
+		msg.Msghash = m.GetDirectCall().GetMsghash()
+	case ScsMsgType_SCS_MSG_BROADCAST:
+		_, match = m.GetPayload().(*ScsPushMsgV2_Broadcast)
+		msg.Status = legacyBytes(int(m.GetBroadcast().GetStatus()))
+		msg.Msghash = m.GetBroadcast().GetMsghash()
+	case ScsMsgType_SCS_MSG_CONTROL:
+		_, match = m.GetPayload().(*ScsPushMsgV2_Control)
+		msg.Status = legacyBytes(int(m.GetControl().GetStatus()) - 1)
+		msg.Msghash = m.GetControl().GetMsghash()
+	case ScsMsgType_SCS_MSG_SHAKE_HAND:
+		_, match = m.GetPayload().(*ScsPushMsgV2_ShakeHand)
+		msg.Msghash = m.GetShakeHand().GetMsghash()
+	case ScsMsgType_SCS_MSG_PING:
+		_, match = m.GetPayload().(*ScsPushMsgV2_Ping)
+		msg.Msghash = m.GetPing().GetMsghash()
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrLegacyType, m.GetType())
+	}
+	if !match && m.GetPayload() != nil {
+		return nil, fmt.Errorf("%w: %v with %T", ErrPayloadMismatch, m.GetType(), m.GetPayload())
+	}
+	return msg, nil
+}
@@ -0,0 +1,773 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: scs_push_v2.proto
+
+package moac
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ScsMsgType int32
+
+const (
+	ScsMsgType_SCS_MSG_UNSPECIFIED ScsMsgType = 0
+	ScsMsgType_SCS_MSG_DIRECT_CALL ScsMsgType = 1
+	ScsMsgType_SCS_MSG_BROADCAST   ScsMsgType = 2
+	ScsMsgType_SCS_MSG_CONTROL     ScsMsgType = 3
+	ScsMsgType_SCS_MSG_SHAKE_HAND  ScsMsgType = 4
+	ScsMsgType_SCS_MSG_PING        ScsMsgType = 5
+)
+
+var ScsMsgType_name = map[int32]string{
+	0: "SCS_MSG_UNSPECIFIED",
+	1: "SCS_MSG_DIRECT_CALL",
+	2: "SCS_MSG_BROADCAST",
+	3: "SCS_MSG_CONTROL",
+	4: "SCS_MSG_SHAKE_HAND",
+	5: "SCS_MSG_PING",
+}
+
+var ScsMsgType_value = map[string]int32{
+	"SCS_MSG_UNSPECIFIED": 0,
+	"SCS_MSG_DIRECT_CALL": 1,
+	"SCS_MSG_BROADCAST":   2,
+	"SCS_MSG_CONTROL":     3,
+	"SCS_MSG_SHAKE_HAND":  4,
+	"SCS_MSG_PING":        5,
+}
+
+func (x ScsMsgType) String() string {
+	return proto.EnumName(ScsMsgType_name, int32(x))
+}
+
+func (ScsMsgType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{0}
+}
+
+type ControlStatus int32
+
+const (
+	ControlStatus_CONTROL_NONE                                           ControlStatus = 0
+	ControlStatus_CONTROL_REG_OPEN                                       ControlStatus = 1
+	ControlStatus_CONTROL_REG_CLOSE                                      ControlStatus = 2
+	ControlStatus_CONTROL_CREATE_PROPOSAL                                ControlStatus = 3
+	ControlStatus_CONTROL_DISPUTE_PROPOSAL                               ControlStatus = 4
+	ControlStatus_CONTROL_APPROVE_PROPOSAL                               ControlStatus = 5
+	ControlStatus_CONTROL_REG_ADD                                        ControlStatus = 6
+	ControlStatus_CONTROL_REG_AS_MONITOR                                 ControlStatus = 7
+	ControlStatus_CONTROL_REG_AS_BACKUP                                  ControlStatus = 8
+	ControlStatus_CONTROL_UPDATE_LAST_FLUSH_BLK                          ControlStatus = 9
+	ControlStatus_CONTROL_DISTRIBUTE_PROPOSAL                            ControlStatus = 10
+	ControlStatus_CONTROL_RESET_ALL                                      ControlStatus = 11
+	ControlStatus_CONTROL_UPLOAD_REDEEM_DATA                             ControlStatus = 12
+	ControlStatus_CONTROL_ENTER_AND_REDEEM                               ControlStatus = 13
+	ControlStatus_CONTROL_REQUEST_RELEASE_IMMEDIATE_AND_VSS_GROUP_CONFIG ControlStatus = 14
+	ControlStatus_CONTROL_ENABLE_RNG                                     ControlStatus = 15
+	ControlStatus_CONTROL_VSS_GROUP_CONFIG                               ControlStatus = 16
+	ControlStatus_CONTROL_DISTRIBUTE_PROPOSAL_AND_VSS_GROUP_CONFIG       ControlStatus = 17
+)
+
+var ControlStatus_name = map[int32]string{
+	0:  "CONTROL_NONE",
+	1:  "CONTROL_REG_OPEN",
+	2:  "CONTROL_REG_CLOSE",
+	3:  "CONTROL_CREATE_PROPOSAL",
+	4:  "CONTROL_DISPUTE_PROPOSAL",
+	5:  "CONTROL_APPROVE_PROPOSAL",
+	6:  "CONTROL_REG_ADD",
+	7:  "CONTROL_REG_AS_MONITOR",
+	8:  "CONTROL_REG_AS_BACKUP",
+	9:  "CONTROL_UPDATE_LAST_FLUSH_BLK",
+	10: "CONTROL_DISTRIBUTE_PROPOSAL",
+	11: "CONTROL_RESET_ALL",
+	12: "CONTROL_UPLOAD_REDEEM_DATA",
+	13: "CONTROL_ENTER_AND_REDEEM",
+	14: "CONTROL_REQUEST_RELEASE_IMMEDIATE_AND_VSS_GROUP_CONFIG",
+	15: "CONTROL_ENABLE_RNG",
+	16: "CONTROL_VSS_GROUP_CONFIG",
+	17: "CONTROL_DISTRIBUTE_PROPOSAL_AND_VSS_GROUP_CONFIG",
+}
+
+var ControlStatus_value = map[string]int32{
+	"CONTROL_NONE":                                           0,
+	"CONTROL_REG_OPEN":                                       1,
+	"CONTROL_REG_CLOSE":                                      2,
+	"CONTROL_CREATE_PROPOSAL":                                3,
+	"CONTROL_DISPUTE_PROPOSAL":                               4,
+	"CONTROL_APPROVE_PROPOSAL":                               5,
+	"CONTROL_REG_ADD":                                        6,
+	"CONTROL_REG_AS_MONITOR":                                 7,
+	"CONTROL_REG_AS_BACKUP":                                  8,
+	"CONTROL_UPDATE_LAST_FLUSH_BLK":                          9,
+	"CONTROL_DISTRIBUTE_PROPOSAL":                            10,
+	"CONTROL_RESET_ALL":                                      11,
+	"CONTROL_UPLOAD_REDEEM_DATA":                             12,
+	"CONTROL_ENTER_AND_REDEEM":                               13,
+	"CONTROL_REQUEST_RELEASE_IMMEDIATE_AND_VSS_GROUP_CONFIG": 14,
+	"CONTROL_ENABLE_RNG":                                     15,
+	"CONTROL_VSS_GROUP_CONFIG":                               16,
+	"CONTROL_DISTRIBUTE_PROPOSAL_AND_VSS_GROUP_CONFIG":       17,
+}
+
+func (x ControlStatus) String() string {
+	return proto.EnumName(ControlStatus_name, int32(x))
+}
+
+func (ControlStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{1}
+}
+
+type BroadcastStatus int32
+
+const (
+	BroadcastStatus_BROADCAST_NEW_BLOCK          BroadcastStatus = 0
+	BroadcastStatus_BROADCAST_SYNC_REQUEST       BroadcastStatus = 1
+	BroadcastStatus_BROADCAST_SYNC_COMPLETE      BroadcastStatus = 2
+	BroadcastStatus_BROADCAST_RNG_SHARES         BroadcastStatus = 3
+	BroadcastStatus_BROADCAST_SIG_SHARES         BroadcastStatus = 4
+	BroadcastStatus_BROADCAST_REQUEST_SIG_SHARES BroadcastStatus = 5
+	BroadcastStatus_BROADCAST_NEW_PROPOSAL       BroadcastStatus = 6
+)
+
+var BroadcastStatus_name = map[int32]string{
+	0: "BROADCAST_NEW_BLOCK",
+	1: "BROADCAST_SYNC_REQUEST",
+	2: "BROADCAST_SYNC_COMPLETE",
+	3: "BROADCAST_RNG_SHARES",
+	4: "BROADCAST_SIG_SHARES",
+	5: "BROADCAST_REQUEST_SIG_SHARES",
+	6: "BROADCAST_NEW_PROPOSAL",
+}
+
+var BroadcastStatus_value = map[string]int32{
+	"BROADCAST_NEW_BLOCK":          0,
+	"BROADCAST_SYNC_REQUEST":       1,
+	"BROADCAST_SYNC_COMPLETE":      2,
+	"BROADCAST_RNG_SHARES":         3,
+	"BROADCAST_SIG_SHARES":         4,
+	"BROADCAST_REQUEST_SIG_SHARES": 5,
+	"BROADCAST_NEW_PROPOSAL":       6,
+}
+
+func (x BroadcastStatus) String() string {
+	return proto.EnumName(BroadcastStatus_name, int32(x))
+}
+
+func (BroadcastStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{2}
+}
+
+type ScsDirectCall struct {
+	Msghash              []byte   `protobuf:"bytes,1,opt,name=msghash,proto3" json:"msghash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScsDirectCall) Reset()         { *m = ScsDirectCall{} }
+func (m *ScsDirectCall) String() string { return proto.CompactTextString(m) }
+func (*ScsDirectCall) ProtoMessage()    {}
+func (*ScsDirectCall) Descriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{0}
+}
+
+func (m *ScsDirectCall) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScsDirectCall.Unmarshal(m, b)
+}
+func (m *ScsDirectCall) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScsDirectCall.Marshal(b, m, deterministic)
+}
+func (m *ScsDirectCall) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScsDirectCall.Merge(m, src)
+}
+func (m *ScsDirectCall) XXX_Size() int {
+	return xxx_messageInfo_ScsDirectCall.Size(m)
+}
+func (m *ScsDirectCall) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScsDirectCall.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScsDirectCall proto.InternalMessageInfo
+
+func (m *ScsDirectCall) GetMsghash() []byte {
+	if m != nil {
+		return m.Msghash
+	}
+	return nil
+}
+
+type ScsBroadcast struct {
+	Status               BroadcastStatus `protobuf:"varint,1,opt,name=status,proto3,enum=moac.BroadcastStatus" json:"status,omitempty"`
+	Msghash              []byte          `protobuf:"bytes,2,opt,name=msghash,proto3" json:"msghash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *ScsBroadcast) Reset()         { *m = ScsBroadcast{} }
+func (m *ScsBroadcast) String() string { return proto.CompactTextString(m) }
+func (*ScsBroadcast) ProtoMessage()    {}
+func (*ScsBroadcast) Descriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{1}
+}
+
+func (m *ScsBroadcast) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScsBroadcast.Unmarshal(m, b)
+}
+func (m *ScsBroadcast) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScsBroadcast.Marshal(b, m, deterministic)
+}
+func (m *ScsBroadcast) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScsBroadcast.Merge(m, src)
+}
+func (m *ScsBroadcast) XXX_Size() int {
+	return xxx_messageInfo_ScsBroadcast.Size(m)
+}
+func (m *ScsBroadcast) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScsBroadcast.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScsBroadcast proto.InternalMessageInfo
+
+func (m *ScsBroadcast) GetStatus() BroadcastStatus {
+	if m != nil {
+		return m.Status
+	}
+	return BroadcastStatus_BROADCAST_NEW_BLOCK
+}
+
+func (m *ScsBroadcast) GetMsghash() []byte {
+	if m != nil {
+		return m.Msghash
+	}
+	return nil
+}
+
+type ScsControl struct {
+	Status               ControlStatus `protobuf:"varint,1,opt,name=status,proto3,enum=moac.ControlStatus" json:"status,omitempty"`
+	Msghash              []byte        `protobuf:"bytes,2,opt,name=msghash,proto3" json:"msghash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ScsControl) Reset()         { *m = ScsControl{} }
+func (m *ScsControl) String() string { return proto.CompactTextString(m) }
+func (*ScsControl) ProtoMessage()    {}
+func (*ScsControl) Descriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{2}
+}
+
+func (m *ScsControl) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScsControl.Unmarshal(m, b)
+}
+func (m *ScsControl) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScsControl.Marshal(b, m, deterministic)
+}
+func (m *ScsControl) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScsControl.Merge(m, src)
+}
+func (m *ScsControl) XXX_Size() int {
+	return xxx_messageInfo_ScsControl.Size(m)
+}
+func (m *ScsControl) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScsControl.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScsControl proto.InternalMessageInfo
+
+func (m *ScsControl) GetStatus() ControlStatus {
+	if m != nil {
+		return m.Status
+	}
+	return ControlStatus_CONTROL_NONE
+}
+
+func (m *ScsControl) GetMsghash() []byte {
+	if m != nil {
+		return m.Msghash
+	}
+	return nil
+}
+
+type ScsShakeHand struct {
+	Capability           uint32   `protobuf:"varint,1,opt,name=capability,proto3" json:"capability,omitempty"`
+	Msghash              []byte   `protobuf:"bytes,2,opt,name=msghash,proto3" json:"msghash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScsShakeHand) Reset()         { *m = ScsShakeHand{} }
+func (m *ScsShakeHand) String() string { return proto.CompactTextString(m) }
+func (*ScsShakeHand) ProtoMessage()    {}
+func (*ScsShakeHand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{3}
+}
+
+func (m *ScsShakeHand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScsShakeHand.Unmarshal(m, b)
+}
+func (m *ScsShakeHand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScsShakeHand.Marshal(b, m, deterministic)
+}
+func (m *ScsShakeHand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScsShakeHand.Merge(m, src)
+}
+func (m *ScsShakeHand) XXX_Size() int {
+	return xxx_messageInfo_ScsShakeHand.Size(m)
+}
+func (m *ScsShakeHand) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScsShakeHand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScsShakeHand proto.InternalMessageInfo
+
+func (m *ScsShakeHand) GetCapability() uint32 {
+	if m != nil {
+		return m.Capability
+	}
+	return 0
+}
+
+func (m *ScsShakeHand) GetMsghash() []byte {
+	if m != nil {
+		return m.Msghash
+	}
+	return nil
+}
+
+type ScsPing struct {
+	Msghash              []byte   `protobuf:"bytes,1,opt,name=msghash,proto3" json:"msghash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScsPing) Reset()         { *m = ScsPing{} }
+func (m *ScsPing) String() string { return proto.CompactTextString(m) }
+func (*ScsPing) ProtoMessage()    {}
+func (*ScsPing) Descriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{4}
+}
+
+func (m *ScsPing) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScsPing.Unmarshal(m, b)
+}
+func (m *ScsPing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScsPing.Marshal(b, m, deterministic)
+}
+func (m *ScsPing) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScsPing.Merge(m, src)
+}
+func (m *ScsPing) XXX_Size() int {
+	return xxx_messageInfo_ScsPing.Size(m)
+}
+func (m *ScsPing) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScsPing.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScsPing proto.InternalMessageInfo
+
+func (m *ScsPing) GetMsghash() []byte {
+	if m != nil {
+		return m.Msghash
+	}
+	return nil
+}
+
+type ScsPushMsgV2 struct {
+	Requestid   []byte     `protobuf:"bytes,1,opt,name=requestid,proto3" json:"requestid,omitempty"`
+	Timestamp   int64      `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Requestflag bool       `protobuf:"varint,3,opt,name=requestflag,proto3" json:"requestflag,omitempty"`
+	Type        ScsMsgType `protobuf:"varint,4,opt,name=type,proto3,enum=moac.ScsMsgType" json:"type,omitempty"`
+	Scsid       []byte     `protobuf:"bytes,5,opt,name=scsid,proto3" json:"scsid,omitempty"`
+	Subchainid  []byte     `protobuf:"bytes,6,opt,name=subchainid,proto3" json:"subchainid,omitempty"`
+	Sender      []byte     `protobuf:"bytes,7,opt,name=sender,proto3" json:"sender,omitempty"`
+	Receiver    []byte     `protobuf:"bytes,8,opt,name=receiver,proto3" json:"receiver,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//	*ScsPushMsgV2_DirectCall
+	//	*ScsPushMsgV2_Broadcast
+	//	*ScsPushMsgV2_Control
+	//	*ScsPushMsgV2_ShakeHand
+	//	*ScsPushMsgV2_Ping
+	Payload              isScsPushMsgV2_Payload `protobuf_oneof:"payload"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *ScsPushMsgV2) Reset()         { *m = ScsPushMsgV2{} }
+func (m *ScsPushMsgV2) String() string { return proto.CompactTextString(m) }
+func (*ScsPushMsgV2) ProtoMessage()    {}
+func (*ScsPushMsgV2) Descriptor() ([]byte, []int) {
+	return fileDescriptor_26ad65d6cf5987ed, []int{5}
+}
+
+func (m *ScsPushMsgV2) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScsPushMsgV2.Unmarshal(m, b)
+}
+func (m *ScsPushMsgV2) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScsPushMsgV2.Marshal(b, m, deterministic)
+}
+func (m *ScsPushMsgV2) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScsPushMsgV2.Merge(m, src)
+}
+func (m *ScsPushMsgV2) XXX_Size() int {
+	return xxx_messageInfo_ScsPushMsgV2.Size(m)
+}
+func (m *ScsPushMsgV2) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScsPushMsgV2.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScsPushMsgV2 proto.InternalMessageInfo
+
+func (m *ScsPushMsgV2) GetRequestid() []byte {
+	if m != nil {
+		return m.Requestid
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *ScsPushMsgV2) GetRequestflag() bool {
+	if m != nil {
+		return m.Requestflag
+	}
+	return false
+}
+
+func (m *ScsPushMsgV2) GetType() ScsMsgType {
+	if m != nil {
+		return m.Type
+	}
+	return ScsMsgType_SCS_MSG_UNSPECIFIED
+}
+
+func (m *ScsPushMsgV2) GetScsid() []byte {
+	if m != nil {
+		return m.Scsid
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetSubchainid() []byte {
+	if m != nil {
+		return m.Subchainid
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetSender() []byte {
+	if m != nil {
+		return m.Sender
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetReceiver() []byte {
+	if m != nil {
+		return m.Receiver
+	}
+	return nil
+}
+
+type isScsPushMsgV2_Payload interface {
+	isScsPushMsgV2_Payload()
+}
+
+type ScsPushMsgV2_DirectCall struct {
+	DirectCall *ScsDirectCall `protobuf:"bytes,9,opt,name=direct_call,json=directCall,proto3,oneof"`
+}
+
+type ScsPushMsgV2_Broadcast struct {
+	Broadcast *ScsBroadcast `protobuf:"bytes,10,opt,name=broadcast,proto3,oneof"`
+}
+
+type ScsPushMsgV2_Control struct {
+	Control *ScsControl `protobuf:"bytes,11,opt,name=control,proto3,oneof"`
+}
+
+type ScsPushMsgV2_ShakeHand struct {
+	ShakeHand *ScsShakeHand `protobuf:"bytes,12,opt,name=shake_hand,json=shakeHand,proto3,oneof"`
+}
+
+type ScsPushMsgV2_Ping struct {
+	Ping *ScsPing `protobuf:"bytes,13,opt,name=ping,proto3,oneof"`
+}
+
+func (*ScsPushMsgV2_DirectCall) isScsPushMsgV2_Payload() {}
+
+func (*ScsPushMsgV2_Broadcast) isScsPushMsgV2_Payload() {}
+
+func (*ScsPushMsgV2_Control) isScsPushMsgV2_Payload() {}
+
+func (*ScsPushMsgV2_ShakeHand) isScsPushMsgV2_Payload() {}
+
+func (*ScsPushMsgV2_Ping) isScsPushMsgV2_Payload() {}
+
+func (m *ScsPushMsgV2) GetPayload() isScsPushMsgV2_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetDirectCall() *ScsDirectCall {
+	if x, ok := m.GetPayload().(*ScsPushMsgV2_DirectCall); ok {
+		return x.DirectCall
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetBroadcast() *ScsBroadcast {
+	if x, ok := m.GetPayload().(*ScsPushMsgV2_Broadcast); ok {
+		return x.Broadcast
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetControl() *ScsControl {
+	if x, ok := m.GetPayload().(*ScsPushMsgV2_Control); ok {
+		return x.Control
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetShakeHand() *ScsShakeHand {
+	if x, ok := m.GetPayload().(*ScsPushMsgV2_ShakeHand); ok {
+		return x.ShakeHand
+	}
+	return nil
+}
+
+func (m *ScsPushMsgV2) GetPing() *ScsPing {
+	if x, ok := m.GetPayload().(*ScsPushMsgV2_Ping); ok {
+		return x.Ping
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*ScsPushMsgV2) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*ScsPushMsgV2_DirectCall)(nil),
+		(*ScsPushMsgV2_Broadcast)(nil),
+		(*ScsPushMsgV2_Control)(nil),
+		(*ScsPushMsgV2_ShakeHand)(nil),
+		(*ScsPushMsgV2_Ping)(nil),
+	}
+}
+
+func init() {
+	proto.RegisterEnum("moac.ScsMsgType", ScsMsgType_name, ScsMsgType_value)
+	proto.RegisterEnum("moac.ControlStatus", ControlStatus_name, ControlStatus_value)
+	proto.RegisterEnum("moac.BroadcastStatus", BroadcastStatus_name, BroadcastStatus_value)
+	proto.RegisterType((*ScsDirectCall)(nil), "moac.ScsDirectCall")
+	proto.RegisterType((*ScsBroadcast)(nil), "moac.ScsBroadcast")
+	proto.RegisterType((*ScsControl)(nil), "moac.ScsControl")
+	proto.RegisterType((*ScsShakeHand)(nil), "moac.ScsShakeHand")
+	proto.RegisterType((*ScsPing)(nil), "moac.ScsPing")
+	proto.RegisterType((*ScsPushMsgV2)(nil), "moac.ScsPushMsgV2")
+}
+
+func init() {
+	proto.RegisterFile("scs_push_v2.proto", fileDescriptor_26ad65d6cf5987ed)
+}
+
+var fileDescriptor_26ad65d6cf5987ed = []byte{
+	// 925 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xdd, 0x6e, 0xdb, 0x36,
+	0x18, 0xb5, 0x1a, 0xc7, 0x4e, 0x3e, 0xc7, 0x0d, 0xc3, 0xfc, 0x54, 0x4b, 0xb3, 0x2e, 0x4b, 0x77,
+	0x91, 0x65, 0x5b, 0x50, 0xb8, 0x43, 0x07, 0xec, 0x4e, 0x3f, 0x8c, 0x2d, 0x44, 0x96, 0x34, 0x52,
+	0x76, 0xb1, 0x2b, 0x42, 0x91, 0x34, 0x5b, 0x98, 0x63, 0x7b, 0xa6, 0x5c, 0x20, 0xef, 0xb1, 0x47,
+	0xd9, 0xab, 0xec, 0x69, 0x76, 0x33, 0x50, 0xb6, 0x7e, 0xec, 0x15, 0xc1, 0x2e, 0x79, 0xce, 0xe1,
+	0xf9, 0x3e, 0xf2, 0x3b, 0xa2, 0xe0, 0x48, 0x84, 0x82, 0xcf, 0x97, 0x62, 0xcc, 0x3f, 0x75, 0x6e,
+	0xe7, 0x8b, 0x59, 0x3a, 0xc3, 0xf5, 0xc7, 0x59, 0x10, 0x5e, 0x7d, 0x0b, 0x6d, 0x16, 0x0a, 0x33,
+	0x59, 0xc4, 0x61, 0x6a, 0x04, 0x93, 0x09, 0x56, 0xa1, 0xf9, 0x28, 0x46, 0xe3, 0x40, 0x8c, 0x55,
+	0xe5, 0x52, 0xb9, 0x3e, 0xa0, 0xf9, 0xf2, 0xea, 0x23, 0x1c, 0xb0, 0x50, 0xe8, 0x8b, 0x59, 0x10,
+	0x85, 0x81, 0x48, 0xf1, 0x0f, 0xd0, 0x10, 0x69, 0x90, 0x2e, 0x45, 0x26, 0x7c, 0xd9, 0x39, 0xbd,
+	0x95, 0x8e, 0xb7, 0x85, 0x80, 0x65, 0x24, 0x5d, 0x8b, 0xaa, 0xc6, 0x2f, 0x36, 0x8d, 0x19, 0x00,
+	0x0b, 0x85, 0x31, 0x9b, 0xa6, 0x8b, 0xd9, 0x04, 0x7f, 0xb7, 0x65, 0x7b, 0xbc, 0xb2, 0x5d, 0xd3,
+	0xff, 0xdb, 0xb4, 0x97, 0x75, 0xcb, 0xc6, 0xc1, 0xef, 0x71, 0x2f, 0x98, 0x46, 0xf8, 0x0d, 0x40,
+	0x18, 0xcc, 0x83, 0x87, 0x64, 0x92, 0xa4, 0x4f, 0x99, 0x75, 0x9b, 0x56, 0x90, 0x67, 0x9c, 0xde,
+	0x42, 0x93, 0x85, 0xc2, 0x4b, 0xa6, 0xa3, 0x67, 0x2e, 0xe7, 0x9f, 0x9d, 0xac, 0x9e, 0xb7, 0x14,
+	0xe3, 0xbe, 0x18, 0x0d, 0x3b, 0xf8, 0x02, 0xf6, 0x17, 0xf1, 0x1f, 0xcb, 0x58, 0xa4, 0x49, 0xb4,
+	0x16, 0x97, 0x80, 0x64, 0xd3, 0xe4, 0x31, 0x16, 0x69, 0xf0, 0x38, 0xcf, 0xea, 0xed, 0xd0, 0x12,
+	0xc0, 0x97, 0xd0, 0x5a, 0x4b, 0x7f, 0x9b, 0x04, 0x23, 0x75, 0xe7, 0x52, 0xb9, 0xde, 0xa3, 0x55,
+	0x08, 0x7f, 0x03, 0xf5, 0xf4, 0x69, 0x1e, 0xab, 0xf5, 0xec, 0x8a, 0xd0, 0xea, 0x8a, 0x58, 0x28,
+	0xfa, 0x62, 0xe4, 0x3f, 0xcd, 0x63, 0x9a, 0xb1, 0xf8, 0x04, 0x76, 0x45, 0x28, 0x92, 0x48, 0xdd,
+	0xcd, 0xea, 0xaf, 0x16, 0xf2, 0x26, 0xc4, 0xf2, 0x21, 0x1c, 0x07, 0xc9, 0x34, 0x89, 0xd4, 0x46,
+	0x46, 0x55, 0x10, 0x7c, 0x06, 0x0d, 0x11, 0x4f, 0xa3, 0x78, 0xa1, 0x36, 0x33, 0x6e, 0xbd, 0xc2,
+	0xe7, 0xb0, 0xb7, 0x88, 0xc3, 0x38, 0xf9, 0x14, 0x2f, 0xd4, 0xbd, 0x8c, 0x29, 0xd6, 0xf8, 0x03,
+	0xb4, 0xa2, 0x2c, 0x43, 0x3c, 0x0c, 0x26, 0x13, 0x75, 0xff, 0x52, 0xb9, 0x6e, 0xe5, 0x93, 0xdb,
+	0xc8, 0x57, 0xaf, 0x46, 0x21, 0x2a, 0x56, 0xb8, 0x03, 0xfb, 0x0f, 0x79, 0x5e, 0x54, 0xc8, 0x76,
+	0xe1, 0x62, 0x57, 0x91, 0xa4, 0x5e, 0x8d, 0x96, 0x32, 0xfc, 0x3d, 0x34, 0xc3, 0x55, 0x18, 0xd4,
+	0x56, 0xb6, 0xa3, 0x3c, 0xfe, 0x3a, 0x24, 0xbd, 0x1a, 0xcd, 0x25, 0xf8, 0x3d, 0x80, 0x90, 0x21,
+	0xe0, 0xe3, 0x60, 0x1a, 0xa9, 0x07, 0x5b, 0x25, 0x8a, 0x7c, 0xc8, 0x12, 0x22, 0x5f, 0xe0, 0xb7,
+	0x50, 0x9f, 0x27, 0xd3, 0x91, 0xda, 0xce, 0xe4, 0xed, 0x42, 0x2e, 0x43, 0xd0, 0xab, 0xd1, 0x8c,
+	0xd4, 0xf7, 0xa1, 0x39, 0x0f, 0x9e, 0x26, 0xb3, 0x20, 0xba, 0xf9, 0x53, 0x01, 0x28, 0x6f, 0x1f,
+	0xbf, 0x82, 0x63, 0x66, 0x30, 0xde, 0x67, 0x5d, 0x3e, 0x70, 0x98, 0x47, 0x0c, 0xeb, 0xce, 0x22,
+	0x26, 0xaa, 0x55, 0x09, 0xd3, 0xa2, 0xc4, 0xf0, 0xb9, 0xa1, 0xd9, 0x36, 0x52, 0xf0, 0x29, 0x1c,
+	0xe5, 0x84, 0x4e, 0x5d, 0xcd, 0x34, 0x34, 0xe6, 0xa3, 0x17, 0xf8, 0x18, 0x0e, 0x73, 0xd8, 0x70,
+	0x1d, 0x9f, 0xba, 0x36, 0xda, 0xc1, 0x67, 0x80, 0x73, 0x90, 0xf5, 0xb4, 0x7b, 0xc2, 0x7b, 0x9a,
+	0x63, 0xa2, 0x3a, 0x46, 0x70, 0x90, 0xe3, 0x9e, 0xe5, 0x74, 0xd1, 0xee, 0xcd, 0x5f, 0x75, 0x68,
+	0x6f, 0x7c, 0x37, 0x52, 0xb3, 0x36, 0xe2, 0x8e, 0xeb, 0x10, 0x54, 0xc3, 0x27, 0x80, 0x72, 0x84,
+	0x92, 0x2e, 0x77, 0x3d, 0xe2, 0xac, 0xfa, 0xa9, 0xa2, 0x86, 0xed, 0x32, 0x82, 0x5e, 0xe0, 0xd7,
+	0xf0, 0x2a, 0x87, 0x0d, 0x4a, 0x34, 0x9f, 0x70, 0x8f, 0xba, 0x9e, 0xcb, 0x34, 0xd9, 0xd7, 0x05,
+	0xa8, 0x39, 0x69, 0x5a, 0xcc, 0x1b, 0x54, 0xd9, 0x7a, 0x95, 0xd5, 0x3c, 0x8f, 0xba, 0xc3, 0x0a,
+	0xbb, 0x2b, 0x0f, 0x5a, 0xad, 0xa7, 0x99, 0x26, 0x6a, 0xe0, 0x73, 0x38, 0xdb, 0x00, 0x19, 0xef,
+	0xbb, 0x8e, 0xe5, 0xbb, 0x14, 0x35, 0xf1, 0x17, 0x70, 0xba, 0xc5, 0xe9, 0x9a, 0x71, 0x3f, 0xf0,
+	0xd0, 0x1e, 0xfe, 0x1a, 0xbe, 0xcc, 0xa9, 0x81, 0x67, 0xca, 0x26, 0x6d, 0x8d, 0xf9, 0xfc, 0xce,
+	0x1e, 0xb0, 0x1e, 0xd7, 0xed, 0x7b, 0xb4, 0x8f, 0xbf, 0x82, 0xd7, 0x95, 0x56, 0x7d, 0x6a, 0xe9,
+	0x1b, 0xdd, 0xc2, 0xe6, 0xf9, 0x19, 0xf1, 0xb9, 0x1c, 0x53, 0x0b, 0xbf, 0x81, 0xf3, 0xd2, 0xda,
+	0x76, 0x35, 0x93, 0x53, 0x62, 0x12, 0xd2, 0xe7, 0xa6, 0xe6, 0x6b, 0xe8, 0xa0, 0x7a, 0x48, 0xe2,
+	0xf8, 0x84, 0x72, 0xcd, 0xc9, 0x25, 0xa8, 0x8d, 0x7f, 0x86, 0x0f, 0xa5, 0xe9, 0x2f, 0x03, 0xc2,
+	0x7c, 0x4e, 0x89, 0x4d, 0x34, 0x46, 0xb8, 0xd5, 0xef, 0x13, 0xd3, 0x92, 0xbd, 0xca, 0x1d, 0x43,
+	0xc6, 0x78, 0x97, 0xba, 0x03, 0x4f, 0x4e, 0xfd, 0xce, 0xea, 0xa2, 0x97, 0x72, 0xe8, 0xa5, 0xb3,
+	0xa6, 0xdb, 0x84, 0x53, 0xa7, 0x8b, 0x0e, 0xab, 0x15, 0xff, 0xb3, 0x0b, 0xe1, 0x1f, 0xe1, 0xdd,
+	0x33, 0xe7, 0xfc, 0x7c, 0xad, 0xa3, 0x9b, 0xbf, 0x15, 0x38, 0xdc, 0x7a, 0xc5, 0x65, 0x72, 0x8b,
+	0x60, 0x72, 0x87, 0x7c, 0xe4, 0xba, 0xed, 0x1a, 0xf7, 0xa8, 0x26, 0x87, 0x54, 0x12, 0xec, 0x57,
+	0xc7, 0xc8, 0xcf, 0x86, 0x14, 0x19, 0x97, 0x2d, 0xce, 0x70, 0xfb, 0x9e, 0x4d, 0x7c, 0x99, 0x25,
+	0x15, 0x4e, 0x4a, 0x92, 0x3a, 0x59, 0x98, 0x29, 0x61, 0x68, 0x67, 0x93, 0x61, 0x56, 0xc1, 0xd4,
+	0xf1, 0x25, 0x5c, 0x54, 0xf6, 0xac, 0xef, 0xb0, 0xa2, 0xd8, 0xdd, 0x6c, 0x47, 0xf6, 0x59, 0x0c,
+	0xb5, 0xd1, 0xd1, 0xa1, 0x39, 0x9c, 0xce, 0xa2, 0x78, 0xd8, 0xc1, 0x3f, 0xad, 0xde, 0xf4, 0xa5,
+	0x18, 0xe3, 0xf2, 0x31, 0x28, 0x1e, 0xef, 0xf3, 0xcf, 0x60, 0x57, 0xb5, 0x6b, 0xe5, 0x9d, 0xa2,
+	0x1f, 0xea, 0xad, 0xfe, 0x2c, 0x08, 0x3d, 0xf9, 0x0b, 0x1d, 0x76, 0x3c, 0xe5, 0xa1, 0x91, 0xfd,
+	0x4d, 0xdf, 0xff, 0x3b, 0x00, 0x35, 0xfe, 0x01, 0x11, 0x62, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// VnodeV2Client is the client API for VnodeV2 service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type VnodeV2Client interface {
+	ScsPush(ctx context.Context, opts ...grpc.CallOption) (VnodeV2_ScsPushClient, error)
+}
+
+type vnodeV2Client struct {
+	cc *grpc.ClientConn
+}
+
+func NewVnodeV2Client(cc *grpc.ClientConn) VnodeV2Client {
+	return &vnodeV2Client{cc}
+}
+
+func (c *vnodeV2Client) ScsPush(ctx context.Context, opts ...grpc.CallOption) (VnodeV2_ScsPushClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_VnodeV2_serviceDesc.Streams[0], "/moac.VnodeV2/ScsPush", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vnodeV2ScsPushClient{stream}
+	return x, nil
+}
+
+type VnodeV2_ScsPushClient interface {
+	Send(*ScsPushMsgV2) error
+	Recv() (*ScsPushMsgV2, error)
+	grpc.ClientStream
+}
+
+type vnodeV2ScsPushClient struct {
+	grpc.ClientStream
+}
+
+func (x *vnodeV2ScsPushClient) Send(m *ScsPushMsgV2) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *vnodeV2ScsPushClient) Recv() (*ScsPushMsgV2, error) {
+	m := new(ScsPushMsgV2)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VnodeV2Server is the server API for VnodeV2 service.
+type VnodeV2Server interface {
+	ScsPush(VnodeV2_ScsPushServer) error
+}
+
+// UnimplementedVnodeV2Server can be embedded to have forward compatible implementations.
+type UnimplementedVnodeV2Server struct {
+}
+
+func (*UnimplementedVnodeV2Server) ScsPush(srv VnodeV2_ScsPushServer) error {
+	return status.Errorf(codes.Unimplemented, "method ScsPush not implemented")
+}
+
+func RegisterVnodeV2Server(s *grpc.Server, srv VnodeV2Server) {
+	s.RegisterService(&_VnodeV2_serviceDesc, srv)
+}
+
+func _VnodeV2_ScsPush_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VnodeV2Server).ScsPush(&vnodeV2ScsPushServer{stream})
+}
+
+type VnodeV2_ScsPushServer interface {
+	Send(*ScsPushMsgV2) error
+	Recv() (*ScsPushMsgV2, error)
+	grpc.ServerStream
+}
+
+type vnodeV2ScsPushServer struct {
+	grpc.ServerStream
+}
+
+func (x *vnodeV2ScsPushServer) Send(m *ScsPushMsgV2) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *vnodeV2ScsPushServer) Recv() (*ScsPushMsgV2, error) {
+	m := new(ScsPushMsgV2)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _VnodeV2_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "moac.VnodeV2",
+	HandlerType: (*VnodeV2Server)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ScsPush",
+			Handler:       _VnodeV2_ScsPush_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "scs_push_v2.proto",
+}
@@ -0,0 +1,101 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package moac
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestUpgradeScsPushMsg(t *testing.T) {
+	for _, test := range []struct {
+		typ, status int
+		want        isScsPushMsgV2_Payload
+	}{
+		{1, -1, &ScsPushMsgV2_DirectCall{&ScsDirectCall{Msghash: []byte("call")}}},
+		{2, 0, &ScsPushMsgV2_Broadcast{&ScsBroadcast{Status: BroadcastStatus_BROADCAST_NEW_BLOCK, Msghash: []byte("call")}}},
+		{2, 6, &ScsPushMsgV2_Broadcast{&ScsBroadcast{Status: BroadcastStatus_BROADCAST_NEW_PROPOSAL, Msghash: []byte("call")}}},
+		{3, 0, &ScsPushMsgV2_Control{&ScsControl{Status: ControlStatus_CONTROL_REG_OPEN, Msghash: []byte("call")}}},
+		{3, 4, &ScsPushMsgV2_Control{&ScsControl{Status: ControlStatus_CONTROL_APPROVE_PROPOSAL, Msghash: []byte("call")}}},
+		{3, -1, &ScsPushMsgV2_Control{&ScsControl{Status: ControlStatus_CONTROL_NONE, Msghash: []byte("call")}}},
+		{4, -1, &ScsPushMsgV2_ShakeHand{&ScsShakeHand{Msghash: []byte("call")}}},
+		{5, -1, &ScsPushMsgV2_Ping{&ScsPing{Msghash: []byte("call")}}},
+	} {
+		legacy := &ScsPushMsg{
+			Requestid:   []byte("7"),
+			Timestamp:   []byte{0, 0, 0, 0, 0x5a, 0, 0, 1},
+			Requestflag: true,
+			Type:        legacyBytes(test.typ),
+			Status:      legacyBytes(test.status),
+			Subchainid:  []byte{1},
+			Msghash:     []byte("call"),
+		}
+		v2, err := UpgradeScsPushMsg(legacy)
+		if err != nil {
+			t.Fatalf("type %d status %d: %v", test.typ, test.status, err)
+		}
+		if int(v2.Type) != test.typ || v2.Timestamp != 0x5a000001 || !proto.Equal(v2, &ScsPushMsgV2{
+			Requestid:   legacy.Requestid,
+			Timestamp:   v2.Timestamp,
+			Requestflag: true,
+			Type:        v2.Type,
+			Subchainid:  legacy.Subchainid,
+			Payload:     test.want,
+		}) {
+			t.Fatalf("type %d status %d: upgrade mismatch: %v", test.typ, test.status, v2)
+		}
+		back, err := v2.Legacy()
+		if err != nil {
+			t.Fatalf("type %d status %d: %v", test.typ, test.status, err)
+		}
+		if !proto.Equal(back, legacy) {
+			t.Fatalf("type %d status %d: round trip mismatch: have %v, want %v", test.typ, test.status, back, legacy)
+		}
+	}
+}
+
+func TestUpgradeScsPushMsgErrors(t *testing.T) {
+	for _, test := range []struct {
+		msg  *ScsPushMsg
+		want error
+	}{
+		{&ScsPushMsg{Type: legacyBytes(9)}, ErrLegacyType},
+		{&ScsPushMsg{Type: []byte{1}}, ErrLegacyEncoding},
+		{&ScsPushMsg{Type: legacyBytes(2), Status: legacyBytes(7)}, ErrLegacyStatus},
+		{&ScsPushMsg{Type: legacyBytes(3), Status: legacyBytes(17)}, ErrLegacyStatus},
+		{&ScsPushMsg{Type: legacyBytes(1), Timestamp: []byte{1}}, ErrLegacyEncoding},
+	} {
+		if _, err := UpgradeScsPushMsg(test.msg); !errors.Is(err, test.want) {
+			t.Errorf("%v: error mismatch: have %v, want %v", test.msg, err, test.want)
+		}
+	}
+	mismatch := &ScsPushMsgV2{Type: ScsMsgType_SCS_MSG_PING, Payload: &ScsPushMsgV2_DirectCall{&ScsDirectCall{}}}
+	if _, err := mismatch.Legacy(); !errors.Is(err, ErrPayloadMismatch) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrPayloadMismatch)
+	}
+}
+
+func TestNegotiatePushVersion(t *testing.T) {
+	if v := NegotiatePushVersion(CapPushV2, CapPushV2|1<<5); v != PushVersion2 {
+		t.Errorf("version mismatch: have %d, want %d", v, PushVersion2)
+	}
+	if v := NegotiatePushVersion(CapPushV2, 0); v != PushVersion1 {
+		t.Errorf("version mismatch: have %d, want %d", v, PushVersion1)
+	}
+}
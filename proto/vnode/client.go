@@ -0,0 +1,151 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/MOACChain/MoacLib/common"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+	"google.golang.org/grpc"
+)
+
+// ErrRequestID is returned when a reply doesn't carry the id of its request.
+var ErrRequestID = errors.New("reply request id mismatch")
+
+// Client calls a vnode on behalf of sender using typed requests and
+// replies.
+type Client struct {
+	vnode  pb.VnodeClient
+	sender common.Address
+	seq    uint32 // atomic
+}
+
+// NewClient returns a client calling the vnode at cc.
+func NewClient(cc *grpc.ClientConn, sender common.Address) *Client {
+	return &Client{vnode: pb.NewVnodeClient(cc), sender: sender}
+}
+
+func (c *Client) nextID() uint32 {
+	return atomic.AddUint32(&c.seq, 1)
+}
+
+func checkID(have, want uint32) error {
+	if have != want {
+		return fmt.Errorf("%w: have %d, want %d", ErrRequestID, have, want)
+	}
+	return nil
+}
+
+// AccountInfo returns the account info of addr.
+func (c *Client) AccountInfo(ctx context.Context, addr common.Address) (*types.AccountInfo, error) {
+	req := NewAccountInfoRequest(c.nextID(), addr)
+	reply, err := c.vnode.AccountInfo(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkID(reply.GetRequestid(), req.Requestid); err != nil {
+		return nil, err
+	}
+	return DecodeAccountInfo(reply.GetReplybody())
+}
+
+// ContractInfo returns the storage of the contract at addr selected by reqs.
+func (c *Client) ContractInfo(ctx context.Context, addr common.Address, reqs ...*pb.StorageRequest) (*types.ContractInfo, error) {
+	req := NewChainInfoRequest(c.nextID(), addr, reqs...)
+	reply, err := c.vnode.ChainInfo(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkID(reply.GetRequestid(), req.Requestid); err != nil {
+		return nil, err
+	}
+	return DecodeContractInfo(reply.GetReplybody())
+}
+
+// RemoteCall calls contract with data and returns its return data.
+func (c *Client) RemoteCall(ctx context.Context, contract common.Address, data []byte) ([]byte, error) {
+	req := NewRemoteCallRequest(c.nextID(), c.sender, contract, data)
+	reply, err := c.vnode.RemoteCall(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkID(reply.GetRequestid(), req.Requestid); err != nil {
+		return nil, err
+	}
+	return reply.GetReplybody(), nil
+}
+
+// ScbPublicCall calls the public function of the subchain base contract
+// with data and returns its return data.
+func (c *Client) ScbPublicCall(ctx context.Context, contract common.Address, data []byte) ([]byte, error) {
+	req := NewScbPublicCallRequest(c.nextID(), c.sender, contract, data)
+	reply, err := c.vnode.ScbPublicCall(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkID(reply.GetRequestid(), req.Requestid); err != nil {
+		return nil, err
+	}
+	return reply.GetReplybody(), nil
+}
+
+// UploadBlock uploads block of subchain produced by the SCS scsid.
+func (c *Client) UploadBlock(ctx context.Context, scsid, subchain common.Address, block *scs.Block) error {
+	req, err := NewUploadBlockRequest(c.nextID(), c.sender, scsid, subchain, block)
+	if err != nil {
+		return err
+	}
+	reply, err := c.vnode.UploadBlock(ctx, req)
+	if err != nil {
+		return err
+	}
+	return checkID(reply.GetRequestid(), req.Requestid)
+}
+
+// DownloadBlock returns block number of subchain, see
+// NewDownloadBlockRequest.
+func (c *Client) DownloadBlock(ctx context.Context, scsid, uploader, subchain common.Address, number uint64, hash common.Hash) (*scs.Block, error) {
+	req := NewDownloadBlockRequest(c.nextID(), c.sender, scsid, uploader, subchain, number, hash)
+	reply, err := c.vnode.DownloadBlock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkID(reply.GetRequestid(), req.Requestid); err != nil {
+		return nil, err
+	}
+	return DecodeBlock(reply.GetReplybody())
+}
+
+// ScsPush opens the push stream of the SCS scsid. The vnode knows the
+// stream by the id sent in the first message, so a handshake message is
+// sent right away.
+func (c *Client) ScsPush(ctx context.Context, scsid common.Address) (pb.Vnode_ScsPushClient, error) {
+	stream, err := c.vnode.ScsPush(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&pb.ScsPushMsg{Scsid: scsid.Bytes(), Sender: c.sender.Bytes()}); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
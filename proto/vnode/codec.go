@@ -0,0 +1,172 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package vnode implements typed helpers for the Vnode/SCS gRPC protocol of
+// package proto, whose request and reply bodies are opaque bytes, together
+// with a client and an in-memory reference VnodeServer.
+//
+// Reply bodies are encoded as follows:
+//
+//	AccountInfo             RLP encoded types.AccountInfo
+//	ChainInfo               JSON encoded types.ContractInfo
+//	RemoteCall, ScbPublicCall  the return data of the call
+//	DownloadBlock, Sync     RLP encoded scs.Block
+//	UploadBlock             empty
+package vnode
+
+import (
+	"encoding/json"
+
+	"github.com/MOACChain/MoacLib/common"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// Storage request types, see StorageRequest.Reqtype.
+const (
+	StorageAll     uint32 = iota // all variables of the contract
+	StorageList                  // an array
+	StorageMapping               // an entry of a mapping
+	StorageStruct                // a structure
+	StorageSingle                // a single slot variable
+	StorageBytes                 // a string or bytes variable
+)
+
+// NewStorageRequest returns a request for the contract variable at key. For
+// StorageList position is the index of the element, or nil for all of them,
+// and for StorageMapping it is the key of the entry. A zero position is index
+// or key 0, not all of them. format describes the fields of structures: '1'
+// single, '2' list, '3' string.
+func NewStorageRequest(reqtype uint32, key common.Hash, position *common.Hash, format []byte) *pb.StorageRequest {
+	req := &pb.StorageRequest{
+		Reqtype:      reqtype,
+		Storagekey:   key.Bytes(),
+		Structformat: format,
+	}
+	if position != nil {
+		req.Position = position.Bytes()
+	}
+	return req
+}
+
+// NewAccountInfoRequest returns a request for the account info of addr.
+func NewAccountInfoRequest(id uint32, addr common.Address) *pb.AccountInfoRequest {
+	return &pb.AccountInfoRequest{Requestid: id, Addr: addr.Bytes()}
+}
+
+// EncodeAccountInfo returns the AccountInfo reply body for info.
+func EncodeAccountInfo(info *types.AccountInfo) ([]byte, error) {
+	return rlp.EncodeToBytes(info)
+}
+
+// DecodeAccountInfo decodes an AccountInfo reply body.
+func DecodeAccountInfo(body []byte) (*types.AccountInfo, error) {
+	info := new(types.AccountInfo)
+	if err := rlp.DecodeBytes(body, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// NewChainInfoRequest returns a request for the storage of the subchain
+// consensus contract at addr selected by reqs.
+func NewChainInfoRequest(id uint32, addr common.Address, reqs ...*pb.StorageRequest) *pb.ChainInfoRequest {
+	return &pb.ChainInfoRequest{Requestid: id, Consensusaddr: addr.Bytes(), Request: reqs}
+}
+
+// EncodeContractInfo returns the ChainInfo reply body for info.
+func EncodeContractInfo(info *types.ContractInfo) ([]byte, error) {
+	return json.Marshal(info)
+}
+
+// DecodeContractInfo decodes a ChainInfo reply body.
+func DecodeContractInfo(body []byte) (*types.ContractInfo, error) {
+	info := new(types.ContractInfo)
+	if err := json.Unmarshal(body, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// NewRemoteCallRequest returns a request calling contract with data.
+func NewRemoteCallRequest(id uint32, sender, contract common.Address, data []byte) *pb.RemoteCallRequest {
+	return &pb.RemoteCallRequest{Requestid: id, Sender: sender.Bytes(), Contractaddr: contract.Bytes(), Data: data}
+}
+
+// NewScbPublicCallRequest returns a request calling the public function of
+// subchain base contract with data.
+func NewScbPublicCallRequest(id uint32, sender, contract common.Address, data []byte) *pb.ScbPublicCallRequest {
+	return &pb.ScbPublicCallRequest{Requestid: id, Sender: sender.Bytes(), Contractaddr: contract.Bytes(), Data: data}
+}
+
+// NewUploadBlockRequest returns a request uploading block of subchain
+// produced by the SCS scsid.
+func NewUploadBlockRequest(id uint32, sender, scsid, subchain common.Address, block *scs.Block) (*pb.UploadBlockRequest, error) {
+	data, err := EncodeBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UploadBlockRequest{
+		Requestid:   id,
+		Sender:      sender.Bytes(),
+		Blocknumber: block.NumberU64(),
+		Blockhash:   block.Hash().Bytes(),
+		Scsid:       scsid.Bytes(),
+		Subchainid:  subchain.Bytes(),
+		Blockdata:   data,
+	}, nil
+}
+
+// NewDownloadBlockRequest returns a request for block number of subchain,
+// which must have the given hash unless it is empty. uploader is the SCS
+// which uploaded it, or empty for any.
+func NewDownloadBlockRequest(id uint32, sender, scsid, uploader, subchain common.Address, number uint64, hash common.Hash) *pb.DownloadBlockRequest {
+	req := &pb.DownloadBlockRequest{
+		Requestid:   id,
+		Sender:      sender.Bytes(),
+		Blocknumber: number,
+		Scsid:       scsid.Bytes(),
+		Subchainid:  subchain.Bytes(),
+	}
+	if hash != (common.Hash{}) {
+		req.Blockhash = hash.Bytes()
+	}
+	if uploader != (common.Address{}) {
+		req.Uploadscsid = uploader.Bytes()
+	}
+	return req
+}
+
+// NewBlockSyncRequest returns a request for block number from the SCS scsid.
+func NewBlockSyncRequest(id uint32, sender, scsid common.Address, number uint32) *pb.BlockSyncRequest {
+	return &pb.BlockSyncRequest{Requestid: id, Sender: sender.Bytes(), Blocknumber: number, Scsid: scsid.Bytes()}
+}
+
+// EncodeBlock returns the DownloadBlock or Sync reply body for block.
+func EncodeBlock(block *scs.Block) ([]byte, error) {
+	return rlp.EncodeToBytes(block)
+}
+
+// DecodeBlock decodes a DownloadBlock or Sync reply body.
+func DecodeBlock(body []byte) (*scs.Block, error) {
+	block := new(scs.Block)
+	if err := rlp.DecodeBytes(body, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
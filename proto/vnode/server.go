@@ -0,0 +1,261 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vnode
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/MOACChain/MoacLib/common"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/state"
+	"github.com/MOACChain/MoacLib/types"
+	"github.com/MOACChain/MoacLib/validation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CallFunc executes a RemoteCall, or an ScbPublicCall if public is set, and
+// returns the return data.
+type CallFunc func(ctx context.Context, sender, contract common.Address, data []byte, public bool) ([]byte, error)
+
+// Server is an in-memory VnodeServer backed by a state.StateDB, meant for
+// integration tests of SCS code. Uploaded subchain blocks are validated and
+// kept in memory.
+type Server struct {
+	// Call executes contract calls. Without it they fail with
+	// codes.Unimplemented.
+	Call CallFunc
+	// OnPush is called with every message received on a push stream but
+	// the handshake. The messages it returns are sent back on the stream.
+	OnPush func(msg *pb.ScsPushMsg) []*pb.ScsPushMsg
+
+	mu      sync.Mutex
+	state   *state.StateDB
+	blocks  map[common.Address]map[uint64]*storedBlock // subchain -> number -> block
+	streams map[common.Address]*pushStream             // scsid -> stream
+}
+
+type storedBlock struct {
+	block    *scs.Block
+	uploader common.Address
+}
+
+type pushStream struct {
+	mu     sync.Mutex // protects Send
+	stream pb.Vnode_ScsPushServer
+}
+
+func (s *pushStream) send(msg *pb.ScsPushMsg) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.Send(msg)
+}
+
+var _ pb.VnodeServer = (*Server)(nil)
+
+// NewServer returns a server answering from db.
+func NewServer(db *state.StateDB) *Server {
+	return &Server{
+		state:   db,
+		blocks:  make(map[common.Address]map[uint64]*storedBlock),
+		streams: make(map[common.Address]*pushStream),
+	}
+}
+
+// WithState calls fn with the state of the server, which isn't used
+// concurrently meanwhile.
+func (s *Server) WithState(fn func(db *state.StateDB)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.state)
+}
+
+// AddBlock stores block of subchain as uploaded by uploader, without
+// validation.
+func (s *Server) AddBlock(subchain, uploader common.Address, block *scs.Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addBlock(subchain, uploader, block)
+}
+
+func (s *Server) addBlock(subchain, uploader common.Address, block *scs.Block) {
+	if s.blocks[subchain] == nil {
+		s.blocks[subchain] = make(map[uint64]*storedBlock)
+	}
+	s.blocks[subchain][block.NumberU64()] = &storedBlock{block: block, uploader: uploader}
+}
+
+// Push sends msg on the push stream of the SCS scsid.
+func (s *Server) Push(scsid common.Address, msg *pb.ScsPushMsg) error {
+	s.mu.Lock()
+	stream := s.streams[scsid]
+	s.mu.Unlock()
+	if stream == nil {
+		return status.Errorf(codes.NotFound, "scs %x not connected", scsid)
+	}
+	return stream.send(msg)
+}
+
+// AccountInfoFromState returns the account info of addr in db.
+func AccountInfoFromState(db *state.StateDB, addr common.Address) *types.AccountInfo {
+	shard, _ := db.GetFlag(addr)
+	creation, wait, _ := db.GetFlushInfo(addr)
+	return &types.AccountInfo{
+		Addr:                addr,
+		Balance:             db.GetBalance(addr),
+		Nonce:               db.GetNonce(addr),
+		CodeHash:            db.GetCodeHash(addr),
+		Shard:               shard,
+		CreationBlockNumber: creation,
+		WaitBlockNumber:     wait,
+	}
+}
+
+// ScsPush registers the stream under the scsid of its first message and
+// hands the following ones to OnPush until the SCS hangs up.
+func (s *Server) ScsPush(stream pb.Vnode_ScsPushServer) error {
+	msg, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	scsid := common.BytesToAddress(msg.GetScsid())
+	ps := &pushStream{stream: stream}
+	s.mu.Lock()
+	s.streams[scsid] = ps
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if s.streams[scsid] == ps {
+			delete(s.streams, scsid)
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if s.OnPush == nil {
+			continue
+		}
+		for _, reply := range s.OnPush(msg) {
+			if err := ps.send(reply); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// AccountInfo implements pb.VnodeServer.
+func (s *Server) AccountInfo(ctx context.Context, req *pb.AccountInfoRequest) (*pb.AccountInfoReply, error) {
+	s.mu.Lock()
+	info := AccountInfoFromState(s.state, common.BytesToAddress(req.GetAddr()))
+	s.mu.Unlock()
+	body, err := EncodeAccountInfo(info)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.AccountInfoReply{Requestid: req.GetRequestid(), Replybody: body}, nil
+}
+
+// ChainInfo implements pb.VnodeServer.
+func (s *Server) ChainInfo(ctx context.Context, req *pb.ChainInfoRequest) (*pb.ChainInfoReply, error) {
+	addr := common.BytesToAddress(req.GetConsensusaddr())
+	s.mu.Lock()
+	body := s.state.DumpContractStorage(addr, req.GetRequest())
+	s.mu.Unlock()
+	if body == nil {
+		return nil, status.Errorf(codes.NotFound, "contract %x not found", addr)
+	}
+	return &pb.ChainInfoReply{Requestid: req.GetRequestid(), Replybody: body}, nil
+}
+
+func (s *Server) call(ctx context.Context, sender, contract []byte, data []byte, public bool) ([]byte, error) {
+	if s.Call == nil {
+		return nil, status.Error(codes.Unimplemented, "contract calls not supported")
+	}
+	return s.Call(ctx, common.BytesToAddress(sender), common.BytesToAddress(contract), data, public)
+}
+
+// RemoteCall implements pb.VnodeServer.
+func (s *Server) RemoteCall(ctx context.Context, req *pb.RemoteCallRequest) (*pb.RemoteCallReply, error) {
+	ret, err := s.call(ctx, req.GetSender(), req.GetContractaddr(), req.GetData(), false)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RemoteCallReply{Requestid: req.GetRequestid(), Replybody: ret}, nil
+}
+
+// ScbPublicCall implements pb.VnodeServer.
+func (s *Server) ScbPublicCall(ctx context.Context, req *pb.ScbPublicCallRequest) (*pb.ScbPublicCallReply, error) {
+	ret, err := s.call(ctx, req.GetSender(), req.GetContractaddr(), req.GetData(), true)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ScbPublicCallReply{Requestid: req.GetRequestid(), Replybody: ret}, nil
+}
+
+// UploadBlock implements pb.VnodeServer. The block is checked with
+// validation.ValidateUploadBlock, against its parent if that is known.
+func (s *Server) UploadBlock(ctx context.Context, req *pb.UploadBlockRequest) (*pb.UploadBlockReply, error) {
+	subchain := common.BytesToAddress(req.GetSubchainid())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var parent *scs.Header
+	if n := req.GetBlocknumber(); n > 0 {
+		if stored := s.blocks[subchain][n-1]; stored != nil {
+			parent = stored.block.Header()
+		}
+	}
+	block, err := validation.ValidateUploadBlock(req, parent)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.addBlock(subchain, common.BytesToAddress(req.GetScsid()), block)
+	return &pb.UploadBlockReply{Requestid: req.GetRequestid()}, nil
+}
+
+// DownloadBlock implements pb.VnodeServer.
+func (s *Server) DownloadBlock(ctx context.Context, req *pb.DownloadBlockRequest) (*pb.DownloadBlockReply, error) {
+	subchain := common.BytesToAddress(req.GetSubchainid())
+	s.mu.Lock()
+	stored := s.blocks[subchain][req.GetBlocknumber()]
+	s.mu.Unlock()
+
+	switch {
+	case stored == nil:
+		return nil, status.Errorf(codes.NotFound, "block %d of subchain %x not found", req.GetBlocknumber(), subchain)
+	case len(req.GetBlockhash()) > 0 && stored.block.Hash() != common.BytesToHash(req.GetBlockhash()):
+		return nil, status.Errorf(codes.NotFound, "block %x of subchain %x not found", req.GetBlockhash(), subchain)
+	case len(req.GetUploadscsid()) > 0 && stored.uploader != common.BytesToAddress(req.GetUploadscsid()):
+		return nil, status.Errorf(codes.NotFound, "block %d of subchain %x not uploaded by %x", req.GetBlocknumber(), subchain, req.GetUploadscsid())
+	}
+	body, err := EncodeBlock(stored.block)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.DownloadBlockReply{Requestid: req.GetRequestid(), Replybody: body}, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vnode
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/mcdb"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/state"
+	"github.com/MOACChain/MoacLib/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var (
+	testSender   = common.HexToAddress("0x01")
+	testContract = common.HexToAddress("0x02")
+	testScs      = common.HexToAddress("0x03")
+	testSubchain = common.HexToAddress("0x04")
+)
+
+func newTestState(t *testing.T) *state.StateDB {
+	db, _ := mcdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetBalance(testContract, big.NewInt(42))
+	statedb.SetNonce(testContract, 7)
+	statedb.SetCode(testContract, []byte{0x60, 0x00})
+	statedb.SetState(testContract, common.Hash{1}, common.Hash{2})
+	root, err := statedb.CommitTo(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb, _ = state.New(root, state.NewDatabase(db))
+	return statedb
+}
+
+// newTestClient serves srv over an in-memory connection.
+func newTestClient(t *testing.T, srv *Server) *Client {
+	l := bufconn.Listen(1 << 16)
+	gs := grpc.NewServer()
+	pb.RegisterVnodeServer(gs, srv)
+	go gs.Serve(l)
+	t.Cleanup(gs.Stop)
+
+	dial := func(string, time.Duration) (net.Conn, error) { return l.Dial() }
+	cc, err := grpc.Dial("bufconn", grpc.WithDialer(dial), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return NewClient(cc, testSender)
+}
+
+func testScsBlock(number int64, parent common.Hash) *scs.Block {
+	return scs.NewBlock(&scs.Header{
+		ParentHash: parent,
+		Number:     big.NewInt(number),
+		Time:       big.NewInt(number * 10),
+		Difficulty: big.NewInt(1),
+		GasLimit:   big.NewInt(9000000),
+		GasUsed:    big.NewInt(0),
+	}, nil, nil, nil)
+}
+
+func TestAccountAndChainInfo(t *testing.T) {
+	client := newTestClient(t, NewServer(newTestState(t)))
+	ctx := context.Background()
+
+	info, err := client.AccountInfo(ctx, testContract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Addr != testContract || info.Balance.Int64() != 42 || info.Nonce != 7 || info.CodeHash == (common.Hash{}) {
+		t.Fatalf("account info mismatch: %+v", info)
+	}
+
+	contract, err := client.ContractInfo(ctx, testContract, NewStorageRequest(StorageAll, common.Hash{}, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract.Balance.Int64() != 42 || !bytes.Equal(contract.Code, []byte{0x60, 0x00}) || len(contract.Storage) != 1 {
+		t.Fatalf("contract info mismatch: %+v", contract)
+	}
+	if _, err := client.ContractInfo(ctx, testSender); status.Code(err) != codes.NotFound {
+		t.Fatalf("error mismatch: have %v", err)
+	}
+}
+
+func TestStorageRequestIndexZero(t *testing.T) {
+	// A list of two elements at slot 1.
+	key := common.Bytes2Hex(common.Hash{1}.Bytes())
+	first := common.KeytoKey(key)
+	second := common.IncreaseHexByOne(first)
+	storage := map[string]string{
+		key:    "0x02",
+		first:  common.Hash{0xaa}.Hex(),
+		second: common.Hash{0xbb}.Hex(),
+	}
+
+	req := NewStorageRequest(StorageList, common.Hash{1}, &common.Hash{}, nil)
+	if len(req.Position) != common.HashLength {
+		t.Fatalf("index 0 dropped: position %x", req.Position)
+	}
+	resp := types.ScreeningStorage(storage, []*pb.StorageRequest{req})
+	if len(resp) != 1 || resp[first] != storage[first] {
+		t.Fatalf("element 0 mismatch: %v", resp)
+	}
+
+	req = NewStorageRequest(StorageList, common.Hash{1}, nil, nil)
+	if req.Position != nil {
+		t.Fatalf("position set without index: %x", req.Position)
+	}
+	if resp := types.ScreeningStorage(storage, []*pb.StorageRequest{req}); len(resp) != 3 {
+		t.Fatalf("whole list mismatch: %v", resp)
+	}
+}
+
+func TestRemoteCall(t *testing.T) {
+	srv := NewServer(newTestState(t))
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	if _, err := client.RemoteCall(ctx, testContract, nil); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("error mismatch: have %v", err)
+	}
+	srv.Call = func(ctx context.Context, sender, contract common.Address, data []byte, public bool) ([]byte, error) {
+		if public {
+			return append([]byte("public:"), data...), nil
+		}
+		return append(sender.Bytes(), data...), nil
+	}
+	if ret, err := client.RemoteCall(ctx, testContract, []byte{1}); err != nil || !bytes.Equal(ret, append(testSender.Bytes(), 1)) {
+		t.Fatalf("remote call: have %x, %v", ret, err)
+	}
+	if ret, err := client.ScbPublicCall(ctx, testContract, []byte("x")); err != nil || string(ret) != "public:x" {
+		t.Fatalf("public call: have %q, %v", ret, err)
+	}
+}
+
+func TestUploadDownloadBlock(t *testing.T) {
+	srv := NewServer(newTestState(t))
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	parent := testScsBlock(1, common.Hash{})
+	srv.AddBlock(testSubchain, testScs, parent)
+	block := testScsBlock(2, parent.Hash())
+	if err := client.UploadBlock(ctx, testScs, testSubchain, block); err != nil {
+		t.Fatal(err)
+	}
+	orphan := testScsBlock(2, common.Hash{1})
+	if err := client.UploadBlock(ctx, testScs, testSubchain, orphan); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("error mismatch: have %v", err)
+	}
+
+	have, err := client.DownloadBlock(ctx, testScs, testScs, testSubchain, 2, block.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.Hash() != block.Hash() {
+		t.Fatalf("block mismatch: have %x, want %x", have.Hash(), block.Hash())
+	}
+	for _, hash := range []common.Hash{{1}, {}} {
+		number := uint64(2)
+		if hash == (common.Hash{}) {
+			number = 3
+		}
+		if _, err := client.DownloadBlock(ctx, testScs, common.Address{}, testSubchain, number, hash); status.Code(err) != codes.NotFound {
+			t.Fatalf("error mismatch: have %v", err)
+		}
+	}
+}
+
+func TestScsPush(t *testing.T) {
+	srv := NewServer(newTestState(t))
+	srv.OnPush = func(msg *pb.ScsPushMsg) []*pb.ScsPushMsg {
+		return []*pb.ScsPushMsg{{Requestid: msg.Requestid, Requestflag: false}}
+	}
+	client := newTestClient(t, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.ScsPush(ctx, testScs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&pb.ScsPushMsg{Requestid: []byte("1"), Requestflag: true}); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := stream.Recv(); err != nil || string(reply.Requestid) != "1" {
+		t.Fatalf("reply mismatch: %v, %v", reply, err)
+	}
+	// Once the handshake arrived, the vnode can push to the SCS.
+	if err := srv.Push(testScs, &pb.ScsPushMsg{Requestid: []byte("2")}); err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := stream.Recv(); err != nil || string(msg.Requestid) != "2" {
+		t.Fatalf("push mismatch: %v, %v", msg, err)
+	}
+	if err := srv.Push(testSender, &pb.ScsPushMsg{}); status.Code(err) != codes.NotFound {
+		t.Fatalf("error mismatch: have %v", err)
+	}
+}
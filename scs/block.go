@@ -28,6 +28,7 @@ import (
 
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/common/hexutil"
+	"github.com/MOACChain/MoacLib/core"
 	"github.com/MOACChain/MoacLib/log"
 	"github.com/MOACChain/MoacLib/rlp"
 )
@@ -122,6 +123,29 @@ func (h *Header) HashNoNonce() common.Hash {
 	})
 }
 
+var _ core.HeaderLike = (*Header)(nil)
+
+// Accessors of the header fields, implementing core.HeaderLike.
+func (h *Header) GetParentHash() common.Hash  { return h.ParentHash }
+func (h *Header) GetCoinbase() common.Address { return h.Coinbase }
+func (h *Header) GetNumber() *big.Int         { return bigOrZero(h.Number) }
+func (h *Header) GetTime() *big.Int           { return bigOrZero(h.Time) }
+func (h *Header) GetRoot() common.Hash        { return h.Root }
+func (h *Header) GetTxHash() common.Hash      { return h.TxHash }
+func (h *Header) GetReceiptHash() common.Hash { return h.ReceiptHash }
+func (h *Header) GetBloom() Bloom             { return h.Bloom }
+func (h *Header) GetGasLimit() *big.Int       { return bigOrZero(h.GasLimit) }
+func (h *Header) GetGasUsed() *big.Int        { return bigOrZero(h.GasUsed) }
+func (h *Header) GetExtra() []byte            { return common.CopyBytes(h.Extra) }
+
+// bigOrZero returns a copy of i, or zero if i is nil.
+func bigOrZero(i *big.Int) *big.Int {
+	if i == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i)
+}
+
 // Body is a simple (mutable, non-safe) TxData container for storing and moving
 // a block's TxData contents (transactions and uncles) together.
 type Body struct {
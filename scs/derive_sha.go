@@ -17,25 +17,13 @@
 package scs
 
 import (
-	"bytes"
-
 	"github.com/MOACChain/MoacLib/common"
-	"github.com/MOACChain/MoacLib/rlp"
-	"github.com/MOACChain/MoacLib/trie"
+	"github.com/MOACChain/MoacLib/core"
 )
 
-type DerivableList interface {
-	Len() int
-	GetRlp(i int) []byte
-}
+type DerivableList = core.DerivableList
 
+// DeriveSha returns the root of the trie holding the list elements.
 func DeriveSha(list DerivableList) common.Hash {
-	keybuf := new(bytes.Buffer)
-	trie := new(trie.Trie)
-	for i := 0; i < list.Len(); i++ {
-		keybuf.Reset()
-		rlp.Encode(keybuf, uint(i))
-		trie.Update(keybuf.Bytes(), list.GetRlp(i))
-	}
-	return trie.Hash()
+	return core.DeriveSha(list)
 }
@@ -24,6 +24,7 @@ import (
 
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/common/hexutil"
+	"github.com/MOACChain/MoacLib/core"
 	"github.com/MOACChain/MoacLib/rlp"
 )
 
@@ -134,6 +135,17 @@ func (r *Receipt) String() string {
 	return fmt.Sprintf("receipt{med=%x cgas=%v bloom=%x logs=%v}", r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs)
 }
 
+var _ core.ReceiptLike = (*Receipt)(nil)
+
+// Accessors of the receipt fields, implementing core.ReceiptLike.
+func (r *Receipt) GetTxHash() common.Hash             { return r.TxHash }
+func (r *Receipt) GetFailed() bool                    { return r.Failed }
+func (r *Receipt) GetCumulativeGasUsed() *big.Int     { return bigOrZero(r.CumulativeGasUsed) }
+func (r *Receipt) GetGasUsed() *big.Int               { return bigOrZero(r.GasUsed) }
+func (r *Receipt) GetContractAddress() common.Address { return r.ContractAddress }
+func (r *Receipt) GetBloom() Bloom                    { return r.Bloom }
+func (r *Receipt) GetLogs() []*core.Log               { return coreLogs(r.Logs) }
+
 // ReceiptForStorage is a wrapper around a Receipt that flattens and parses the
 // entire content of a receipt, as opposed to only the consensus fields originally.
 type ReceiptForStorage Receipt
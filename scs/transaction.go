@@ -27,6 +27,7 @@ import (
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/common/hexutil"
 
+	"github.com/MOACChain/MoacLib/core"
 	"github.com/MOACChain/MoacLib/rlp"
 )
 
@@ -46,7 +47,7 @@ const (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+	ErrInvalidSig = core.ErrInvalidSig
 	errNoSigner   = errors.New("missing signing methods")
 )
 
@@ -55,7 +56,7 @@ var (
 // may change in the future.
 // REturn a signer with chainID info
 func deriveSigner(V *big.Int) Signer {
-	// if V.Sign() != 0 && isProtectedV(V) {
+	// if V.Sign() != 0 && core.IsProtectedV(V) {
 	// 	return NewEIP155Signer(deriveChainId(V))
 	// } else {
 	return NewPanguSigner(deriveChainId(V))
@@ -178,16 +179,7 @@ func (tx *Transaction) ChainId() *big.Int {
 
 // Protected returns whether the transaction is protected from replay protection.
 func (tx *Transaction) Protected() bool {
-	return isProtectedV(tx.TxData.V)
-}
-
-func isProtectedV(V *big.Int) bool {
-	if V.BitLen() <= 8 {
-		v := V.Uint64()
-		return v != 27 && v != 28
-	}
-	// anything not 27 or 28 are considered unprotected
-	return true
+	return core.IsProtectedV(tx.TxData.V)
 }
 
 // DecodeRLP implements rlp.Encoder
@@ -211,8 +203,11 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 	return err
 }
 
+var _ core.TxLike = (*Transaction)(nil)
+
 func (tx *Transaction) Data() []byte             { return common.CopyBytes(tx.TxData.Payload) }
 func (tx *Transaction) Gas() *big.Int            { return new(big.Int).Set(tx.TxData.GasLimit) }
+func (tx *Transaction) GasLimit() *big.Int       { return tx.Gas() }
 func (tx *Transaction) GasPrice() *big.Int       { return new(big.Int).Set(tx.TxData.Price) }
 func (tx *Transaction) Value() *big.Int          { return new(big.Int).Set(tx.TxData.Amount) }
 func (tx *Transaction) Nonce() uint64            { return tx.TxData.AccountNonce }
@@ -522,11 +517,16 @@ type TransactionsByPriceAndNonce struct {
 func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
 	// Initialize a price based heap with the head transactions
 	heads := make(TxByPrice, 0, len(txs))
+	rest := make([]Transactions, 0, len(txs))
 	for _, accTxs := range txs {
 		heads = append(heads, accTxs[0])
-		// Ensure the sender address is from the signer
-		acc, _ := Sender(signer, accTxs[0])
-		txs[acc] = accTxs[1:]
+		rest = append(rest, accTxs[1:])
+	}
+	// Ensure the sender addresses are from the signer, recovering the head
+	// signatures concurrently
+	accs, _ := RecoverSenders(signer, Transactions(heads))
+	for i, acc := range accs {
+		txs[acc] = rest[i]
 	}
 	heap.Init(&heads)
 
@@ -608,12 +608,15 @@ func (m Message) To() *common.Address  { return m.to }
 func (m Message) GasPrice() *big.Int   { return m.price }
 func (m Message) Value() *big.Int      { return m.amount }
 func (m Message) Gas() *big.Int        { return m.gasLimit }
+func (m Message) GasLimit() *big.Int   { return m.gasLimit }
 func (m Message) Nonce() uint64        { return m.nonce }
 func (m Message) Data() []byte         { return m.data }
 func (m Message) CheckNonce() bool     { return m.checkNonce }
 func (m Message) GetSystem() uint64    { return m.system }
 
 // func (m Message) SyncFlag() bool            { return m.syncFlag }
+var _ core.MessageLike = Message{}
+
 func (m Message) AutoFlush() bool           { return m.autoFlush }
 func (m Message) WaitBlockNumber() *big.Int { return m.waitBlockNumber }
 
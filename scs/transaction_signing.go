@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/core"
 	"github.com/MOACChain/MoacLib/crypto"
 	"github.com/MOACChain/MoacLib/log"
 	//"github.com/MOACChain/MoacLib/params"
@@ -123,6 +124,25 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// RecoverSenders derives the senders of all transactions in parallel using a
+// bounded pool of worker goroutines, populating each transaction's sender
+// cache so that subsequent Sender calls with the same signer are free.
+//
+// The returned slice holds the sender of txs[i] at index i. If recovery fails
+// for any transaction, the error of the lowest failing index is returned
+// alongside the senders that could be derived.
+func RecoverSenders(signer Signer, txs Transactions) ([]common.Address, error) {
+	senders, errs := core.RecoverSenders(len(txs), func(i int) (common.Address, error) {
+		return Sender(signer, txs[i])
+	})
+	for i, err := range errs {
+		if err != nil {
+			return senders, fmt.Errorf("tx %d (%x): %v", i, txs[i].Hash(), err)
+		}
+	}
+	return senders, nil
+}
+
 // Signer encapsulates transaction signature handling. Note that this interface is not a
 // stable API and may change at any time to accommodate new protocol rules.
 type Signer interface {
@@ -170,22 +190,8 @@ func (ps PanguSigner) Equal(s2 Signer) bool {
 // Replaced by the SignatureValues
 func (ps PanguSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
 	// R, S, V, err = PanguSigner{}.SignatureValues(tx, sig)
-	if len(sig) != 65 {
-		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
-	}
-	R = new(big.Int).SetBytes(sig[:32])
-	S = new(big.Int).SetBytes(sig[32:64])
-	V = new(big.Int).SetBytes([]byte{sig[64] + 27})
-
-	log.Debugf("[core/types/transaction_signing.go->PANGU signer] chainID: %v\n", ps.chainId)
-	if ps.chainId != nil {
-		// fmt.Printf("sign %v\n", ps.chainId.Sign())
-		if ps.chainId.Sign() != 0 {
-			V = big.NewInt(int64(sig[64] + 35))
-			V.Add(V, ps.chainIdMul)
-		}
-	}
-
+	R, S, V = core.SignatureValues(ps.chainId, sig)
+	log.Debugf("[core/types/transaction_signing.go->PANGU signer] chainID: %v", ps.chainId)
 	return R, S, V, nil
 }
 
@@ -231,60 +237,11 @@ func (ps PanguSigner) Sender(tx *Transaction) (common.Address, error) {
 	V.Sub(V, big8)
 	//Need to make sure the input is 27,
 	//Get the Sender info
-	return recoverPlain(ps.Hash(tx), tx.TxData.R, tx.TxData.S, V, true)
+	return core.RecoverPlain(ps.Hash(tx), tx.TxData.R, tx.TxData.S, V, true)
 }
 
-// New function used in GETH 1.7 and later
-// Return the Address of the transaction Sender
-//
-func recoverPlain(sighash common.Hash, R, S, Vb *big.Int, pangu bool) (common.Address, error) {
-	if Vb.BitLen() > 8 {
-		return common.Address{}, ErrInvalidSig
-	}
-	//Compute the actual V value
-	//For EIP155: v = CHAIN_ID * 2 + 35 or v = CHAIN_ID * 2 + 36
-	//then when computing the hash of a transaction for purposes of signing or recovering,
-	//instead of hashing only the first six elements (i.e. nonce, gasprice, startgas, to, value, data),
-	//hash nine elements, with v replaced by CHAIN_ID, r = 0 and s = 0.
-	//The currently existing signature scheme using v = 27 and v = 28,
-	// remains valid and continues to operate under the same rules as it does now.
-	//v is the `recovery id', a 1 byte value specifying the sign and niteness of the curve point; this
-	//value is in the range of [27; 30], however we declare the upper two possibilities, representing innite values, invalid
-	V := byte(Vb.Uint64() - 27)
-
-	//When validate, V need to be either 27 or 28
-	if !crypto.ValidateSignatureValues(V, R, S, pangu) {
-		return common.Address{}, ErrInvalidSig
-	}
-	// encode the snature in uncompressed format
-	r, s := R.Bytes(), S.Bytes()
-	sig := make([]byte, 65)
-	copy(sig[32-len(r):32], r)
-	copy(sig[64-len(s):64], s)
-	sig[64] = V
-
-	// recover the public key from the snature
-	pub, err := crypto.Ecrecover(sighash[:], sig)
-	if err != nil {
-		return common.Address{}, err
-	}
-	if len(pub) == 0 || pub[0] != 4 {
-		return common.Address{}, errors.New("invalid public key")
-	}
-	var addr common.Address
-	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
-	return addr, nil
-}
 
 // deriveChainId derives the chain id from the given v parameter
 func deriveChainId(v *big.Int) *big.Int {
-	if v.BitLen() <= 64 {
-		v := v.Uint64()
-		if v == 27 || v == 28 {
-			return new(big.Int)
-		}
-		return new(big.Int).SetUint64((v - 35) / 2)
-	}
-	v = new(big.Int).Sub(v, big.NewInt(35))
-	return v.Div(v, big.NewInt(2))
+	return core.DeriveChainId(v)
 }
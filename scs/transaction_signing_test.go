@@ -0,0 +1,65 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package scs
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+func TestRecoverSenders(t *testing.T) {
+	signer := NewPanguSigner(big.NewInt(100))
+	var (
+		txs   Transactions
+		addrs []common.Address
+	)
+	for i := 0; i < 32; i++ {
+		key, _ := crypto.GenerateKey()
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		tx, err := SignTx(NewTransaction(uint64(i), addr, new(big.Int), new(big.Int), new(big.Int), 0, nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+		addrs = append(addrs, addr)
+	}
+	senders, err := RecoverSenders(signer, txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range txs {
+		if senders[i] != addrs[i] {
+			t.Errorf("tx %d: sender mismatch: have %x, want %x", i, senders[i], addrs[i])
+		}
+		sc := tx.from.Load()
+		if sc == nil || sc.(sigCache).from != addrs[i] {
+			t.Errorf("tx %d: sender cache not populated", i)
+		}
+	}
+	// A transaction signed for another subchain must be reported
+	key, _ := crypto.GenerateKey()
+	bad, _ := SignTx(NewTransaction(0, addrs[0], new(big.Int), new(big.Int), new(big.Int), 0, nil), NewPanguSigner(big.NewInt(99)), key)
+	if _, err := RecoverSenders(signer, append(txs, bad)); err == nil {
+		t.Error("expected error for transaction with invalid chain id")
+	}
+	if senders, err := RecoverSenders(signer, nil); err != nil || len(senders) != 0 {
+		t.Errorf("empty input: have %v, %v", senders, err)
+	}
+}
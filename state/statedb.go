@@ -252,6 +252,17 @@ func (self *StateDB) StorageTrie(a common.Address) Trie {
 	return cpy.updateTrie(self.db)
 }
 
+// StorageRoot returns the root of the storage trie of an account including
+// uncommitted changes, without committing them. It is the empty hash for
+// non-existent accounts.
+func (self *StateDB) StorageRoot(a common.Address) common.Hash {
+	tr := self.StorageTrie(a)
+	if tr == nil {
+		return common.Hash{}
+	}
+	return tr.Hash()
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
@@ -17,81 +17,28 @@
 package types
 
 import (
-	"fmt"
 	"math/big"
 
-	"github.com/MOACChain/MoacLib/common/hexutil"
-	"github.com/MOACChain/MoacLib/crypto"
-	"github.com/MOACChain/MoacLib/log"
+	"github.com/MOACChain/MoacLib/core"
 )
 
-type bytesBacked interface {
-	Bytes() []byte
-}
-
 const (
 	// BloomByteLength represents the number of bytes used in a header log bloom.
-	BloomByteLength = 256
+	BloomByteLength = core.BloomByteLength
 
 	// BloomBitLength represents the number of bits used in a header log bloom.
-	BloomBitLength = 8 * BloomByteLength
+	BloomBitLength = core.BloomBitLength
 )
 
-// Bloom represents a 2048 bit bloom filter.
-type Bloom [BloomByteLength]byte
-
-// BytesToBloom converts a byte slice to a bloom filter.
-// It panics if b is not of suitable size.
-func BytesToBloom(b []byte) Bloom {
-	var bloom Bloom
-	bloom.SetBytes(b)
-	return bloom
-}
-
-// SetBytes sets the content of b to the given bytes.
-// It panics if d is not of suitable size.
-func (b *Bloom) SetBytes(d []byte) {
-	if len(b) < len(d) {
-		panic(fmt.Sprintf("bloom bytes too big %d %d", len(b), len(d)))
-	}
-	copy(b[BloomByteLength-len(d):], d)
-}
+// Bloom represents a 2048 bit bloom filter, shared with subchain blocks.
+type Bloom = core.Bloom
 
-// Add adds d to the filter. Future calls of Test(d) will return true.
-func (b *Bloom) Add(d *big.Int) {
-	bin := new(big.Int).SetBytes(b[:])
-	bin.Or(bin, bloom9(d.Bytes()))
-	b.SetBytes(bin.Bytes())
-}
-
-// Big converts b to a big integer.
-func (b Bloom) Big() *big.Int {
-	return new(big.Int).SetBytes(b[:])
-}
-
-func (b Bloom) Bytes() []byte {
-	return b[:]
-}
-
-func (b Bloom) Test(test *big.Int) bool {
-	return BloomLookup(b, test)
-}
-
-func (b Bloom) TestBytes(test []byte) bool {
-	return b.Test(new(big.Int).SetBytes(test))
-
-}
-
-// MarshalText encodes b as a hex string with 0x prefix.
-func (b Bloom) MarshalText() ([]byte, error) {
-	return hexutil.Bytes(b[:]).MarshalText()
-}
-
-// UnmarshalText b as a hex string with 0x prefix.
-func (b *Bloom) UnmarshalText(input []byte) error {
-	log.Info("[core/types/bloom9.go->Bloom.UnmarshalText] input="+string(input))
-	return hexutil.UnmarshalFixedText("Bloom", input, b[:])
-}
+var (
+	BytesToBloom = core.BytesToBloom
+	LogsBloom    = core.LogsBloom
+	Bloom9       = core.Bloom9
+	BloomLookup  = core.BloomLookup
+)
 
 func CreateBloom(receipts Receipts) Bloom {
 	bin := new(big.Int)
@@ -101,38 +48,3 @@ func CreateBloom(receipts Receipts) Bloom {
 
 	return BytesToBloom(bin.Bytes())
 }
-
-func LogsBloom(logs []*Log) *big.Int {
-	bin := new(big.Int)
-	for _, log := range logs {
-		bin.Or(bin, bloom9(log.Address.Bytes()))
-		for _, b := range log.Topics {
-			bin.Or(bin, bloom9(b[:]))
-		}
-	}
-
-	return bin
-}
-
-func bloom9(b []byte) *big.Int {
-	b = crypto.Keccak256(b[:])
-
-	r := new(big.Int)
-
-	for i := 0; i < 6; i += 2 {
-		t := big.NewInt(1)
-		b := (uint(b[i+1]) + (uint(b[i]) << 8)) & 2047
-		r.Or(r, t.Lsh(t, b))
-	}
-
-	return r
-}
-
-var Bloom9 = bloom9
-
-func BloomLookup(bin Bloom, topic bytesBacked) bool {
-	bloom := bin.Big()
-	cmp := bloom9(topic.Bytes()[:])
-
-	return bloom.And(bloom, cmp).Cmp(cmp) == 0
-}
@@ -17,121 +17,13 @@
 package types
 
 import (
-	"fmt"
-	"io"
-
-	"github.com/MOACChain/MoacLib/common"
-	"github.com/MOACChain/MoacLib/common/hexutil"
-	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/core"
 )
 
-//go:generate gencodec -type Log -field-override logMarshaling -out gen_log_json.go
-
-// Log represents a contract log event. These events are generated by the LOG opcode and
-// stored/indexed by the node.
-type Log struct {
-	// Consensus fields:
-	// address of the contract that generated the event
-	Address common.Address `json:"address" gencodec:"required"`
-	// list of topics provided by the contract.
-	Topics []common.Hash `json:"topics" gencodec:"required"`
-	// supplied by the contract, usually ABI-encoded
-	Data []byte `json:"TxData" gencodec:"required"`
-
-	// Derived fields. These fields are filled in by the node
-	// but not secured by consensus.
-	// block in which the transaction was included
-	BlockNumber uint64 `json:"blockNumber"`
-	// hash of the transaction
-	TxHash common.Hash `json:"transactionHash" gencodec:"required"`
-	// index of the transaction in the block
-	TxIndex uint `json:"transactionIndex" gencodec:"required"`
-	// hash of the block in which the transaction was included
-	BlockHash common.Hash `json:"blockHash"`
-	// index of the log in the receipt
-	Index uint `json:"logIndex" gencodec:"required"`
-
-	// The Removed field is true if this log was reverted due to a chain reorganisation.
-	// You must pay attention to this field if you receive logs through a filter query.
-	Removed bool `json:"removed"`
-}
-
-type logMarshaling struct {
-	Data        hexutil.Bytes
-	BlockNumber hexutil.Uint64
-	TxIndex     hexutil.Uint
-	Index       hexutil.Uint
-}
-
-type rlpLog struct {
-	Address common.Address
-	Topics  []common.Hash
-	Data    []byte
-}
-
-type rlpStorageLog struct {
-	Address     common.Address
-	Topics      []common.Hash
-	Data        []byte
-	BlockNumber uint64
-	TxHash      common.Hash
-	TxIndex     uint
-	BlockHash   common.Hash
-	Index       uint
-}
-
-// EncodeRLP implements rlp.Encoder.
-func (l *Log) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, rlpLog{Address: l.Address, Topics: l.Topics, Data: l.Data})
-}
-
-// DecodeRLP implements rlp.Decoder.
-func (l *Log) DecodeRLP(s *rlp.Stream) error {
-	var dec rlpLog
-	err := s.Decode(&dec)
-	if err == nil {
-		l.Address, l.Topics, l.Data = dec.Address, dec.Topics, dec.Data
-	}
-	return err
-}
-
-func (l *Log) String() string {
-	return fmt.Sprintf(`log: %x %x %x %x %d %x %d`, l.Address, l.Topics, l.Data, l.TxHash, l.TxIndex, l.BlockHash, l.Index)
-}
+// Log represents a contract log event, shared by main chain and subchain
+// receipts.
+type Log = core.Log
 
 // LogForStorage is a wrapper around a Log that flattens and parses the entire content of
 // a log including non-consensus fields.
-type LogForStorage Log
-
-// EncodeRLP implements rlp.Encoder.
-func (l *LogForStorage) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, rlpStorageLog{
-		Address:     l.Address,
-		Topics:      l.Topics,
-		Data:        l.Data,
-		BlockNumber: l.BlockNumber,
-		TxHash:      l.TxHash,
-		TxIndex:     l.TxIndex,
-		BlockHash:   l.BlockHash,
-		Index:       l.Index,
-	})
-}
-
-// DecodeRLP implements rlp.Decoder.
-func (l *LogForStorage) DecodeRLP(s *rlp.Stream) error {
-	var dec rlpStorageLog
-	err := s.Decode(&dec)
-	if err == nil {
-		*l = LogForStorage{
-			Address:     dec.Address,
-			Topics:      dec.Topics,
-			Data:        dec.Data,
-			BlockNumber: dec.BlockNumber,
-			TxHash:      dec.TxHash,
-			TxIndex:     dec.TxIndex,
-			BlockHash:   dec.BlockHash,
-			Index:       dec.Index,
-		}
-	}
-	return err
-}
+type LogForStorage = core.LogForStorage
@@ -17,6 +17,13 @@ type ShakeInfo struct {
 
 func (s *ShakeInfo) GetScsid() string { return s.Scsid }
 
+// PushVersion returns the push stream version to use with the SCS, given
+// the local capabilities. Capability holds the capability bits, such as
+// pb.CapPushV2, announced by the SCS in its handshake.
+func (s *ShakeInfo) PushVersion(local uint32) uint32 {
+	return pb.NegotiatePushVersion(local, s.Capability)
+}
+
 type AccountInfo struct {
 	Addr                common.Address
 	Balance             *big.Int
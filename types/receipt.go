@@ -24,6 +24,9 @@ import (
 
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/common/hexutil"
+	"github.com/MOACChain/MoacLib/core"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/params"
 	"github.com/MOACChain/MoacLib/rlp"
 )
 
@@ -134,6 +137,17 @@ func (r *Receipt) String() string {
 	return fmt.Sprintf("receipt{med=%x cgas=%v bloom=%x logs=%v}", r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs)
 }
 
+var _ core.ReceiptLike = (*Receipt)(nil)
+
+// Accessors of the receipt fields, implementing core.ReceiptLike.
+func (r *Receipt) GetTxHash() common.Hash             { return r.TxHash }
+func (r *Receipt) GetFailed() bool                    { return r.Failed }
+func (r *Receipt) GetCumulativeGasUsed() *big.Int     { return bigOrZero(r.CumulativeGasUsed) }
+func (r *Receipt) GetGasUsed() *big.Int               { return bigOrZero(r.GasUsed) }
+func (r *Receipt) GetContractAddress() common.Address { return r.ContractAddress }
+func (r *Receipt) GetBloom() Bloom                    { return r.Bloom }
+func (r *Receipt) GetLogs() []*Log                    { return r.Logs }
+
 // ReceiptForStorage is a wrapper around a Receipt that flattens and parses the
 // entire content of a receipt, as opposed to only the consensus fields originally.
 type ReceiptForStorage Receipt
@@ -192,3 +206,50 @@ func (r Receipts) GetRlp(i int) []byte {
 	}
 	return bytes
 }
+
+// DeriveFields fills in the fields of the receipts and their logs that are
+// not part of the consensus encoding, from the block the receipts belong to
+// and its transactions: the transaction hash, the contract address of
+// contract creations, the gas used by each transaction, and the position of
+// every log in the block.
+func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, txs Transactions) error {
+	if len(txs) != len(r) {
+		return fmt.Errorf("transaction and receipt count mismatch: %d txs, %d receipts", len(txs), len(r))
+	}
+	signer := MakeSigner(config, new(big.Int).SetUint64(number))
+
+	var (
+		logIndex   uint
+		cumulative = new(big.Int)
+	)
+	for i, receipt := range r {
+		receipt.TxHash = txs[i].Hash()
+
+		// The contract address can be derived from the sender and nonce
+		if txs[i].To() == nil {
+			from, err := Sender(signer, txs[i])
+			if err != nil {
+				return fmt.Errorf("tx %d (%x): %v", i, receipt.TxHash, err)
+			}
+			receipt.ContractAddress = crypto.CreateAddress(from, txs[i].Nonce())
+		} else {
+			receipt.ContractAddress = common.Address{}
+		}
+		// The gas used by the tx is the growth of the cumulative gas used
+		if receipt.CumulativeGasUsed == nil {
+			return fmt.Errorf("receipt %d (%x): missing cumulative gas used", i, receipt.TxHash)
+		}
+		receipt.GasUsed = new(big.Int).Sub(receipt.CumulativeGasUsed, cumulative)
+		cumulative = receipt.CumulativeGasUsed
+
+		for _, log := range receipt.Logs {
+			log.BlockNumber = number
+			log.BlockHash = hash
+			log.TxHash = receipt.TxHash
+			log.TxIndex = uint(i)
+			log.Index = logIndex
+			logIndex++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/params"
+	"github.com/MOACChain/MoacLib/rlp"
+)
+
+func TestDeriveFields(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	signer := MakeSigner(params.TestnetChainConfig, big.NewInt(1))
+
+	var txs Transactions
+	for i, tx := range []*Transaction{
+		NewTransaction(0, to, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil),
+		NewContractCreation(1, big.NewInt(0), big.NewInt(100000), big.NewInt(1), 0, nil, []byte{0x60}),
+		NewTransaction(2, to, big.NewInt(1), big.NewInt(50000), big.NewInt(1), 0, nil, []byte{0x01}),
+	} {
+		signed, err := SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		txs = append(txs, signed)
+	}
+	receipts := Receipts{
+		{CumulativeGasUsed: big.NewInt(21000), Logs: []*Log{}},
+		{CumulativeGasUsed: big.NewInt(74000), Logs: []*Log{{Address: to}, {Address: to}}},
+		{CumulativeGasUsed: big.NewInt(100000), Logs: []*Log{{Address: to}}},
+	}
+	// Only the consensus fields survive the encoding.
+	enc, err := rlp.EncodeToBytes(receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Receipts
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, number := common.HexToHash("0x1234"), uint64(42)
+	if err := dec.DeriveFields(params.TestnetChainConfig, hash, number, txs); err != nil {
+		t.Fatal(err)
+	}
+	wantGas := []int64{21000, 53000, 26000}
+	logIndex := uint(0)
+	for i, r := range dec {
+		if r.TxHash != txs[i].Hash() {
+			t.Errorf("receipt %d: tx hash mismatch: have %x, want %x", i, r.TxHash, txs[i].Hash())
+		}
+		if r.GasUsed.Int64() != wantGas[i] {
+			t.Errorf("receipt %d: gas used mismatch: have %v, want %d", i, r.GasUsed, wantGas[i])
+		}
+		wantContract := common.Address{}
+		if i == 1 {
+			wantContract = crypto.CreateAddress(from, 1)
+		}
+		if r.ContractAddress != wantContract {
+			t.Errorf("receipt %d: contract address mismatch: have %x, want %x", i, r.ContractAddress, wantContract)
+		}
+		for j, log := range r.Logs {
+			if log.BlockNumber != number || log.BlockHash != hash || log.TxHash != txs[i].Hash() || log.TxIndex != uint(i) {
+				t.Errorf("receipt %d log %d: block context mismatch: %v", i, j, log)
+			}
+			if log.Index != logIndex {
+				t.Errorf("receipt %d log %d: index mismatch: have %d, want %d", i, j, log.Index, logIndex)
+			}
+			logIndex++
+		}
+	}
+	// A config without a Pangu block still accepts protected transactions.
+	noPangu := &params.ChainConfig{ChainId: params.TestnetChainConfig.ChainId}
+	if err := dec.DeriveFields(noPangu, hash, number, txs); err != nil {
+		t.Fatalf("config without pangu block: %v", err)
+	}
+	dec[1].CumulativeGasUsed = nil
+	if err := dec.DeriveFields(params.TestnetChainConfig, hash, number, txs); err == nil {
+		t.Error("expected error for missing cumulative gas used")
+	}
+	if err := dec[:2].DeriveFields(params.TestnetChainConfig, hash, number, txs); err == nil {
+		t.Error("expected error for receipt count mismatch")
+	}
+}
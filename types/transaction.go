@@ -26,7 +26,9 @@ import (
 
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/common/hexutil"
+	"github.com/MOACChain/MoacLib/core"
 	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/crypto/sha3"
 	"github.com/MOACChain/MoacLib/log"
 	"github.com/MOACChain/MoacLib/rlp"
 )
@@ -34,18 +36,44 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
-	errNoSigner   = errors.New("missing signing methods")
+	ErrInvalidSig         = core.ErrInvalidSig
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	errNoSigner           = errors.New("missing signing methods")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
 
 	IsClassicBit = 216
 )
 
+// Transaction types. Legacy transactions are encoded as an RLP list, typed
+// transactions as an EIP-2718 envelope: the type byte followed by the RLP
+// encoding of the transaction data.
+const (
+	LegacyTxType = iota
+	AccessListTxType
+	DynamicFeeTxType
+)
+
 // deriveSigner makes a *best* guess about which signer to use.
 // For MOAC pangu release, just use one
 // may change in the future.
 // REturn a signer with chainID info
-func deriveSigner(V *big.Int) Signer {
-	return NewPanguSigner(DeriveChainId(V))
+func deriveSigner(tx *Transaction) Signer {
+	if tx.TxData.Type != LegacyTxType {
+		return NewTypedSigner(tx.TxData.ChainID)
+	}
+	if !tx.Protected() {
+		return HomesteadSigner{}
+	}
+	return NewPanguSigner(DeriveChainId(tx.TxData.Vx()))
+}
+
+// TypedTxData is the data of a typed transaction, either an *AccessListTx
+// or a *DynamicFeeTx.
+type TypedTxData interface {
+	txType() byte
+	toTxdata() txdata
+	fromTxdata(d *txdata)
+	sigHashFields(chainID *big.Int) []interface{}
 }
 
 type ClassicTxdata struct {
@@ -117,6 +145,46 @@ type txdata struct {
 
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
+
+	// Typed transaction fields, not part of the legacy encoding. The fee
+	// cap of dynamic fee transactions is held in Price.
+	Type       uint8      `json:"type"                 rlp:"-"`
+	ChainID    *big.Int   `json:"chainId"              rlp:"-"`
+	GasTipCap  *big.Int   `json:"maxPriorityFeePerGas" rlp:"-"`
+	AccessList AccessList `json:"accessList"           rlp:"-"`
+}
+
+// inner returns the typed transaction data, or nil for legacy and unknown
+// transaction types.
+func (tdata *txdata) inner() TypedTxData {
+	var inner TypedTxData
+	switch tdata.Type {
+	case AccessListTxType:
+		inner = new(AccessListTx)
+	case DynamicFeeTxType:
+		inner = new(DynamicFeeTx)
+	default:
+		return nil
+	}
+	inner.fromTxdata(tdata)
+	return inner
+}
+
+// bigOrZero returns a copy of i, or zero if i is nil.
+func bigOrZero(i *big.Int) *big.Int {
+	if i == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i)
+}
+
+// copyAddressPtr returns a copy of the address, or nil if a is nil.
+func copyAddressPtr(a *common.Address) *common.Address {
+	if a == nil {
+		return nil
+	}
+	cpy := *a
+	return &cpy
 }
 
 // Vx() returns the V value without the isclassic bit
@@ -210,50 +278,138 @@ func newTransaction(nonce uint64, to *common.Address, amount, gasLimit, gasPrice
 	return &Transaction{TxData: d}
 }
 
+// NewTx creates a new typed transaction from an *AccessListTx or a
+// *DynamicFeeTx. The input data is copied.
+func NewTx(inner TypedTxData) *Transaction {
+	return &Transaction{TxData: inner.toTxdata()}
+}
+
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.TxData.Type != LegacyTxType {
+		return new(big.Int).Set(tx.TxData.ChainID)
+	}
 	return DeriveChainId(tx.TxData.Vx())
 }
 
 // Protected returns whether the transaction is protected from replay protection.
+// Typed transactions always are.
 func (tx *Transaction) Protected() bool {
-	return isProtectedV(tx.TxData.Vx())
+	if tx.TxData.Type != LegacyTxType {
+		return true
+	}
+	return core.IsProtectedV(tx.TxData.Vx())
 }
 
-func isProtectedV(V *big.Int) bool {
-	if V.BitLen() <= 8 {
-		v := V.Uint64()
-		return v != 27 && v != 28
+// EncodeRLP implements rlp.Encoder. Typed transactions are encoded as an
+// RLP string holding the envelope.
+func (tx *Transaction) EncodeRLP(w io.Writer) error {
+	if tx.TxData.Type == LegacyTxType {
+		return rlp.Encode(w, &tx.TxData)
+	}
+	enc, err := tx.encodeTyped()
+	if err != nil {
+		return err
 	}
-	// anything not 27 or 28 are considered unprotected
-	return true
+	return rlp.Encode(w, enc)
 }
 
-// DecodeRLP implements rlp.Encoder
-func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.TxData)
+// encodeTyped returns the envelope of a typed transaction.
+func (tx *Transaction) encodeTyped() ([]byte, error) {
+	inner := tx.TxData.inner()
+	if inner == nil {
+		return nil, ErrTxTypeNotSupported
+	}
+	payload, err := rlp.EncodeToBytes(inner)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.TxData.Type}, payload...), nil
 }
 
-// MarshalBinary returns the canonical encoding of the transaction.
+// MarshalBinary returns the canonical encoding of the transaction: the RLP
+// list for legacy transactions and the envelope for typed transactions.
 func (tx *Transaction) MarshalBinary() ([]byte, error) {
-	return rlp.EncodeToBytes(tx.TxData)
+	if tx.TxData.Type == LegacyTxType {
+		return rlp.EncodeToBytes(tx.TxData)
+	}
+	return tx.encodeTyped()
+}
+
+// UnmarshalBinary decodes the canonical encoding of a transaction.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		// It's a legacy transaction.
+		var data txdata
+		if err := rlp.DecodeBytes(b, &data); err != nil {
+			return err
+		}
+		*tx = Transaction{TxData: data}
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	}
+	data, err := decodeTyped(b)
+	if err != nil {
+		return err
+	}
+	*tx = Transaction{TxData: data}
+	tx.size.Store(common.StorageSize(len(b)))
+	return nil
+}
+
+// decodeTyped decodes a typed transaction envelope.
+func decodeTyped(b []byte) (txdata, error) {
+	if len(b) == 0 {
+		return txdata{}, errEmptyTypedTx
+	}
+	var inner TypedTxData
+	switch b[0] {
+	case AccessListTxType:
+		inner = new(AccessListTx)
+	case DynamicFeeTxType:
+		inner = new(DynamicFeeTx)
+	default:
+		return txdata{}, ErrTxTypeNotSupported
+	}
+	if err := rlp.DecodeBytes(b[1:], inner); err != nil {
+		return txdata{}, err
+	}
+	return inner.toTxdata(), nil
 }
 
 // DecodeRLP implements rlp.Decoder
 // Add the check on the data size
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.TxData)
-	if err == nil {
-		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+	kind, size, err := s.Kind()
+	switch {
+	case err != nil:
+		return err
+	case kind == rlp.List:
+		err := s.Decode(&tx.TxData)
+		if err == nil {
+			tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		}
+		return err
+	default:
+		// It's a typed transaction envelope wrapped in an RLP string.
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		data, err := decodeTyped(b)
+		if err == nil {
+			tx.TxData = data
+			tx.size.Store(common.StorageSize(len(b)))
+		}
+		return err
 	}
-
-	return err
 }
 
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
 	hash := tx.Hash()
+	if tx.TxData.Type != LegacyTxType {
+		return tx.marshalTypedJSON(hash)
+	}
 	data := tx.TxData
 	data.Hash = &hash
 	return data.MarshalJSON()
@@ -261,12 +417,17 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON decodes the chain3 RPC transaction format.
 func (tx *Transaction) UnmarshalJSON(input []byte) error {
+	if typed, err := isTypedJSON(input); err != nil {
+		return err
+	} else if typed {
+		return tx.unmarshalTypedJSON(input)
+	}
 	var dec txdata
 	if err := dec.UnmarshalJSON(input); err != nil {
 		return err
 	}
 	var V byte
-	if isProtectedV(dec.Vx()) {
+	if core.IsProtectedV(dec.Vx()) {
 		chainId := DeriveChainId(dec.Vx()).Uint64()
 		V = byte(dec.Vx().Uint64() - 35 - 2*chainId)
 	} else {
@@ -309,9 +470,17 @@ func (tx *Transaction) UnmarshalClassicRLP(encodeTx hexutil.Bytes) error {
 	tx.TxData.SystemContract = 0
 	tx.TxData.ShardingFlag = 0
 
+	// classic transactions are legacy transactions
+	tx.TxData.Type = LegacyTxType
+	tx.TxData.ChainID = nil
+	tx.TxData.GasTipCap = nil
+	tx.TxData.AccessList = nil
+
 	return nil
 }
 
+var _ core.TxLike = (*Transaction)(nil)
+
 func (tx *Transaction) Data() []byte             { return common.CopyBytes(tx.TxData.Payload) }
 func (tx *Transaction) GasLimit() *big.Int       { return new(big.Int).Set(tx.TxData.GasLimit) }
 func (tx *Transaction) GasPrice() *big.Int       { return new(big.Int).Set(tx.TxData.Price) }
@@ -322,6 +491,40 @@ func (tx *Transaction) UpdateNonce(nonce uint64) { tx.TxData.AccountNonce = nonc
 func (tx *Transaction) IsClassic() bool          { return tx.TxData.IsClassic() }
 func (tx *Transaction) Via() *common.Address     { return tx.TxData.Via }
 
+// Type returns the transaction type.
+func (tx *Transaction) Type() uint8 { return tx.TxData.Type }
+
+// AccessList returns the access list of the transaction, nil for legacy
+// transactions.
+func (tx *Transaction) AccessList() AccessList { return tx.TxData.AccessList }
+
+// GasFeeCap returns the fee cap per gas of the transaction, which is the gas
+// price for transactions other than dynamic fee transactions.
+func (tx *Transaction) GasFeeCap() *big.Int { return new(big.Int).Set(tx.TxData.Price) }
+
+// GasTipCap returns the priority fee per gas of the transaction, which is
+// the gas price for transactions other than dynamic fee transactions.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.TxData.Type == DynamicFeeTxType {
+		return new(big.Int).Set(tx.TxData.GasTipCap)
+	}
+	return new(big.Int).Set(tx.TxData.Price)
+}
+
+// EffectiveGasPrice returns the price per gas paid given the base fee of the
+// block, min(GasTipCap + baseFee, GasFeeCap). Without a base fee the fee cap
+// is paid.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if baseFee == nil || tx.TxData.Type != DynamicFeeTxType {
+		return tx.GasFeeCap()
+	}
+	price := new(big.Int).Add(tx.TxData.GasTipCap, baseFee)
+	if price.Cmp(tx.TxData.Price) > 0 {
+		price.Set(tx.TxData.Price)
+	}
+	return price
+}
+
 //functions to pass flag values
 func (tx *Transaction) ShardingFlag() uint64 { return tx.TxData.ShardingFlag }
 func (tx *Transaction) SystemFlag() uint64   { return tx.TxData.SystemContract }
@@ -347,11 +550,27 @@ func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := common.RlpHash(tx)
+	var v common.Hash
+	if tx.TxData.Type == LegacyTxType {
+		v = common.RlpHash(tx)
+	} else {
+		enc, _ := tx.encodeTyped()
+		v = crypto.Keccak256Hash(enc)
+	}
 	tx.hash.Store(v)
 	return v
 }
 
+// prefixedRlpHash writes the prefix into the hasher before rlp-encoding x.
+// It's used for typed transactions.
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	hw.Write([]byte{prefix})
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
+
 // SigHash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (tx *Transaction) SigHash(signer Signer) common.Hash {
@@ -362,6 +581,11 @@ func (tx *Transaction) Size() common.StorageSize {
 	if size := tx.size.Load(); size != nil {
 		return size.(common.StorageSize)
 	}
+	if tx.TxData.Type != LegacyTxType {
+		enc, _ := tx.encodeTyped()
+		tx.size.Store(common.StorageSize(len(enc)))
+		return common.StorageSize(len(enc))
+	}
 	c := writeCounter(0)
 	rlp.Encode(&c, &tx.TxData)
 	tx.size.Store(common.StorageSize(c))
@@ -395,6 +619,7 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 		shardFlag:       tx.TxData.ShardingFlag,
 		via:             tx.TxData.Via,
 		msgHash:         &msgHash,
+		accessList:      tx.TxData.AccessList,
 	}
 
 	var err error
@@ -435,7 +660,7 @@ func (tx *Transaction) GetSender() string {
 	if tx.TxData.V != nil {
 		// make a best guess about the signer and use that to derive
 		// the sender.
-		signer := deriveSigner(tx.TxData.Vx())
+		signer := deriveSigner(tx)
 		if f, err := Sender(signer, tx); err != nil { // derive but don't cache
 			from = "[invalid sender: invalid sig]"
 		} else {
@@ -455,7 +680,7 @@ func (tx *Transaction) String() string {
 	if tx.TxData.V != nil {
 		// make a best guess about the signer and use that to derive
 		// the sender.
-		signer := deriveSigner(tx.TxData.Vx())
+		signer := deriveSigner(tx)
 		if f, err := Sender(signer, tx); err != nil { // derive but don't cache
 			from = "[invalid sender: invalid sig]"
 		} else {
@@ -544,9 +769,11 @@ func (s Transactions) Len() int { return len(s) }
 // Swap swaps the i'th and the j'th element in s
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-// GetRlp implements Rlpable and returns the i'th element of s in rlp
+// GetRlp implements Rlpable and returns the i'th element of s in rlp. Typed
+// transactions are returned as their envelope, which is what DeriveSha puts
+// into the transaction trie.
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -614,11 +841,16 @@ type TransactionsByPriceAndNonce struct {
 func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
 	// Initialize a price based heap with the head transactions
 	heads := make(TxByPrice, 0, len(txs))
+	rest := make([]Transactions, 0, len(txs))
 	for _, accTxs := range txs {
 		heads = append(heads, accTxs[0])
-		// Ensure the sender address is from the signer
-		acc, _ := Sender(signer, accTxs[0])
-		txs[acc] = accTxs[1:]
+		rest = append(rest, accTxs[1:])
+	}
+	// Ensure the sender addresses are from the signer, recovering the head
+	// signatures concurrently
+	accs, _ := RecoverSenders(signer, Transactions(heads))
+	for i, acc := range accs {
+		txs[acc] = rest[i]
 	}
 	heap.Init(&heads)
 
@@ -673,6 +905,7 @@ type Message struct {
 	shardFlag       uint64
 	via             *common.Address
 	msgHash         *common.Hash
+	accessList      AccessList
 }
 
 func NewMessage(
@@ -707,6 +940,8 @@ func NewMessage(
 	}
 }
 
+var _ core.MessageLike = Message{}
+
 func (m Message) AutoFlush() bool           { return m.autoFlush }
 func (m Message) WaitBlockNumber() *big.Int { return m.waitBlockNumber }
 func (m Message) Via() *common.Address      { return m.via }
@@ -721,6 +956,7 @@ func (m Message) Data() []byte              { return m.data }
 func (m Message) CheckNonce() bool          { return m.checkNonce }
 func (m Message) GetSystem() uint64         { return m.system }
 func (m Message) GetMsgHash() *common.Hash  { return m.msgHash }
+func (m Message) AccessList() AccessList    { return m.accessList }
 
 func (m *Message) SetShardingFlag(sharding uint64) {
 	if sharding > 0 {
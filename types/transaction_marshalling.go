@@ -0,0 +1,176 @@
+// Copyright 2017  The MOAC Foundation
+// This file is modified from the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/common/hexutil"
+	"github.com/MOACChain/MoacLib/crypto"
+)
+
+// typedTxJSON is the JSON representation of typed transactions. Legacy
+// transactions keep the txdata format.
+type typedTxJSON struct {
+	Type                 hexutil.Uint64  `json:"type"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+	AccountNonce         *hexutil.Uint64 `json:"nonce"`
+	SystemContract       *hexutil.Uint64 `json:"syscnt"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	GasLimit             *hexutil.Big    `json:"gas"`
+	Recipient            *common.Address `json:"to"`
+	Amount               *hexutil.Big    `json:"value"`
+	Payload              *hexutil.Bytes  `json:"input"`
+	AccessList           *AccessList     `json:"accessList,omitempty"`
+	ShardingFlag         *hexutil.Uint64 `json:"shardingFlag"`
+	Via                  *common.Address `json:"via"`
+	V                    *hexutil.Big    `json:"v"`
+	R                    *hexutil.Big    `json:"r"`
+	S                    *hexutil.Big    `json:"s"`
+
+	// Only used when encoding.
+	Hash *common.Hash `json:"hash,omitempty"`
+}
+
+// isTypedJSON reports whether the JSON transaction carries a non-legacy type.
+func isTypedJSON(input []byte) (bool, error) {
+	var peek struct {
+		Type *hexutil.Uint64 `json:"type"`
+	}
+	if err := json.Unmarshal(input, &peek); err != nil {
+		return false, err
+	}
+	return peek.Type != nil && *peek.Type != LegacyTxType, nil
+}
+
+// marshalTypedJSON encodes a typed transaction with the given hash.
+func (tx *Transaction) marshalTypedJSON(hash common.Hash) ([]byte, error) {
+	var (
+		d   = &tx.TxData
+		enc typedTxJSON
+	)
+	enc.Type = hexutil.Uint64(d.Type)
+	enc.ChainID = (*hexutil.Big)(d.ChainID)
+	enc.AccountNonce = (*hexutil.Uint64)(&d.AccountNonce)
+	enc.SystemContract = (*hexutil.Uint64)(&d.SystemContract)
+	switch d.Type {
+	case AccessListTxType:
+		enc.GasPrice = (*hexutil.Big)(d.Price)
+	case DynamicFeeTxType:
+		enc.MaxPriorityFeePerGas = (*hexutil.Big)(d.GasTipCap)
+		enc.MaxFeePerGas = (*hexutil.Big)(d.Price)
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+	enc.GasLimit = (*hexutil.Big)(d.GasLimit)
+	enc.Recipient = d.Recipient
+	enc.Amount = (*hexutil.Big)(d.Amount)
+	enc.Payload = (*hexutil.Bytes)(&d.Payload)
+	al := d.AccessList
+	if al == nil {
+		al = AccessList{}
+	}
+	enc.AccessList = &al
+	enc.ShardingFlag = (*hexutil.Uint64)(&d.ShardingFlag)
+	enc.Via = d.Via
+	enc.V = (*hexutil.Big)(d.V)
+	enc.R = (*hexutil.Big)(d.R)
+	enc.S = (*hexutil.Big)(d.S)
+	enc.Hash = &hash
+	return json.Marshal(&enc)
+}
+
+// unmarshalTypedJSON decodes a typed transaction.
+func (tx *Transaction) unmarshalTypedJSON(input []byte) error {
+	var dec typedTxJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	d := txdata{Type: uint8(dec.Type)}
+	switch d.Type {
+	case AccessListTxType:
+		if dec.GasPrice == nil {
+			return errors.New("missing required field 'gasPrice' in transaction")
+		}
+		d.Price = (*big.Int)(dec.GasPrice)
+	case DynamicFeeTxType:
+		if dec.MaxPriorityFeePerGas == nil {
+			return errors.New("missing required field 'maxPriorityFeePerGas' in transaction")
+		}
+		d.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
+		if dec.MaxFeePerGas == nil {
+			return errors.New("missing required field 'maxFeePerGas' in transaction")
+		}
+		d.Price = (*big.Int)(dec.MaxFeePerGas)
+	default:
+		return ErrTxTypeNotSupported
+	}
+	if dec.ChainID == nil {
+		return errors.New("missing required field 'chainId' in transaction")
+	}
+	d.ChainID = (*big.Int)(dec.ChainID)
+	if dec.AccountNonce == nil {
+		return errors.New("missing required field 'nonce' in transaction")
+	}
+	d.AccountNonce = uint64(*dec.AccountNonce)
+	if dec.SystemContract != nil {
+		d.SystemContract = uint64(*dec.SystemContract)
+	}
+	if dec.GasLimit == nil {
+		return errors.New("missing required field 'gas' in transaction")
+	}
+	d.GasLimit = (*big.Int)(dec.GasLimit)
+	d.Recipient = dec.Recipient
+	if dec.Amount == nil {
+		return errors.New("missing required field 'value' in transaction")
+	}
+	d.Amount = (*big.Int)(dec.Amount)
+	if dec.Payload == nil {
+		return errors.New("missing required field 'input' in transaction")
+	}
+	d.Payload = *dec.Payload
+	if dec.AccessList != nil {
+		d.AccessList = *dec.AccessList
+	}
+	if dec.ShardingFlag != nil {
+		d.ShardingFlag = uint64(*dec.ShardingFlag)
+	}
+	d.Via = dec.Via
+	if dec.V == nil {
+		return errors.New("missing required field 'v' in transaction")
+	}
+	d.V = (*big.Int)(dec.V)
+	if dec.R == nil {
+		return errors.New("missing required field 'r' in transaction")
+	}
+	d.R = (*big.Int)(dec.R)
+	if dec.S == nil {
+		return errors.New("missing required field 's' in transaction")
+	}
+	d.S = (*big.Int)(dec.S)
+	if d.V.BitLen() > 8 || !crypto.ValidateSignatureValues(byte(d.V.Uint64()), d.R, d.S, false) {
+		return ErrInvalidSig
+	}
+
+	*tx = Transaction{TxData: d}
+	return nil
+}
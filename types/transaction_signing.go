@@ -23,15 +23,19 @@ import (
 	"math/big"
 
 	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/core"
 	"github.com/MOACChain/MoacLib/crypto"
 	"github.com/MOACChain/MoacLib/log"
-	//"github.com/MOACChain/MoacLib/params"
+	"github.com/MOACChain/MoacLib/params"
 )
 
 //Added ErrUnproctedTX to exclude
 var (
 	ErrInvalidChainId = errors.New("invalid chain id for signer")
 	ErrUnproctedTX    = errors.New("unprotected transaction from signer")
+	ErrProtectedTX    = errors.New("replay protected transaction from homestead signer")
+	ErrClassicTx      = errors.New("classic transaction not enabled for signer")
+	ErrNotClassicTx   = errors.New("not a classic transaction")
 
 	errAbstractSigner     = errors.New("abstract signer")
 	abstractSignerAddress = common.HexToAddress("ffffffffffffffffffffffffffffffffffffffff")
@@ -65,12 +69,55 @@ func (c *EthashConfig) String() string {
 }
 
 // MakeSigner returns a Signer based on the given chain config and block number.
-// PANGU 0.8
-// May use other signer if the protocol changes
-// Following interfaces are following GETH 1.8
+// A nil block number selects the signer of the latest block.
+//
+// A *params.ChainConfig picks the signer from its fork blocks. Before the
+// Pangu block only unprotected homestead transactions are valid, from Pangu
+// on the PanguSigner is used, and transactions signed by the ethereum tool
+// chain are accepted from the EnableClassicTx block on only. From the
+// TypedTxBlock on the TypedSigner also accepts typed transactions. A config
+// without a Pangu block is on Pangu from the start. Other configs get a
+// PanguSigner accepting classic transactions.
 func MakeSigner(config ChainConfig, blockNumber *big.Int) Signer {
 	log.Debugf("Make crypto signer with chain id: %d", config.GetChainId())
-	return NewPanguSigner(config.GetChainId())
+	fc, ok := config.(*params.ChainConfig)
+	if !ok {
+		return NewPanguSigner(config.GetChainId())
+	}
+	if fc.PanguBlock != nil && !forkReached(fc.PanguBlock, blockNumber) {
+		return HomesteadSigner{}
+	}
+	classic := forkReached(fc.EnableClassicTx, blockNumber)
+	if forkReached(fc.TypedTxBlock, blockNumber) {
+		return TypedSigner{newPanguSigner(fc.ChainId, classic)}
+	}
+	return newPanguSigner(fc.ChainId, classic)
+}
+
+// forkReached returns whether the fork at block is active at num, a nil num
+// being the latest block.
+func forkReached(block, num *big.Int) bool {
+	if block == nil {
+		return false
+	}
+	return num == nil || block.Cmp(num) <= 0
+}
+
+// LatestSigner returns the most permissive Signer available for the given
+// chain config, accepting every transaction kind the chain may carry. Use
+// it in tooling that has no block number at hand; consensus code should
+// use MakeSigner instead.
+func LatestSigner(config *params.ChainConfig) Signer {
+	return LatestSignerForChainID(config.GetChainId())
+}
+
+// LatestSignerForChainID returns the most permissive Signer available for
+// the given chain id. A nil chain id yields a HomesteadSigner.
+func LatestSignerForChainID(chainID *big.Int) Signer {
+	if chainID == nil {
+		return HomesteadSigner{}
+	}
+	return NewTypedSigner(chainID)
 }
 
 // SignTx signs the transaction using the given signer and private key
@@ -119,6 +166,25 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// RecoverSenders derives the senders of all transactions in parallel using a
+// bounded pool of worker goroutines, populating each transaction's sender
+// cache so that subsequent Sender calls with the same signer are free.
+//
+// The returned slice holds the sender of txs[i] at index i. If recovery fails
+// for any transaction, the error of the lowest failing index is returned
+// alongside the senders that could be derived.
+func RecoverSenders(signer Signer, txs Transactions) ([]common.Address, error) {
+	senders, errs := core.RecoverSenders(len(txs), func(i int) (common.Address, error) {
+		return Sender(signer, txs[i])
+	})
+	for i, err := range errs {
+		if err != nil {
+			return senders, fmt.Errorf("tx %d (%x): %v", i, txs[i].Hash(), err)
+		}
+	}
+	return senders, nil
+}
+
 //Changed the interface to GETH 1.8
 // type Signer interface {
 // 	// Hash returns the rlp encoded hash for signatures
@@ -145,16 +211,184 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
+// isClassicTx reports whether tx is a legacy transaction signed by the
+// ethereum tool chain.
+func isClassicTx(tx *Transaction) bool {
+	return tx.TxData.V != nil && tx.TxData.IsClassic()
+}
+
+// withClassicBit marks V as the signature of a classic transaction if tx is
+// one.
+func withClassicBit(tx *Transaction, V *big.Int) *big.Int {
+	if isClassicTx(tx) {
+		V.SetBit(V, IsClassicBit, 1)
+	}
+	return V
+}
+
+// HomesteadSigner handles unprotected legacy transactions, whose V is 27 or
+// 28 and whose signature does not cover a chain id.
+type HomesteadSigner struct{}
+
+func (hs HomesteadSigner) Equal(s2 Signer) bool {
+	_, ok := s2.(HomesteadSigner)
+	return ok
+}
+
+// SignatureValues returns the R, S, V values of the signature. The
+// signature must be in the [R || S || V] format where V is 0 or 1.
+func (hs HomesteadSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != LegacyTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	R = new(big.Int).SetBytes(sig[:32])
+	S = new(big.Int).SetBytes(sig[32:64])
+	V = withClassicBit(tx, new(big.Int).SetBytes([]byte{sig[64] + 27}))
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender. Classic transactions
+// are hashed over the ethereum fields only.
+func (hs HomesteadSigner) Hash(tx *Transaction) common.Hash {
+	if isClassicTx(tx) {
+		return common.RlpHash([]interface{}{
+			tx.TxData.AccountNonce,
+			tx.TxData.Price,
+			tx.TxData.GasLimit,
+			tx.TxData.Recipient,
+			tx.TxData.Amount,
+			tx.TxData.Payload,
+		})
+	}
+	return common.RlpHash([]interface{}{
+		tx.TxData.AccountNonce,
+		tx.TxData.SystemContract,
+		tx.TxData.Price,
+		tx.TxData.GasLimit,
+		tx.TxData.Recipient,
+		tx.TxData.Amount,
+		tx.TxData.Payload,
+		tx.TxData.ShardingFlag,
+		tx.TxData.Via,
+	})
+}
+
+// Sender returns the sender of an unprotected transaction.
+func (hs HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+	if tx.Protected() {
+		return common.Address{}, ErrProtectedTX
+	}
+	return core.RecoverPlain(hs.Hash(tx), tx.TxData.R, tx.TxData.S, tx.TxData.Vx(), true)
+}
+
+// EIP155Signer handles classic transactions, legacy transactions signed by
+// the ethereum tool chain under the EIP155 replay protection rules. Their
+// signature covers the ethereum fields and the chain id only, so the MOAC
+// fields of a classic transaction are always empty. Unprotected classic
+// transactions are handled as in HomesteadSigner.
+type EIP155Signer struct {
+	chainId, chainIdMul *big.Int
+}
+
+// NewEIP155Signer returns a signer for classic transactions of the given
+// chain.
+func NewEIP155Signer(chainId *big.Int) EIP155Signer {
+	if chainId == nil {
+		chainId = new(big.Int)
+	}
+	return EIP155Signer{
+		chainId:    chainId,
+		chainIdMul: new(big.Int).Mul(chainId, big.NewInt(2)),
+	}
+}
+
+func (es EIP155Signer) Equal(s2 Signer) bool {
+	eip155, ok := s2.(EIP155Signer)
+	return ok && eip155.chainId.Cmp(es.chainId) == 0
+}
+
+// SignatureValues returns the R, S, V values of the signature, marking the
+// transaction as a classic one. The signature must be in the [R || S || V]
+// format where V is 0 or 1.
+func (es EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != LegacyTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	R = new(big.Int).SetBytes(sig[:32])
+	S = new(big.Int).SetBytes(sig[32:64])
+	if es.chainId.Sign() != 0 {
+		V = big.NewInt(int64(sig[64] + 35))
+		V.Add(V, es.chainIdMul)
+	} else {
+		V = new(big.Int).SetBytes([]byte{sig[64] + 27})
+	}
+	V.SetBit(V, IsClassicBit, 1)
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender.
+func (es EIP155Signer) Hash(tx *Transaction) common.Hash {
+	return common.RlpHash([]interface{}{
+		tx.TxData.AccountNonce,
+		tx.TxData.Price,
+		tx.TxData.GasLimit,
+		tx.TxData.Recipient,
+		tx.TxData.Amount,
+		tx.TxData.Payload,
+		es.chainId,
+		uint(0),
+		uint(0),
+	})
+}
+
+// Sender returns the sender of a classic transaction.
+func (es EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+	if !isClassicTx(tx) {
+		return common.Address{}, ErrNotClassicTx
+	}
+	if !tx.Protected() {
+		return HomesteadSigner{}.Sender(tx)
+	}
+	if tx.ChainId().Cmp(es.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	V := new(big.Int).Sub(tx.TxData.Vx(), es.chainIdMul)
+	V.Sub(V, big.NewInt(8))
+	return core.RecoverPlain(es.Hash(tx), tx.TxData.R, tx.TxData.S, V, true)
+}
+
 // EIP155Transaction implements TransactionInterface using the
 // EIP155 rules
 //type EIP155Signer struct {
 type PanguSigner struct {
 	chainId, chainIdMul *big.Int
+
+	// noClassic rejects classic transactions, before the EnableClassicTx
+	// fork.
+	noClassic bool
 }
 
 // func NewEIP155Signer(chainId *big.Int) EIP155Signer {
 //Following the EIP155 rules
 func NewPanguSigner(inchainID *big.Int) PanguSigner {
+	return newPanguSigner(inchainID, true)
+}
+
+// newPanguSigner returns a PanguSigner, accepting classic transactions if
+// classic is set.
+func newPanguSigner(inchainID *big.Int, classic bool) PanguSigner {
 	// set to chain id to 0 if nil
 	if inchainID == nil {
 		inchainID = new(big.Int)
@@ -162,12 +396,13 @@ func NewPanguSigner(inchainID *big.Int) PanguSigner {
 	return PanguSigner{
 		chainId:    inchainID,
 		chainIdMul: new(big.Int).Mul(inchainID, big.NewInt(2)),
+		noClassic:  !classic,
 	}
 }
 
 func (ps PanguSigner) Equal(s2 Signer) bool {
 	pangu, ok := s2.(PanguSigner)
-	return ok && pangu.chainId.Cmp(ps.chainId) == 0
+	return ok && pangu.chainId.Cmp(ps.chainId) == 0 && pangu.noClassic == ps.noClassic
 }
 
 /*
@@ -181,22 +416,11 @@ func (ps PanguSigner) Equal(s2 Signer) bool {
 // Replaced by the SignatureValues
 func (ps PanguSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
 	// R, S, V, err = PanguSigner{}.SignatureValues(tx, sig)
-	if len(sig) != 65 {
-		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	if tx.Type() != LegacyTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
 	}
-	R = new(big.Int).SetBytes(sig[:32])
-	S = new(big.Int).SetBytes(sig[32:64])
-	V = new(big.Int).SetBytes([]byte{sig[64] + 27})
-
+	R, S, V = core.SignatureValues(ps.chainId, sig)
 	log.Debugf("[core/types/transaction_signing.go->PANGU signer] chainID: %v", ps.chainId)
-	if ps.chainId != nil {
-		log.Debugf("sign %v\n", ps.chainId.Sign())
-		if ps.chainId.Sign() != 0 {
-			V = big.NewInt(int64(sig[64] + 35))
-			V.Add(V, ps.chainIdMul)
-		}
-	}
-
 	return R, S, V, nil
 }
 
@@ -244,6 +468,12 @@ func (ps PanguSigner) Hash(tx *Transaction) common.Hash {
  *
  */
 func (ps PanguSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+	if ps.noClassic && isClassicTx(tx) {
+		return common.Address{}, ErrClassicTx
+	}
 	if !tx.Protected() {
 		//Report error if the input signature is not protected
 		return common.Address{}, ErrUnproctedTX
@@ -259,66 +489,79 @@ func (ps PanguSigner) Sender(tx *Transaction) (common.Address, error) {
 	V.Sub(V, big8)
 	//Need to make sure the input is 27,
 	//Get the Sender info
-	return recoverPlain(ps.Hash(tx), tx.TxData.R, tx.TxData.S, V, true)
+	return core.RecoverPlain(ps.Hash(tx), tx.TxData.R, tx.TxData.S, V, true)
 }
 
-// New function used in GETH 1.7 and later
-// Return the Address of the transaction Sender
-//
-func recoverPlain(sighash common.Hash, R, S, Vb *big.Int, pangu bool) (common.Address, error) {
-	if Vb.BitLen() > 8 {
-		return common.Address{}, ErrInvalidSig
-	}
-	//Compute the actual V value
-	//For EIP155: v = CHAIN_ID * 2 + 35 or v = CHAIN_ID * 2 + 36
-	//then when computing the hash of a transaction for purposes of signing or recovering,
-	//instead of hashing only the first six elements (i.e. nonce, gasprice, startgas, to, value, data),
-	//hash nine elements, with v replaced by CHAIN_ID, r = 0 and s = 0.
-	//The currently existing signature scheme using v = 27 and v = 28,
-	// remains valid and continues to operate under the same rules as it does now.
-	//v is the `recovery id', a 1 byte value specifying the sign and niteness of the curve point; this
-	//value is in the range of [27; 30], however we declare the upper two possibilities, representing innite values, invalid
-	V := byte(Vb.Uint64() - 27)
-
-	//When validate, V need to be either 27 or 28
-	if !crypto.ValidateSignatureValues(V, R, S, pangu) {
-		return common.Address{}, ErrInvalidSig
-	}
-	// encode the snature in uncompressed format
-	r, s := R.Bytes(), S.Bytes()
-	sig := make([]byte, 65)
-	copy(sig[32-len(r):32], r)
-	copy(sig[64-len(s):64], s)
-	sig[64] = V
-
-	// recover the public key from the snature
-	pub, err := crypto.Ecrecover(sighash[:], sig)
-	if err != nil {
-		return common.Address{}, err
+// TypedSigner accepts typed transactions as well as the legacy
+// transactions handled by PanguSigner. MakeSigner returns it from the
+// TypedTxBlock of the chain config on. Typed transactions are signed over
+// the type byte followed by the RLP encoding of their fields, and carry a
+// plain 0/1 recovery id in V since the chain id is part of the payload.
+type TypedSigner struct {
+	PanguSigner
+}
+
+// NewTypedSigner returns a signer for legacy and typed transactions of the
+// given chain.
+func NewTypedSigner(chainId *big.Int) TypedSigner {
+	return TypedSigner{NewPanguSigner(chainId)}
+}
+
+func (ts TypedSigner) Equal(s2 Signer) bool {
+	typed, ok := s2.(TypedSigner)
+	return ok && typed.PanguSigner.Equal(ts.PanguSigner)
+}
+
+// Sender returns the sender of the transaction.
+func (ts TypedSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() == LegacyTxType {
+		return ts.PanguSigner.Sender(tx)
 	}
-	if len(pub) == 0 || pub[0] != 4 {
-		return common.Address{}, errors.New("invalid public key")
+	if tx.TxData.inner() == nil {
+		return common.Address{}, ErrTxTypeNotSupported
 	}
-	//Return the address
-	var addr common.Address
-	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
-	return addr, nil
+	if tx.TxData.ChainID.Cmp(ts.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	V := new(big.Int).Add(tx.TxData.V, big.NewInt(27))
+	return core.RecoverPlain(ts.Hash(tx), tx.TxData.R, tx.TxData.S, V, true)
 }
 
+// SignatureValues returns the R, S, V values of the signature. The
+// signature must be in the [R || S || V] format where V is 0 or 1.
+func (ts TypedSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() == LegacyTxType {
+		return ts.PanguSigner.SignatureValues(tx, sig)
+	}
+	if tx.TxData.inner() == nil {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+	if tx.TxData.ChainID.Cmp(ts.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	R = new(big.Int).SetBytes(sig[:32])
+	S = new(big.Int).SetBytes(sig[32:64])
+	V = new(big.Int).SetBytes([]byte{sig[64]})
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender.
+func (ts TypedSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type() == LegacyTxType {
+		return ts.PanguSigner.Hash(tx)
+	}
+	inner := tx.TxData.inner()
+	if inner == nil {
+		return common.Hash{}
+	}
+	return prefixedRlpHash(inner.txType(), inner.sigHashFields(ts.chainId))
+}
+
+
 // DeriveChainId derives the chain id from the given v parameter
 func DeriveChainId(v *big.Int) *big.Int {
-	if v.BitLen() <= 64 {
-		//If v value is a UINT64 number
-		v := v.Uint64()
-		// No chainID is included in the V
-		if v == 27 || v == 28 {
-			//This should not happen in MOAC network
-			return new(big.Int)
-		}
-		//EIP155 compute
-		return new(big.Int).SetUint64((v - 35) / 2)
-	}
-	//If v is really large
-	v = new(big.Int).Sub(v, big.NewInt(35))
-	return v.Div(v, big.NewInt(2))
+	return core.DeriveChainId(v)
 }
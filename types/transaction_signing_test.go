@@ -24,6 +24,7 @@ import (
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/crypto"
 	"github.com/MOACChain/MoacLib/log"
+	"github.com/MOACChain/MoacLib/params"
 	"github.com/MOACChain/MoacLib/rlp"
 )
 
@@ -159,3 +160,199 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+func TestRecoverSenders(t *testing.T) {
+	signer := NewPanguSigner(big.NewInt(18))
+	var (
+		txs   Transactions
+		addrs []common.Address
+	)
+	for i := 0; i < 64; i++ {
+		key, _ := crypto.GenerateKey()
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		tx, err := SignTx(NewTransaction(uint64(i), addr, new(big.Int), new(big.Int), new(big.Int), 0, nil, nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+		addrs = append(addrs, addr)
+	}
+	senders, err := RecoverSenders(signer, txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range txs {
+		if senders[i] != addrs[i] {
+			t.Errorf("tx %d: sender mismatch: have %x, want %x", i, senders[i], addrs[i])
+		}
+		sc := tx.from.Load()
+		if sc == nil || sc.(sigCache).from != addrs[i] {
+			t.Errorf("tx %d: sender cache not populated", i)
+		}
+	}
+	// A transaction signed for another chain must be reported
+	key, _ := crypto.GenerateKey()
+	bad, _ := SignTx(NewTransaction(0, addrs[0], new(big.Int), new(big.Int), new(big.Int), 0, nil, nil), NewPanguSigner(big.NewInt(99)), key)
+	if _, err := RecoverSenders(signer, append(txs, bad)); err == nil {
+		t.Error("expected error for transaction with invalid chain id")
+	}
+}
+
+func TestHomesteadSigning(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	tx, err := SignTx(NewTransaction(0, addr, new(big.Int), new(big.Int), new(big.Int), 0, nil, nil), HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Protected() {
+		t.Fatal("didn't expect tx to be protected")
+	}
+	from, err := Sender(HomesteadSigner{}, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != addr {
+		t.Errorf("expected from and address to be equal. Got %x want %x", from, addr)
+	}
+	if from, err := Sender(deriveSigner(tx), tx); err != nil || from != addr {
+		t.Errorf("derived signer: got %x, %v want %x", from, err, addr)
+	}
+}
+
+func TestEIP155ClassicSigning(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewEIP155Signer(big.NewInt(101))
+	tx, err := SignTx(NewTransaction(0, addr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.IsClassic() || !tx.Protected() {
+		t.Fatalf("expected a protected classic tx")
+	}
+	if tx.ChainId().Cmp(big.NewInt(101)) != 0 {
+		t.Fatalf("chain id mismatch: got %v", tx.ChainId())
+	}
+	from, err := Sender(signer, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != addr {
+		t.Errorf("expected from and address to be equal. Got %x want %x", from, addr)
+	}
+
+	native, err := SignTx(NewTransaction(0, addr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil), NewPanguSigner(big.NewInt(101)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sender(signer, native); err != ErrNotClassicTx {
+		t.Errorf("native tx: got error %v want %v", err, ErrNotClassicTx)
+	}
+}
+
+// testForkConfig enables Pangu at block 10 and classic transactions at
+// block 20.
+var testForkConfig = &params.ChainConfig{
+	ChainId:         big.NewInt(101),
+	PanguBlock:      big.NewInt(10),
+	EnableClassicTx: big.NewInt(20),
+}
+
+func TestMakeSigner(t *testing.T) {
+	chainID := testForkConfig.ChainId
+	for _, test := range []struct {
+		number int64
+		want   Signer
+	}{
+		{0, HomesteadSigner{}},
+		{9, HomesteadSigner{}},
+		{10, newPanguSigner(chainID, false)},
+		{19, newPanguSigner(chainID, false)},
+		{20, NewPanguSigner(chainID)},
+		{1000, NewPanguSigner(chainID)},
+	} {
+		if have := MakeSigner(testForkConfig, big.NewInt(test.number)); !have.Equal(test.want) {
+			t.Errorf("block %d: signer mismatch: have %#v, want %#v", test.number, have, test.want)
+		}
+	}
+	// A nil block number is the latest block.
+	if have, want := MakeSigner(testForkConfig, nil), NewPanguSigner(chainID); !have.Equal(want) {
+		t.Errorf("nil number: signer mismatch: have %#v, want %#v", have, want)
+	}
+	// A config without a Pangu block is on Pangu from the start, classic
+	// transactions are rejected without an EnableClassicTx block.
+	noPangu := &params.ChainConfig{ChainId: chainID}
+	for _, number := range []*big.Int{nil, big.NewInt(0), big.NewInt(1000)} {
+		if have, want := MakeSigner(noPangu, number), newPanguSigner(chainID, false); !have.Equal(want) {
+			t.Errorf("no pangu block, number %v: signer mismatch: have %#v, want %#v", number, have, want)
+		}
+	}
+	// Configs without fork blocks get the Pangu signer, as they always did.
+	if have, want := MakeSigner(chainIDConfig{chainID}, big.NewInt(0)), NewPanguSigner(chainID); !have.Equal(want) {
+		t.Errorf("plain config: signer mismatch: have %#v, want %#v", have, want)
+	}
+	if have, want := LatestSigner(testForkConfig), NewTypedSigner(chainID); !have.Equal(want) {
+		t.Errorf("latest signer mismatch: have %#v, want %#v", have, want)
+	}
+}
+
+type chainIDConfig struct{ id *big.Int }
+
+func (c chainIDConfig) GetChainId() *big.Int { return c.id }
+
+// TestSignerForkCompatibility checks which transactions each fork accepts:
+// unprotected transactions only before Pangu and replay protected ones from
+// Pangu on, and classic ones from EnableClassicTx on.
+func TestSignerForkCompatibility(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	newTx := func() *Transaction {
+		return NewTransaction(0, addr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil)
+	}
+	sign := func(signer Signer) *Transaction {
+		tx, err := SignTx(newTx(), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	var (
+		homestead = sign(HomesteadSigner{})
+		native    = sign(NewPanguSigner(testForkConfig.ChainId))
+		classic   = sign(NewEIP155Signer(testForkConfig.ChainId))
+		foreign   = sign(NewPanguSigner(big.NewInt(99)))
+	)
+	for _, test := range []struct {
+		name   string
+		tx     *Transaction
+		number int64
+		err    error
+	}{
+		{"homestead/homestead", homestead, 5, nil},
+		{"homestead/pangu", homestead, 10, ErrUnproctedTX},
+		{"native/homestead", native, 5, ErrProtectedTX},
+		{"native/pangu", native, 10, nil},
+		{"native/classic", native, 20, nil},
+		{"classic/pangu", classic, 10, ErrClassicTx},
+		{"classic/classic", classic, 20, nil},
+		{"foreign/pangu", foreign, 10, ErrInvalidChainId},
+	} {
+		from, err := MakeSigner(testForkConfig, big.NewInt(test.number)).Sender(test.tx)
+		if err != test.err {
+			t.Errorf("%s: error mismatch: have %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if err == nil && from != addr {
+			t.Errorf("%s: sender mismatch: have %x, want %x", test.name, from, addr)
+		}
+	}
+	latest := LatestSigner(testForkConfig)
+	for name, tx := range map[string]*Transaction{"native": native, "classic": classic} {
+		if from, err := latest.Sender(tx); err != nil || from != addr {
+			t.Errorf("%s/latest: have %x, %v, want %x", name, from, err, addr)
+		}
+	}
+}
@@ -101,6 +101,9 @@ func TestTransactionEncode(t *testing.T) {
 func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	t, err := &tx, rlp.Decode(bytes.NewReader(data), &tx)
+	if err != nil {
+		return t, err
+	}
 
 	fmt.Printf("decoded tx: %s\n", t.String())
 	// fmt.Printf("t.data:%v\n\n", t.data)
@@ -143,11 +146,14 @@ func TestDecodeMoacTx(t *testing.T) {
 	fmt.Printf("TX nonce: %v\n", tx.TxData.AccountNonce)
 	fmt.Printf("TX amount: %v\n", tx.TxData.Amount)
 	fmt.Printf("TX gasLimit: %v\n", tx.TxData.GasLimit)
-	fmt.Printf("TX ShardingFlag: %v\n", tx.GetShardingFlag())
-	fmt.Printf("TX system: %v\n", tx.GetSystemFlag())
+	fmt.Printf("TX ShardingFlag: %v\n", tx.ShardingFlag())
+	fmt.Printf("TX system: %v\n", tx.SystemFlag())
 
 	fmt.Printf("Tx src:%v\n%v\n", tx.GetSender(), addFrom)
 
+	if tx.TxData.Recipient == nil {
+		t.Fatalf("decoded a contract creation, want a call to %s", addTo)
+	}
 	if strings.ToLower(tx.TxData.Recipient.Hex()) != strings.ToLower(addTo) {
 		t.Error("Derived address doesn't match")
 		fmt.Printf("Get:%s, want %s\n", tx.TxData.Recipient.Hex(), addTo)
@@ -175,27 +181,27 @@ func TestControlFlags(t *testing.T) {
 	emptyTx.SetSystemFlag(f1)
 	fmt.Printf("Control Flag %x\n", emptyTx.TxData.ShardingFlag)
 
-	if emptyTx.GetSystemFlag() != f1 {
+	if emptyTx.SystemFlag() != f1 {
 		fmt.Printf("Set system flag Error: %v\n", emptyTx.TxData.ShardingFlag)
 	}
 	emptyTx.SetShardingFlag(f1)
 	fmt.Printf("Control Flag %x\n", emptyTx.TxData.ShardingFlag)
 
-	if emptyTx.GetShardingFlag() != f1 {
+	if emptyTx.ShardingFlag() != f1 {
 		fmt.Printf("Set sharding flag 0 Error: %v\n", emptyTx.TxData.ShardingFlag)
 	}
 	f1 = 0
 	emptyTx.SetSystemFlag(f1)
 	fmt.Printf("Control Flag %x\n", emptyTx.TxData.ShardingFlag)
 
-	if emptyTx.GetSystemFlag() != f1 {
+	if emptyTx.SystemFlag() != f1 {
 		fmt.Printf("Set system flag 0 Error: %v\n", emptyTx.TxData.ShardingFlag)
 	}
 
 	emptyTx.SetShardingFlag(f1)
 	fmt.Printf("Control Flag %x\n", emptyTx.TxData.ShardingFlag)
 
-	if emptyTx.GetShardingFlag() != f1 {
+	if emptyTx.ShardingFlag() != f1 {
 		fmt.Printf("Set sharding flag 0 Error: %v\n", emptyTx.TxData.ShardingFlag)
 	}
 	/*
@@ -236,7 +242,7 @@ func TestRecipientEmpty(t *testing.T) {
 
 func TestRecipientNormal(t *testing.T) {
 	_, addr := defaultTestKey()
-	fmt.Printf("default key:%v\n", addr.GetBase58Str())
+	fmt.Printf("default key:%v\n", addr.Hex())
 	tx, err := decodeTx(common.Hex2Bytes("f86f808084ee6b28008303345094d814f2ac2c4ca49b33066582e4e97ebae02f2ab98901000000000000000000801ca02955c1beabdf8df0bdf8875d971353aa2ab8b9aca2b2f4a7167164b6a19cbf35a07eef6b59f78c80a8ec5883bb33cac30b131e28bd41121443994013814b8cb194"))
 	if err != nil {
 		t.Error(err)
@@ -0,0 +1,251 @@
+// Copyright 2017  The MOAC Foundation
+// This file is modified from the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/params"
+	"github.com/MOACChain/MoacLib/rlp"
+)
+
+var (
+	typedTestChainID = big.NewInt(99)
+	typedTestTo      = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	typedTestVia     = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	typedTestList    = AccessList{{
+		Address:     common.HexToAddress("0x00000000000000000000000000000000000000cc"),
+		StorageKeys: []common.Hash{{0x01}, {0x02}},
+	}}
+)
+
+func typedTestTxs() []*Transaction {
+	return []*Transaction{
+		NewTx(&AccessListTx{
+			ChainID:      typedTestChainID,
+			Nonce:        3,
+			GasPrice:     big.NewInt(20000000000),
+			Gas:          big.NewInt(1000000),
+			To:           &typedTestTo,
+			Value:        big.NewInt(10),
+			Data:         common.FromHex("5544"),
+			AccessList:   typedTestList,
+			ShardingFlag: 1,
+			Via:          &typedTestVia,
+		}),
+		NewTx(&DynamicFeeTx{
+			ChainID:   typedTestChainID,
+			Nonce:     4,
+			GasTipCap: big.NewInt(1000000000),
+			GasFeeCap: big.NewInt(30000000000),
+			Gas:       big.NewInt(1000000),
+			Value:     big.NewInt(0),
+			Data:      common.FromHex("6060"),
+		}),
+	}
+}
+
+func signTypedTestTxs(t *testing.T) ([]*Transaction, common.Address) {
+	key, _ := crypto.GenerateKey()
+	signer := NewTypedSigner(typedTestChainID)
+	txs := typedTestTxs()
+	for i, tx := range txs {
+		signed, err := SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("tx %d: sign failed: %v", i, err)
+		}
+		txs[i] = signed
+	}
+	return txs, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func assertTxEqual(t *testing.T, want, have *Transaction) {
+	t.Helper()
+	if have.Hash() != want.Hash() {
+		t.Fatalf("hash mismatch: have %x, want %x", have.Hash(), want.Hash())
+	}
+	if have.Type() != want.Type() {
+		t.Fatalf("type mismatch: have %d, want %d", have.Type(), want.Type())
+	}
+	if have.ShardingFlag() != want.ShardingFlag() {
+		t.Fatalf("sharding flag mismatch: have %d, want %d", have.ShardingFlag(), want.ShardingFlag())
+	}
+	if (have.Via() == nil) != (want.Via() == nil) || (want.Via() != nil && *have.Via() != *want.Via()) {
+		t.Fatalf("via mismatch: have %v, want %v", have.Via(), want.Via())
+	}
+	if have.AccessList().StorageKeys() != want.AccessList().StorageKeys() {
+		t.Fatalf("access list mismatch: have %v, want %v", have.AccessList(), want.AccessList())
+	}
+	if have.GasTipCap().Cmp(want.GasTipCap()) != 0 || have.GasFeeCap().Cmp(want.GasFeeCap()) != 0 {
+		t.Fatalf("fee mismatch")
+	}
+}
+
+func TestTypedTxSigning(t *testing.T) {
+	txs, addr := signTypedTestTxs(t)
+	signer := NewTypedSigner(typedTestChainID)
+	for i, tx := range txs {
+		from, err := Sender(signer, tx)
+		if err != nil {
+			t.Fatalf("tx %d: sender failed: %v", i, err)
+		}
+		if from != addr {
+			t.Errorf("tx %d: sender mismatch: have %x, want %x", i, from, addr)
+		}
+		if !tx.Protected() || tx.ChainId().Cmp(typedTestChainID) != 0 {
+			t.Errorf("tx %d: chain id mismatch: have %v", i, tx.ChainId())
+		}
+		if _, err := Sender(NewTypedSigner(big.NewInt(1)), tx); err != ErrInvalidChainId {
+			t.Errorf("tx %d: wrong chain error mismatch: have %v, want %v", i, err, ErrInvalidChainId)
+		}
+		if _, err := NewPanguSigner(typedTestChainID).Sender(tx); err != ErrTxTypeNotSupported {
+			t.Errorf("tx %d: pangu signer error mismatch: have %v, want %v", i, err, ErrTxTypeNotSupported)
+		}
+	}
+}
+
+func TestTypedTxFork(t *testing.T) {
+	txs, addr := signTypedTestTxs(t)
+	config := &params.ChainConfig{ChainId: typedTestChainID, PanguBlock: big.NewInt(0), TypedTxBlock: big.NewInt(10)}
+	for i, tx := range txs {
+		if _, err := Sender(MakeSigner(config, big.NewInt(9)), tx); err != ErrTxTypeNotSupported {
+			t.Errorf("tx %d: pre-fork error mismatch: have %v, want %v", i, err, ErrTxTypeNotSupported)
+		}
+		for _, number := range []*big.Int{big.NewInt(10), nil} {
+			if from, err := Sender(MakeSigner(config, number), tx); err != nil || from != addr {
+				t.Errorf("tx %d, block %v: have %x, %v, want %x", i, number, from, err, addr)
+			}
+		}
+	}
+}
+
+func TestTypedSignerLegacy(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := NewTypedSigner(typedTestChainID)
+	tx, err := SignTx(NewTransaction(0, addr, new(big.Int), new(big.Int), new(big.Int), 0, nil, nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash() != common.RlpHash(tx) {
+		t.Fatalf("legacy hash changed")
+	}
+	from, err := NewPanguSigner(typedTestChainID).Sender(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != addr {
+		t.Errorf("sender mismatch: have %x, want %x", from, addr)
+	}
+}
+
+func TestTypedTxBinaryEncoding(t *testing.T) {
+	txs, _ := signTypedTestTxs(t)
+	for i, tx := range txs {
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("tx %d: encode failed: %v", i, err)
+		}
+		if enc[0] != tx.Type() {
+			t.Fatalf("tx %d: envelope type mismatch: have %d, want %d", i, enc[0], tx.Type())
+		}
+		if tx.Hash() != crypto.Keccak256Hash(enc) {
+			t.Fatalf("tx %d: hash is not the keccak of the envelope", i)
+		}
+		if tx.Size() != common.StorageSize(len(enc)) {
+			t.Fatalf("tx %d: size mismatch: have %v, want %d", i, tx.Size(), len(enc))
+		}
+		var dec Transaction
+		if err := dec.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("tx %d: decode failed: %v", i, err)
+		}
+		assertTxEqual(t, tx, &dec)
+	}
+	var tx Transaction
+	if err := tx.UnmarshalBinary([]byte{0x05, 0xc0}); err != ErrTxTypeNotSupported {
+		t.Fatalf("unknown type error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
+
+func TestTypedTxRLPEncoding(t *testing.T) {
+	txs, _ := signTypedTestTxs(t)
+	key, _ := crypto.GenerateKey()
+	legacy, err := SignTx(NewTransaction(0, typedTestTo, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil), NewPanguSigner(typedTestChainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := Transactions{legacy, txs[0], txs[1]}
+
+	enc, err := rlp.EncodeToBytes(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Transactions
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if len(dec) != len(all) {
+		t.Fatalf("length mismatch: have %d, want %d", len(dec), len(all))
+	}
+	for i := range all {
+		assertTxEqual(t, all[i], dec[i])
+	}
+	if DeriveSha(dec) != DeriveSha(all) {
+		t.Fatalf("derived root mismatch")
+	}
+	legacyEnc, _ := rlp.EncodeToBytes(legacy)
+	if !bytes.Equal(all.GetRlp(0), legacyEnc) {
+		t.Fatalf("legacy trie value changed")
+	}
+	typedEnc, _ := txs[0].MarshalBinary()
+	if !bytes.Equal(all.GetRlp(1), typedEnc) {
+		t.Fatalf("typed trie value is not the envelope")
+	}
+}
+
+func TestTypedTxJSON(t *testing.T) {
+	txs, _ := signTypedTestTxs(t)
+	for i, tx := range txs {
+		enc, err := json.Marshal(tx)
+		if err != nil {
+			t.Fatalf("tx %d: encode failed: %v", i, err)
+		}
+		var dec Transaction
+		if err := json.Unmarshal(enc, &dec); err != nil {
+			t.Fatalf("tx %d: decode failed: %v", i, err)
+		}
+		assertTxEqual(t, tx, &dec)
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	txs := typedTestTxs()
+	if have := txs[0].EffectiveGasPrice(big.NewInt(7)); have.Cmp(big.NewInt(20000000000)) != 0 {
+		t.Errorf("access list tx price mismatch: have %v", have)
+	}
+	if have := txs[1].EffectiveGasPrice(big.NewInt(2000000000)); have.Cmp(big.NewInt(3000000000)) != 0 {
+		t.Errorf("dynamic fee tx price mismatch: have %v", have)
+	}
+	if have := txs[1].EffectiveGasPrice(big.NewInt(40000000000)); have.Cmp(big.NewInt(30000000000)) != 0 {
+		t.Errorf("capped dynamic fee tx price mismatch: have %v", have)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2017  The MOAC Foundation
+// This file is modified from the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/MOACChain/MoacLib/common"
+)
+
+// DynamicFeeTx is the data of a dynamic fee transaction, which bids a
+// maximum fee per gas and a priority fee instead of a fixed gas price. It
+// carries the MOAC system contract, sharding flag and via fields of legacy
+// transactions. The field order is the order of the RLP payload.
+type DynamicFeeTx struct {
+	ChainID        *big.Int        // destination chain ID
+	Nonce          uint64          // nonce of sender account
+	SystemContract uint64          // system contract flag
+	GasTipCap      *big.Int        // maxPriorityFeePerGas
+	GasFeeCap      *big.Int        // maxFeePerGas
+	Gas            *big.Int        // gas limit
+	To             *common.Address `rlp:"nil"` // nil means contract creation
+	Value          *big.Int        // wei amount
+	Data           []byte          // contract invocation input data
+	AccessList     AccessList      // EIP-2930 access list
+	ShardingFlag   uint64          // subchain transaction type
+	Via            *common.Address `rlp:"nil"` // vnode proxy of subchain transactions
+	V, R, S        *big.Int        // signature values
+}
+
+func (tx *DynamicFeeTx) txType() byte { return DynamicFeeTxType }
+
+// toTxdata converts the transaction into the common representation, in
+// which the fee cap takes the place of the gas price.
+func (tx *DynamicFeeTx) toTxdata() txdata {
+	return txdata{
+		Type:           DynamicFeeTxType,
+		ChainID:        bigOrZero(tx.ChainID),
+		AccountNonce:   tx.Nonce,
+		SystemContract: tx.SystemContract,
+		Price:          bigOrZero(tx.GasFeeCap),
+		GasTipCap:      bigOrZero(tx.GasTipCap),
+		GasLimit:       bigOrZero(tx.Gas),
+		Recipient:      copyAddressPtr(tx.To),
+		Amount:         bigOrZero(tx.Value),
+		Payload:        common.CopyBytes(tx.Data),
+		AccessList:     tx.AccessList.copy(),
+		ShardingFlag:   tx.ShardingFlag,
+		Via:            copyAddressPtr(tx.Via),
+		V:              bigOrZero(tx.V),
+		R:              bigOrZero(tx.R),
+		S:              bigOrZero(tx.S),
+	}
+}
+
+func (tx *DynamicFeeTx) fromTxdata(d *txdata) {
+	*tx = DynamicFeeTx{
+		ChainID:        d.ChainID,
+		Nonce:          d.AccountNonce,
+		SystemContract: d.SystemContract,
+		GasTipCap:      d.GasTipCap,
+		GasFeeCap:      d.Price,
+		Gas:            d.GasLimit,
+		To:             d.Recipient,
+		Value:          d.Amount,
+		Data:           d.Payload,
+		AccessList:     d.AccessList,
+		ShardingFlag:   d.ShardingFlag,
+		Via:            d.Via,
+		V:              d.V,
+		R:              d.R,
+		S:              d.S,
+	}
+}
+
+// sigHashFields returns the fields covered by the signature, in order.
+func (tx *DynamicFeeTx) sigHashFields(chainID *big.Int) []interface{} {
+	return []interface{}{
+		chainID,
+		tx.Nonce,
+		tx.SystemContract,
+		tx.GasTipCap,
+		tx.GasFeeCap,
+		tx.Gas,
+		tx.To,
+		tx.Value,
+		tx.Data,
+		tx.AccessList,
+		tx.ShardingFlag,
+		tx.Via,
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/core"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/scs"
+)
+
+// ValidateScsHeader checks a subchain header. If parent is not nil the
+// header must also be its child.
+func ValidateScsHeader(header, parent *scs.Header) error {
+	if err := checkFields(header.Number, header.Time, header.GasLimit, header.GasUsed, header.Difficulty); err != nil {
+		return err
+	}
+	if parent == nil {
+		return validateHeader(header, nil)
+	}
+	if err := checkFields(parent.Number, parent.Time, parent.GasLimit, parent.GasUsed, parent.Difficulty); err != nil {
+		return fmt.Errorf("parent: %w", err)
+	}
+	return validateHeader(header, parent)
+}
+
+// ValidateScsBody checks the transaction root and uncle hash of a subchain
+// block against its body.
+func ValidateScsBody(block *scs.Block) error {
+	header := block.Header()
+	if have, want := scs.CalcUncleHash(block.Uncles()), header.UncleHash; have != want {
+		return mismatch(ErrUncleHash, have.Hex(), want.Hex())
+	}
+	if have, want := scs.DeriveSha(block.Transactions()), header.TxHash; have != want {
+		return mismatch(ErrTxRoot, have.Hex(), want.Hex())
+	}
+	return nil
+}
+
+// ValidateScsReceipts checks the receipt root, bloom and gas used of a
+// subchain block against the receipts of its transactions.
+func ValidateScsReceipts(block *scs.Block, receipts scs.Receipts) error {
+	header := block.Header()
+	if have, want := scs.DeriveSha(receipts), header.ReceiptHash; have != want {
+		return mismatch(ErrReceiptRoot, have.Hex(), want.Hex())
+	}
+	if have, want := scs.CreateBloom(receipts), header.Bloom; have != want {
+		return fmt.Errorf("%w: have %x, want %x", ErrBloom, have, want)
+	}
+	list := make([]core.ReceiptLike, len(receipts))
+	for i, r := range receipts {
+		list[i] = r
+	}
+	return validateGasUsed(header.GasUsed, list)
+}
+
+// ValidateScsBlock runs ValidateScsHeader, ValidateScsBody and, if receipts is
+// not nil, ValidateScsReceipts on a subchain block.
+func ValidateScsBlock(block *scs.Block, parent *scs.Header, receipts scs.Receipts) error {
+	if err := ValidateScsHeader(block.Header(), parent); err != nil {
+		return err
+	}
+	if err := ValidateScsBody(block); err != nil {
+		return err
+	}
+	if receipts == nil {
+		return nil
+	}
+	return ValidateScsReceipts(block, receipts)
+}
+
+// ValidateUploadBlock decodes the RLP encoded subchain block uploaded by an
+// SCS, checks it against the block number and hash of the request, and runs
+// ValidateScsHeader and ValidateScsBody on it.
+func ValidateUploadBlock(req *pb.UploadBlockRequest, parent *scs.Header) (*scs.Block, error) {
+	block := new(scs.Block)
+	if err := rlp.DecodeBytes(req.GetBlockdata(), block); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBlockData, err)
+	}
+	header := block.Header()
+	if err := checkFields(header.Number, header.Time, header.GasLimit, header.GasUsed, header.Difficulty); err != nil {
+		return nil, err
+	}
+	if have, want := header.Number.Uint64(), req.GetBlocknumber(); have != want {
+		return nil, mismatch(ErrInvalidNumber, have, want)
+	}
+	if have, want := block.Hash(), common.BytesToHash(req.GetBlockhash()); have != want {
+		return nil, mismatch(ErrBlockHash, have.Hex(), want.Hex())
+	}
+	if err := ValidateScsHeader(header, parent); err != nil {
+		return nil, err
+	}
+	if err := ValidateScsBody(block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
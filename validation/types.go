@@ -0,0 +1,84 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/MOACChain/MoacLib/core"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+// ValidateHeader checks a main chain header. If parent is not nil the header
+// must also be its child.
+func ValidateHeader(header, parent *types.Header) error {
+	if err := checkFields(header.Number, header.Time, header.GasLimit, header.GasUsed, header.Difficulty); err != nil {
+		return err
+	}
+	if parent == nil {
+		return validateHeader(header, nil)
+	}
+	if err := checkFields(parent.Number, parent.Time, parent.GasLimit, parent.GasUsed, parent.Difficulty); err != nil {
+		return fmt.Errorf("parent: %w", err)
+	}
+	return validateHeader(header, parent)
+}
+
+// ValidateBody checks the transaction root and uncle hash of a main chain
+// block against its body.
+func ValidateBody(block *types.Block) error {
+	header := block.Header()
+	if have, want := types.CalcUncleHash(block.Uncles()), header.UncleHash; have != want {
+		return mismatch(ErrUncleHash, have.Hex(), want.Hex())
+	}
+	if have, want := types.DeriveSha(block.Transactions()), header.TxHash; have != want {
+		return mismatch(ErrTxRoot, have.Hex(), want.Hex())
+	}
+	return nil
+}
+
+// ValidateReceipts checks the receipt root, bloom and gas used of a main
+// chain block against the receipts of its transactions.
+func ValidateReceipts(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if have, want := types.DeriveSha(receipts), header.ReceiptHash; have != want {
+		return mismatch(ErrReceiptRoot, have.Hex(), want.Hex())
+	}
+	if have, want := types.CreateBloom(receipts), header.Bloom; have != want {
+		return fmt.Errorf("%w: have %x, want %x", ErrBloom, have, want)
+	}
+	list := make([]core.ReceiptLike, len(receipts))
+	for i, r := range receipts {
+		list[i] = r
+	}
+	return validateGasUsed(header.GasUsed, list)
+}
+
+// ValidateBlock runs ValidateHeader, ValidateBody and, if receipts is not
+// nil, ValidateReceipts on a main chain block.
+func ValidateBlock(block *types.Block, parent *types.Header, receipts types.Receipts) error {
+	if err := ValidateHeader(block.Header(), parent); err != nil {
+		return err
+	}
+	if err := ValidateBody(block); err != nil {
+		return err
+	}
+	if receipts == nil {
+		return nil
+	}
+	return ValidateReceipts(block, receipts)
+}
@@ -0,0 +1,122 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package validation sanity-checks main chain and subchain blocks received
+// from the network. The checks are independent of the consensus engine: the
+// header is checked against its parent, and the roots and bloom committed to
+// in the header against the block body and receipts. Seals, difficulty and
+// state transitions are not verified.
+//
+// Every error returned wraps one of the exported rule errors, so callers can
+// tell which rule failed with errors.Is.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/MOACChain/MoacLib/core"
+	"github.com/MOACChain/MoacLib/params"
+)
+
+// AllowedFutureBlockTime is how far ahead of the local clock a block
+// timestamp may be.
+const AllowedFutureBlockTime = 15 * time.Second
+
+var (
+	ErrMissingField     = errors.New("missing header field")
+	ErrExtraDataTooLong = errors.New("extra-data too long")
+	ErrGasUsed          = errors.New("gas used exceeds gas limit")
+	ErrFutureBlock      = errors.New("block in the future")
+	ErrUnknownParent    = errors.New("unknown parent")
+	ErrInvalidNumber    = errors.New("invalid block number")
+	ErrOlderBlockTime   = errors.New("timestamp not after parent")
+	ErrTxRoot           = errors.New("transaction root mismatch")
+	ErrUncleHash        = errors.New("uncle hash mismatch")
+	ErrReceiptRoot      = errors.New("receipt root mismatch")
+	ErrBloom            = errors.New("bloom mismatch")
+	ErrReceiptGasUsed   = errors.New("receipt gas used mismatch")
+	ErrBlockHash        = errors.New("block hash mismatch")
+	ErrBlockData        = errors.New("invalid block data")
+)
+
+// mismatch wraps err with the offending values.
+func mismatch(err error, have, want interface{}) error {
+	return fmt.Errorf("%w: have %v, want %v", err, have, want)
+}
+
+// validateHeader checks the rules shared by main chain and subchain
+// headers. The big integer fields of header must be set. If parent is not
+// nil, header is checked to be its child.
+func validateHeader(header, parent core.HeaderLike) error {
+	if extra := header.GetExtra(); uint64(len(extra)) > params.MaximumExtraDataSize {
+		return mismatch(ErrExtraDataTooLong, len(extra), params.MaximumExtraDataSize)
+	}
+	if used, limit := header.GetGasUsed(), header.GetGasLimit(); used.Cmp(limit) > 0 {
+		return mismatch(ErrGasUsed, used, limit)
+	}
+	if parent == nil {
+		return nil
+	}
+	if have, want := header.GetParentHash(), parent.Hash(); have != want {
+		return mismatch(ErrUnknownParent, have.Hex(), want.Hex())
+	}
+	if have, want := header.GetNumber(), new(big.Int).Add(parent.GetNumber(), big.NewInt(1)); have.Cmp(want) != 0 {
+		return mismatch(ErrInvalidNumber, have, want)
+	}
+	if have, parentTime := header.GetTime(), parent.GetTime(); have.Cmp(parentTime) <= 0 {
+		return fmt.Errorf("%w: have %v, parent %v", ErrOlderBlockTime, have, parentTime)
+	}
+	return nil
+}
+
+// ValidateTimestamp checks that the header time is not more than
+// AllowedFutureBlockTime ahead of now.
+func ValidateTimestamp(header core.HeaderLike, now time.Time) error {
+	limit := new(big.Int).SetInt64(now.Add(AllowedFutureBlockTime).Unix())
+	if have := header.GetTime(); have.Cmp(limit) > 0 {
+		return fmt.Errorf("%w: timestamp %v, limit %v", ErrFutureBlock, have, limit)
+	}
+	return nil
+}
+
+// headerFields names the big integer header fields passed to checkFields.
+var headerFields = []string{"number", "timestamp", "gasLimit", "gasUsed", "difficulty"}
+
+// checkFields reports the first nil header field, given in the order of
+// headerFields.
+func checkFields(values ...*big.Int) error {
+	for i, v := range values {
+		if v == nil {
+			return fmt.Errorf("%w: %s", ErrMissingField, headerFields[i])
+		}
+	}
+	return nil
+}
+
+// validateGasUsed checks the gas used by a block against its receipts.
+func validateGasUsed(gasUsed *big.Int, receipts []core.ReceiptLike) error {
+	cumulative := new(big.Int)
+	if len(receipts) > 0 {
+		cumulative = receipts[len(receipts)-1].GetCumulativeGasUsed()
+	}
+	if cumulative.Cmp(gasUsed) != 0 {
+		return mismatch(ErrReceiptGasUsed, cumulative, gasUsed)
+	}
+	return nil
+}
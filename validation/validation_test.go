@@ -0,0 +1,215 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package validation
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/rlp"
+	"github.com/MOACChain/MoacLib/scs"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+var (
+	testAddr = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	testLogs = []*types.Log{{Address: testAddr, Topics: []common.Hash{{0x01}}}}
+)
+
+func testParent() *types.Header {
+	return &types.Header{
+		Number:     big.NewInt(10),
+		Time:       big.NewInt(1000),
+		Difficulty: big.NewInt(1),
+		GasLimit:   big.NewInt(9000000),
+		GasUsed:    big.NewInt(0),
+	}
+}
+
+// testBlock returns a valid child of testParent with one transaction, and
+// its receipts.
+func testBlock(t *testing.T) (*types.Block, types.Receipts) {
+	key, _ := crypto.GenerateKey()
+	tx, err := types.SignTx(types.NewTransaction(0, testAddr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil, nil), types.NewPanguSigner(big.NewInt(101)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := types.NewReceipt(nil, false, big.NewInt(21000))
+	receipt.TxHash, receipt.GasUsed, receipt.Logs = tx.Hash(), big.NewInt(21000), testLogs
+	receipts := types.Receipts{receipt}
+
+	header := &types.Header{
+		ParentHash: testParent().Hash(),
+		Number:     big.NewInt(11),
+		Time:       big.NewInt(1010),
+		Difficulty: big.NewInt(1),
+		GasLimit:   big.NewInt(9000000),
+		GasUsed:    big.NewInt(21000),
+		Extra:      []byte("moac"),
+	}
+	return types.NewBlock(header, []*types.Transaction{tx}, nil, receipts), receipts
+}
+
+func TestValidateBlock(t *testing.T) {
+	block, receipts := testBlock(t)
+	if err := ValidateBlock(block, testParent(), receipts); err != nil {
+		t.Fatalf("valid block rejected: %v", err)
+	}
+	if err := ValidateBlock(block, nil, nil); err != nil {
+		t.Fatalf("valid block without parent rejected: %v", err)
+	}
+}
+
+func TestValidateHeaderRules(t *testing.T) {
+	block, _ := testBlock(t)
+	for _, test := range []struct {
+		name   string
+		mutate func(h *types.Header)
+		err    error
+	}{
+		{"extra", func(h *types.Header) { h.Extra = make([]byte, 33) }, ErrExtraDataTooLong},
+		{"gas", func(h *types.Header) { h.GasUsed = big.NewInt(9000001) }, ErrGasUsed},
+		{"parent", func(h *types.Header) { h.ParentHash = common.Hash{1} }, ErrUnknownParent},
+		{"number", func(h *types.Header) { h.Number = big.NewInt(12) }, ErrInvalidNumber},
+		{"time", func(h *types.Header) { h.Time = big.NewInt(1000) }, ErrOlderBlockTime},
+		{"missing", func(h *types.Header) { h.Time = nil }, ErrMissingField},
+	} {
+		header := block.Header()
+		test.mutate(header)
+		if err := ValidateHeader(header, testParent()); !errors.Is(err, test.err) {
+			t.Errorf("%s: error mismatch: have %v, want %v", test.name, err, test.err)
+		}
+	}
+	parent := testParent()
+	parent.Number = nil
+	if err := ValidateHeader(block.Header(), parent); !errors.Is(err, ErrMissingField) {
+		t.Errorf("missing parent field: error mismatch: have %v, want %v", err, ErrMissingField)
+	}
+}
+
+func TestValidateBodyAndReceipts(t *testing.T) {
+	block, receipts := testBlock(t)
+	for _, test := range []struct {
+		name   string
+		mutate func(h *types.Header)
+		err    error
+	}{
+		{"txroot", func(h *types.Header) { h.TxHash = common.Hash{1} }, ErrTxRoot},
+		{"uncles", func(h *types.Header) { h.UncleHash = common.Hash{1} }, ErrUncleHash},
+		{"receipts", func(h *types.Header) { h.ReceiptHash = common.Hash{1} }, ErrReceiptRoot},
+		{"bloom", func(h *types.Header) { h.Bloom = types.Bloom{} }, ErrBloom},
+		{"gasused", func(h *types.Header) { h.GasUsed = big.NewInt(21001) }, ErrReceiptGasUsed},
+	} {
+		header := block.Header()
+		test.mutate(header)
+		bad := block.WithSeal(header)
+		if err := ValidateBlock(bad, testParent(), receipts); !errors.Is(err, test.err) {
+			t.Errorf("%s: error mismatch: have %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestValidateTimestamp(t *testing.T) {
+	header := testParent()
+	now := time.Unix(header.Time.Int64(), 0)
+	if err := ValidateTimestamp(header, now.Add(-AllowedFutureBlockTime)); err != nil {
+		t.Errorf("timestamp within bound rejected: %v", err)
+	}
+	if err := ValidateTimestamp(header, now.Add(-AllowedFutureBlockTime-time.Second)); !errors.Is(err, ErrFutureBlock) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrFutureBlock)
+	}
+}
+
+func testScsBlock(t *testing.T) (*scs.Header, *scs.Block, scs.Receipts) {
+	key, _ := crypto.GenerateKey()
+	tx, err := scs.SignTx(scs.NewTransaction(0, testAddr, big.NewInt(1), big.NewInt(21000), big.NewInt(1), 0, nil), scs.NewPanguSigner(big.NewInt(101)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := scs.NewReceipt(nil, false, big.NewInt(21000))
+	receipt.TxHash, receipt.GasUsed, receipt.Logs = tx.Hash(), big.NewInt(21000), []*scs.Log{{Address: testAddr, Topics: []common.Hash{{0x01}}}}
+	receipts := scs.Receipts{receipt}
+
+	parent := &scs.Header{Number: big.NewInt(3), Time: big.NewInt(50), Difficulty: big.NewInt(1), GasLimit: big.NewInt(9000000), GasUsed: big.NewInt(0)}
+	header := &scs.Header{
+		ParentHash: parent.Hash(),
+		Number:     big.NewInt(4),
+		Time:       big.NewInt(60),
+		Difficulty: big.NewInt(1),
+		GasLimit:   big.NewInt(9000000),
+		GasUsed:    big.NewInt(21000),
+	}
+	return parent, scs.NewBlock(header, []*scs.Transaction{tx}, nil, receipts), receipts
+}
+
+func TestValidateScsBlock(t *testing.T) {
+	parent, block, receipts := testScsBlock(t)
+	if err := ValidateScsBlock(block, parent, receipts); err != nil {
+		t.Fatalf("valid block rejected: %v", err)
+	}
+	header := block.Header()
+	header.TxHash = common.Hash{1}
+	if err := ValidateScsBlock(block.WithSeal(header), parent, receipts); !errors.Is(err, ErrTxRoot) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrTxRoot)
+	}
+	header = block.Header()
+	header.Bloom = scs.Bloom{}
+	if err := ValidateScsBlock(block.WithSeal(header), parent, receipts); !errors.Is(err, ErrBloom) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrBloom)
+	}
+}
+
+func TestValidateUploadBlock(t *testing.T) {
+	parent, block, _ := testScsBlock(t)
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.UploadBlockRequest{
+		Blocknumber: block.NumberU64(),
+		Blockhash:   block.Hash().Bytes(),
+		Blockdata:   data,
+	}
+	dec, err := ValidateUploadBlock(req, parent)
+	if err != nil {
+		t.Fatalf("valid upload rejected: %v", err)
+	}
+	if dec.Hash() != block.Hash() || len(dec.Transactions()) != 1 {
+		t.Fatalf("decoded block mismatch")
+	}
+
+	for _, test := range []struct {
+		name   string
+		mutate func(req *pb.UploadBlockRequest)
+		err    error
+	}{
+		{"data", func(req *pb.UploadBlockRequest) { req.Blockdata = []byte{0x01} }, ErrBlockData},
+		{"number", func(req *pb.UploadBlockRequest) { req.Blocknumber++ }, ErrInvalidNumber},
+		{"hash", func(req *pb.UploadBlockRequest) { req.Blockhash = common.Hash{1}.Bytes() }, ErrBlockHash},
+	} {
+		bad := *req
+		test.mutate(&bad)
+		if _, err := ValidateUploadBlock(&bad, parent); !errors.Is(err, test.err) {
+			t.Errorf("%s: error mismatch: have %v, want %v", test.name, err, test.err)
+		}
+	}
+}
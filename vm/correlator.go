@@ -0,0 +1,194 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/MOACChain/MoacLib/proto"
+)
+
+// DefaultRequestTimeout is the time a Correlator waits for the replies to
+// a request if no timeout is configured.
+const DefaultRequestTimeout = 30 * time.Second
+
+var (
+	ErrRequestTimeout   = errors.New("scs request timed out")
+	ErrRequestCanceled  = errors.New("scs request canceled")
+	ErrCorrelatorClosed = errors.New("correlator closed")
+)
+
+// NewRequestID returns a request id unique within the process, for
+// ScsPushMsg.Requestid.
+func NewRequestID() []byte {
+	return []byte(strconv.FormatUint(atomic.AddUint64(&requestId, 1), 10))
+}
+
+// Correlator sends ScsPushMsg requests and matches the replies arriving on
+// the ScsPush stream to them by Requestid, so that any number of requests
+// can wait for their replies concurrently.
+//
+// The reader of the stream hands every message to Deliver.
+type Correlator struct {
+	send    func(msg *pb.ScsPushMsg) error
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingRequest
+	closed  bool
+}
+
+type pendingRequest struct {
+	want    int
+	replies map[int]*pb.ScsPushMsg
+	err     error
+	done    chan struct{}
+}
+
+// NewCorrelator returns a correlator sending requests with send, which must
+// not wait for the replies. A zero timeout means DefaultRequestTimeout.
+func NewCorrelator(send func(msg *pb.ScsPushMsg) error, timeout time.Duration) *Correlator {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return &Correlator{
+		send:    send,
+		timeout: timeout,
+		pending: make(map[string]*pendingRequest),
+	}
+}
+
+// Request sends msg under a new request id and waits until want replies
+// arrived, the timeout expired or ctx is done. The replies are keyed by
+// arrival order. On timeout or cancellation the replies received so far are
+// returned together with ErrRequestTimeout or ErrRequestCanceled, or with
+// the error the request was failed with.
+func (c *Correlator) Request(ctx context.Context, msg *pb.ScsPushMsg, want int) (map[int]*pb.ScsPushMsg, error) {
+	if want < 1 {
+		want = 1
+	}
+	msg.Requestid = NewRequestID()
+	id := string(msg.Requestid)
+	req := &pendingRequest{want: want, replies: make(map[int]*pb.ScsPushMsg), done: make(chan struct{})}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrCorrelatorClosed
+	}
+	c.pending[id] = req
+	c.mu.Unlock()
+
+	if err := c.send(msg); err != nil {
+		c.forget(id)
+		return nil, err
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-req.done:
+	case <-timer.C:
+		err = ErrRequestTimeout
+	case <-ctx.Done():
+		err = ErrRequestCanceled
+	}
+	c.forget(id)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil && req.err != nil {
+		err = req.err
+	}
+	if err == nil && c.closed && len(req.replies) < req.want {
+		err = ErrCorrelatorClosed
+	}
+	return req.replies, err
+}
+
+func (c *Correlator) forget(id string) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Deliver hands a message received on the ScsPush stream to the request it
+// answers. It reports whether the message was a reply to a pending request;
+// requests from the SCS and late replies are left to the caller.
+func (c *Correlator) Deliver(msg *pb.ScsPushMsg) bool {
+	if msg.GetRequestflag() {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req := c.pending[string(msg.GetRequestid())]
+	if req == nil || len(req.replies) >= req.want {
+		return false
+	}
+	req.replies[len(req.replies)] = msg
+	if len(req.replies) == req.want {
+		close(req.done)
+	}
+	return true
+}
+
+// Fail ends the pending request with the given id, which returns the replies
+// received so far together with err, e.g. when the push failed after send
+// returned. It reports whether a request was waiting for replies.
+func (c *Correlator) Fail(requestid []byte, err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req := c.pending[string(requestid)]
+	if req == nil || len(req.replies) >= req.want {
+		return false
+	}
+	req.err = err
+	close(req.done)
+	delete(c.pending, string(requestid))
+	return true
+}
+
+// Pending returns the number of requests waiting for replies.
+func (c *Correlator) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// Close fails all pending and future requests with ErrCorrelatorClosed,
+// e.g. when the stream went down.
+func (c *Correlator) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for id, req := range c.pending {
+		if len(req.replies) < req.want {
+			close(req.done)
+		}
+		delete(c.pending, id)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MOACChain/MoacLib/params"
+	pb "github.com/MOACChain/MoacLib/proto"
+)
+
+// echoCorrelator answers every request after delay with one reply
+// carrying the request id in Msghash.
+func echoCorrelator(delay, timeout time.Duration) *Correlator {
+	var c *Correlator
+	c = NewCorrelator(func(msg *pb.ScsPushMsg) error {
+		reply := &pb.ScsPushMsg{Requestid: msg.Requestid, Msghash: msg.Requestid}
+		time.AfterFunc(delay, func() { c.Deliver(reply) })
+		return nil
+	}, timeout)
+	return c
+}
+
+func TestCorrelatorConcurrent(t *testing.T) {
+	c := echoCorrelator(time.Millisecond, time.Second)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := &pb.ScsPushMsg{Requestflag: true}
+			replies, err := c.Request(context.Background(), msg, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(replies) != 1 || string(replies[0].Msghash) != string(msg.Requestid) {
+				t.Errorf("reply mismatch for request %s: %v", msg.Requestid, replies)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Pending(); n != 0 {
+		t.Fatalf("%d requests left pending", n)
+	}
+}
+
+func TestCorrelatorTimeout(t *testing.T) {
+	c := echoCorrelator(time.Second, 10*time.Millisecond)
+	if _, err := c.Request(context.Background(), &pb.ScsPushMsg{}, 1); err != ErrRequestTimeout {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrRequestTimeout)
+	}
+	// Requests from the SCS and unknown replies are not consumed.
+	if c.Deliver(&pb.ScsPushMsg{Requestid: []byte("x")}) || c.Deliver(&pb.ScsPushMsg{Requestflag: true}) {
+		t.Fatal("unrelated message delivered")
+	}
+}
+
+func TestCorrelatorClose(t *testing.T) {
+	c := NewCorrelator(func(*pb.ScsPushMsg) error { return nil }, time.Second)
+	errc := make(chan error)
+	go func() {
+		_, err := c.Request(context.Background(), &pb.ScsPushMsg{}, 1)
+		errc <- err
+	}()
+	for c.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Close()
+	if err := <-errc; err != ErrCorrelatorClosed {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrCorrelatorClosed)
+	}
+	if _, err := c.Request(context.Background(), &pb.ScsPushMsg{}, 1); err != ErrCorrelatorClosed {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrCorrelatorClosed)
+	}
+}
+
+func TestCorrelatorFail(t *testing.T) {
+	failure := errors.New("push failed")
+	var c *Correlator
+	c = NewCorrelator(func(msg *pb.ScsPushMsg) error {
+		reply := &pb.ScsPushMsg{Requestid: msg.Requestid}
+		id := msg.Requestid
+		go func() {
+			c.Deliver(reply)
+			c.Fail(id, failure)
+		}()
+		return nil
+	}, time.Second)
+	replies, err := c.Request(context.Background(), &pb.ScsPushMsg{}, 2)
+	if err != failure || len(replies) != 1 {
+		t.Fatalf("result mismatch: have %v, %v, want 1 reply, %v", replies, err, failure)
+	}
+	if n := c.Pending(); n != 0 {
+		t.Fatalf("%d requests left pending", n)
+	}
+	// Failing unknown requests and closing after a failure are harmless.
+	if c.Fail([]byte("x"), failure) {
+		t.Fatal("unknown request failed")
+	}
+	c.Close()
+}
+
+func TestRequestIDNoWrap(t *testing.T) {
+	// Ids stay unique past 32 bits.
+	atomic.StoreUint64(&requestId, math.MaxUint32)
+	if have, want := string(NewRequestID()), "4294967296"; have != want {
+		t.Fatalf("request id mismatch: have %s, want %s", have, want)
+	}
+}
+
+func TestEVMCancelQuery(t *testing.T) {
+	evm := NewEVM(Context{BlockNumber: big.NewInt(0)}, nil, params.AllProtocolChanges, Config{}, nil)
+	evm.Correlator = echoCorrelator(time.Second, time.Minute)
+
+	errc := make(chan error)
+	go func() {
+		_, err := evm.pushMsg(&pb.ScsPushMsg{})
+		errc <- err
+	}()
+	for evm.Correlator.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	evm.Cancel()
+	if err := <-errc; err != ErrRequestCanceled {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrRequestCanceled)
+	}
+	if _, err := evm.pushMsg(&pb.ScsPushMsg{}); err != ErrRequestCanceled {
+		t.Fatalf("error mismatch after cancel: have %v", err)
+	}
+}
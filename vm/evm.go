@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"sync"
@@ -39,7 +40,7 @@ var (
 	// emptyCodeHash is used by create to ensure deployment is disallowed to already
 	// deployed contract addresses (relevant after the account abstraction).
 	emptyCodeHash = crypto.Keccak256Hash(nil)
-	requestId     = uint32(0)
+	requestId     = uint64(0) // last id of NewRequestID, 64 bits so it never wraps
 	// the latest EVM instance.
 	evmCache *EVM
 	evmMu    sync.RWMutex
@@ -172,6 +173,12 @@ type EVM struct {
 	// NOTE: must be set atomically
 	abort int32
 	Nr    NetworkRelayInterface
+	// Correlator, if set, is used instead of Nr to send queries to SCSs
+	// and wait for their replies.
+	Correlator *Correlator
+	// ctx is canceled by Cancel to abort queries waiting for replies.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type ContractCallStruct struct {
@@ -185,10 +192,6 @@ type ContractCallStruct struct {
 	Codehash     []byte
 }
 
-type QueryResult struct {
-	address common.Address
-}
-
 type codeAndHash struct {
 	code []byte
 	hash common.Hash
@@ -212,6 +215,7 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 		chainRules:  chainConfig.Rules(ctx.BlockNumber),
 		Nr:          nr,
 	}
+	evm.ctx, evm.cancel = context.WithCancel(context.Background())
 
 	evm.interpreter = NewInterpreter(evm, vmConfig)
 	return evm
@@ -239,10 +243,34 @@ func GetEVM() *EVM {
 	return evmCache
 }
 
-// Cancel cancels any running EVM operation. This may be called concurrently and
-// it's safe to be called multiple times.
+// Cancel cancels any running EVM operation, including queries waiting for
+// SCS replies. This may be called concurrently and it's safe to be called
+// multiple times.
 func (evm *EVM) Cancel() {
 	atomic.StoreInt32(&evm.abort, 1)
+	if evm.cancel != nil {
+		evm.cancel()
+	}
+}
+
+// pushMsg sends a query to the SCSs and waits for the replies, through the
+// Correlator if there is one and otherwise through Nr.
+func (evm *EVM) pushMsg(msg *pb.ScsPushMsg) (map[int]*pb.ScsPushMsg, error) {
+	if atomic.LoadInt32(&evm.abort) != 0 {
+		return nil, ErrRequestCanceled
+	}
+	if evm.Correlator != nil {
+		ctx := evm.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return evm.Correlator.Request(ctx, msg, 1)
+	}
+	if evm.Nr == nil {
+		return nil, ErrGRPCService
+	}
+	msg.Requestid = NewRequestID()
+	return evm.Nr.VnodePushMsg(msg)
 }
 
 // Call executes the contract associated with the addr with the given input as
@@ -352,20 +380,6 @@ func (evm *EVM) Call(
 	return ret, leftOverGas, err
 }
 
-func (evm *EVM) decodeRespond(resp map[int]*pb.ScsPushMsg) (ret []byte, leftOverGas uint64, err error) {
-	//TODO write real code to decode respond
-	ret = []byte("test")
-	leftOverGas = 0
-	err = nil
-	return ret, leftOverGas, err
-}
-
-func (evm *EVM) decodeQueryResult(result []byte) (queryResult QueryResult, err error) {
-	//TODO put the real decode in it.
-	ret := QueryResult{}
-	return ret, nil
-}
-
 // CallCode executes the contract associated with the addr with the given input
 // as parameters. It also handles any necessary value transfer required and takes
 // the necessary steps to create accounts and reverses the state in case of an
@@ -694,13 +708,10 @@ func (evm *EVM) Query(addr common.Address) (ret []byte, leftOverGas uint64, err
 	)
 
 	senderBytes, _ := rlp.EncodeToBytes(addr)
-	subChainIdBytes, _ := rlp.EncodeToBytes("testchainid")
+	subChainIdBytes := addr.Bytes()
 	contractAddrBytes, _ := rlp.EncodeToBytes(addr)
 	valBytes, _ := rlp.EncodeToBytes(0)
 
-	//TODO: make a unified requestId
-	requestId := []byte("test")
-
 	msgStruct := &ContractCallStruct{
 		Sender:       senderBytes,
 		Contractaddr: contractAddrBytes,
@@ -713,7 +724,6 @@ func (evm *EVM) Query(addr common.Address) (ret []byte, leftOverGas uint64, err
 	msgHash, _ := rlp.EncodeToBytes(msgStruct)
 
 	conReq := &pb.ScsPushMsg{
-		Requestid:   requestId,
 		Timestamp:   common.Int64ToBytes(time.Now().Unix()),
 		Requestflag: true,
 		Type:        common.IntToBytes(params.DirectCall),
@@ -725,55 +735,27 @@ func (evm *EVM) Query(addr common.Address) (ret []byte, leftOverGas uint64, err
 		Msghash:     msgHash,
 	}
 
-	r, err := evm.Nr.VnodePushMsg(conReq)
+	r, err := evm.pushMsg(conReq)
 	if err != nil {
 		log.Debug("could not call query. requestid:" + string(conReq.Requestid) + " err:" + err.Error())
-	} else {
-		log.Debug("call returned successfully.")
+		evm.StateDB.RevertToSnapshot(snapshot)
+		return nil, 0, err
 	}
+	log.Debug("call returned successfully.")
 
 	ret, cgas, err := evm.decodeRespond(r)
 	//add by frank end
-
-	// When an error was returned by the EVM or when setting the creation code
-	// above we revert to the snapshot and consume any gas remaining. Additionally
-	// when we're in pangu this also counts for code storage gas errors.
-	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+	if err == nil {
+		err = evm.ApplyFlushChanges(r)
 	}
-	evm.ApplyFlushChanges(r)
-	return ret, cgas, err
 
-}
-
-func (evm *EVM) ApplyFlushChanges(flushResult map[int]*pb.ScsPushMsg) (err error) {
-	log.Debugf("[core/vm/evm.go->ApplyFlushChanges in] flushResult=%v", flushResult)
-
-	//0) read through the detail
-	ret, _, err := evm.decodeRespond(flushResult)
-	queryResult, err := evm.decodeQueryResult(ret)
-	address := queryResult.address
-	ChgObj, err := evm.StateDB.ScsStateChgStringToObj(address, ret)
+	// When the replies can't be decoded or applied we revert to the snapshot.
 	if err != nil {
-		return err
-	}
-
-	accounts := ChgObj.Accounts
-	for _, account := range accounts {
-		//1) apply data changes
-		for key, value := range account.Storage {
-			evm.StateDB.SetState(address, common.HexToHash(key), common.HexToHash(value))
-		}
-		//2) apply transaction
-		log.Debug("ApplyFlushChanges balanceChg:" + account.BalanceChg)
-		balanceChg := new(big.Int)
-		balanceChg.SetString(account.BalanceChg, 10)
-		if balanceChg.Cmp(big.NewInt(0)) > 0 {
-			evm.StateDB.AddBalance(address, balanceChg)
-		}
+		evm.StateDB.RevertToSnapshot(snapshot)
+		return nil, 0, err
 	}
+	return ret, cgas, nil
 
-	return err
 }
 
 // ChainConfig returns the evmironment's chain configuration
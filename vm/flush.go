@@ -0,0 +1,276 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/log"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+var (
+	ErrFlushNoReply  = errors.New("flush has no reply")
+	ErrFlushConflict = errors.New("flush replies conflict")
+	ErrFlushPayload  = errors.New("invalid flush payload")
+	ErrFlushAddress  = errors.New("invalid flush account address")
+	ErrFlushNonce    = errors.New("flush nonce below state nonce")
+	ErrFlushBalance  = errors.New("flush balance below zero")
+	ErrFlushCodeHash = errors.New("flush code hash mismatch")
+	ErrFlushRoot     = errors.New("flush storage root mismatch")
+)
+
+// QueryResult is a decoded flush payload, a types.AccountChgDump, with the
+// accounts in address order.
+type QueryResult struct {
+	accounts []*flushAccount
+}
+
+// flushAccount is the post-state of an account reported by a flush. Nil
+// fields are not reported.
+//
+// A zero nonce is not reported either, as the payload cannot tell it from
+// a missing one. Nonces never decrease, so a reported zero could only be
+// valid for an account whose nonce is still zero, which it leaves as is.
+//
+// The storage root is optional as not every SCS computes it. If reported,
+// it is verified, which requires a StateDB computing storage roots.
+type flushAccount struct {
+	address    common.Address
+	balanceChg *big.Int
+	nonce      uint64
+	code       []byte
+	codeHash   *common.Hash
+	root       *common.Hash
+	storage    map[common.Hash]common.Hash
+}
+
+// storageRooter is implemented by StateDBs computing the storage root of an
+// account including uncommitted changes, like *state.StateDB.
+type storageRooter interface {
+	StorageRoot(common.Address) common.Hash
+}
+
+// decodeRespond returns the payload of the replies to a query. All SCSs
+// replying must agree on it.
+func (evm *EVM) decodeRespond(resp map[int]*pb.ScsPushMsg) (ret []byte, leftOverGas uint64, err error) {
+	keys := make([]int, 0, len(resp))
+	for i, reply := range resp {
+		if reply != nil {
+			keys = append(keys, i)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, 0, ErrFlushNoReply
+	}
+	sort.Ints(keys)
+	ret = resp[keys[0]].Msghash
+	for _, i := range keys[1:] {
+		if !bytes.Equal(resp[i].Msghash, ret) {
+			return nil, 0, fmt.Errorf("%w: replies %d and %d", ErrFlushConflict, keys[0], i)
+		}
+	}
+	return ret, 0, nil
+}
+
+// decodeQueryResult decodes and checks a flush payload without touching the
+// state.
+func (evm *EVM) decodeQueryResult(result []byte) (queryResult QueryResult, err error) {
+	var dump types.AccountChgDump
+	if err := json.Unmarshal(result, &dump); err != nil {
+		return queryResult, fmt.Errorf("%w: %v", ErrFlushPayload, err)
+	}
+	for key, account := range dump.Accounts {
+		acc, err := decodeFlushAccount(key, account)
+		if err != nil {
+			return queryResult, err
+		}
+		queryResult.accounts = append(queryResult.accounts, acc)
+	}
+	sort.Slice(queryResult.accounts, func(i, j int) bool {
+		return bytes.Compare(queryResult.accounts[i].address[:], queryResult.accounts[j].address[:]) < 0
+	})
+	return queryResult, nil
+}
+
+func decodeFlushAccount(key string, account types.AccountChgStruct) (*flushAccount, error) {
+	// The account is keyed by its address, which it may repeat.
+	if account.Address == "" {
+		account.Address = key
+	}
+	if !common.IsHexAddress(account.Address) || (key != "" && !common.IsHexAddress(key)) {
+		return nil, fmt.Errorf("%w: %q", ErrFlushAddress, account.Address)
+	}
+	acc := &flushAccount{
+		address:    common.HexToAddress(account.Address),
+		balanceChg: new(big.Int),
+		nonce:      account.Nonce,
+		storage:    make(map[common.Hash]common.Hash, len(account.Storage)),
+	}
+	if key != "" && common.HexToAddress(key) != acc.address {
+		return nil, fmt.Errorf("%w: %q keyed by %q", ErrFlushAddress, account.Address, key)
+	}
+
+	if account.BalanceChg != "" {
+		if _, ok := acc.balanceChg.SetString(account.BalanceChg, 10); !ok {
+			return nil, fmt.Errorf("%w: %x balance change %q", ErrFlushPayload, acc.address, account.BalanceChg)
+		}
+	}
+	if account.Code != "" {
+		code, err := decodeFlushHex(account.Code)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %x code: %v", ErrFlushPayload, acc.address, err)
+		}
+		acc.code = code
+	}
+	var err error
+	if acc.codeHash, err = decodeFlushHash(account.CodeHash); err != nil {
+		return nil, fmt.Errorf("%w: %x code hash: %v", ErrFlushPayload, acc.address, err)
+	}
+	if acc.code != nil && acc.codeHash != nil && crypto.Keccak256Hash(acc.code) != *acc.codeHash {
+		return nil, fmt.Errorf("%w: %x", ErrFlushCodeHash, acc.address)
+	}
+	if acc.root, err = decodeFlushHash(account.Root); err != nil {
+		return nil, fmt.Errorf("%w: %x root: %v", ErrFlushPayload, acc.address, err)
+	}
+	for k, v := range account.Storage {
+		key, err := decodeFlushHash(k)
+		if err == nil && key == nil {
+			err = errors.New("empty key")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %x storage key %q: %v", ErrFlushPayload, acc.address, k, err)
+		}
+		value, err := decodeFlushHash(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %x storage value %q: %v", ErrFlushPayload, acc.address, v, err)
+		}
+		if value == nil {
+			value = new(common.Hash)
+		}
+		acc.storage[*key] = *value
+	}
+	return acc, nil
+}
+
+// decodeFlushHex decodes hex with an optional 0x prefix, like common.FromHex
+// but failing on invalid input.
+func decodeFlushHex(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+// decodeFlushHash decodes a hash, left padded if short. The empty string is
+// nil.
+func decodeFlushHash(s string) (*common.Hash, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := decodeFlushHex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > common.HashLength {
+		return nil, fmt.Errorf("%d bytes", len(b))
+	}
+	h := common.BytesToHash(b)
+	return &h, nil
+}
+
+// ApplyFlushChanges applies the account changes reported by the replies to
+// a flush: storage, nonce, code and balance changes, which may be negative.
+// The code hash and storage root, if reported, must match the state after
+// applying the changes. On error the state is left untouched.
+func (evm *EVM) ApplyFlushChanges(flushResult map[int]*pb.ScsPushMsg) (err error) {
+	log.Debugf("[core/vm/flush.go->ApplyFlushChanges in] flushResult=%v", flushResult)
+
+	ret, _, err := evm.decodeRespond(flushResult)
+	if err != nil {
+		return err
+	}
+	queryResult, err := evm.decodeQueryResult(ret)
+	if err != nil {
+		return err
+	}
+
+	snapshot := evm.StateDB.Snapshot()
+	for _, account := range queryResult.accounts {
+		if err := evm.applyFlushAccount(account); err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+			return err
+		}
+	}
+	return nil
+}
+
+func (evm *EVM) applyFlushAccount(account *flushAccount) error {
+	db, addr := evm.StateDB, account.address
+
+	if account.nonce != 0 {
+		if nonce := db.GetNonce(addr); account.nonce < nonce {
+			return fmt.Errorf("%w: %x has %d, flush %d", ErrFlushNonce, addr, nonce, account.nonce)
+		}
+		db.SetNonce(addr, account.nonce)
+	}
+	log.Debug("ApplyFlushChanges balanceChg:" + account.balanceChg.String())
+	switch account.balanceChg.Sign() {
+	case 1:
+		db.AddBalance(addr, account.balanceChg)
+	case -1:
+		amount := new(big.Int).Neg(account.balanceChg)
+		if balance := db.GetBalance(addr); balance.Cmp(amount) < 0 {
+			return fmt.Errorf("%w: %x has %v, flush %v", ErrFlushBalance, addr, balance, account.balanceChg)
+		}
+		db.SubBalance(addr, amount)
+	}
+	if account.code != nil {
+		db.SetCode(addr, account.code)
+	}
+	if account.codeHash != nil {
+		if hash := db.GetCodeHash(addr); hash != *account.codeHash {
+			return fmt.Errorf("%w: %x has %x, flush %x", ErrFlushCodeHash, addr, hash, *account.codeHash)
+		}
+	}
+	for key, value := range account.storage {
+		db.SetState(addr, key, value)
+	}
+	if account.root != nil {
+		rooter, ok := db.(storageRooter)
+		if !ok {
+			return fmt.Errorf("%w: %x not verifiable by %T", ErrFlushRoot, addr, db)
+		}
+		if root := rooter.StorageRoot(addr); root != *account.root {
+			return fmt.Errorf("%w: %x has %x, flush %x", ErrFlushRoot, addr, root, *account.root)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/MOACChain/MoacLib/common"
+	"github.com/MOACChain/MoacLib/crypto"
+	"github.com/MOACChain/MoacLib/mcdb"
+	"github.com/MOACChain/MoacLib/params"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/state"
+	"github.com/MOACChain/MoacLib/types"
+)
+
+var (
+	flushAddrA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+	flushAddrB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+)
+
+func newFlushEVM(t *testing.T) (*EVM, *state.StateDB) {
+	db, _ := mcdb.NewMemDatabase()
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.AddBalance(flushAddrA, big.NewInt(100))
+	statedb.SetNonce(flushAddrA, 3)
+	statedb.SetState(flushAddrA, common.Hash{1}, common.Hash{1})
+	return NewEVM(Context{BlockNumber: big.NewInt(0)}, statedb, params.AllProtocolChanges, Config{}, nil), statedb
+}
+
+// flushReplies returns the replies of n SCSs agreeing on dump.
+func flushReplies(t *testing.T, dump types.AccountChgDump, n int) map[int]*pb.ScsPushMsg {
+	payload, err := json.Marshal(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies := make(map[int]*pb.ScsPushMsg)
+	for i := 0; i < n; i++ {
+		replies[i] = &pb.ScsPushMsg{Msghash: payload}
+	}
+	return replies
+}
+
+// storageRoot returns the storage root of an account with the given slots.
+func storageRoot(slots map[common.Hash]common.Hash) common.Hash {
+	db, _ := mcdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for k, v := range slots {
+		statedb.SetState(flushAddrA, k, v)
+	}
+	return statedb.StorageRoot(flushAddrA)
+}
+
+func TestApplyFlushChanges(t *testing.T) {
+	evm, statedb := newFlushEVM(t)
+	code := []byte{0x60, 0x00}
+	root := storageRoot(map[common.Hash]common.Hash{{1}: {1}, {2}: {3}})
+	dump := types.AccountChgDump{Accounts: map[string]types.AccountChgStruct{
+		common.Bytes2Hex(flushAddrA[:]): {
+			BalanceChg: "-40",
+			Nonce:      5,
+			Code:       common.Bytes2Hex(code),
+			CodeHash:   crypto.Keccak256Hash(code).Hex(),
+			Root:       common.Bytes2Hex(root[:]),
+			Storage:    map[string]string{common.Hash{2}.Hex(): common.Hash{3}.Hex()},
+		},
+		flushAddrB.Hex(): {Address: flushAddrB.Hex(), BalanceChg: "7"},
+	}}
+	if err := evm.ApplyFlushChanges(flushReplies(t, dump, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if b := statedb.GetBalance(flushAddrA); b.Int64() != 60 {
+		t.Errorf("balance mismatch: have %v, want 60", b)
+	}
+	if n := statedb.GetNonce(flushAddrA); n != 5 {
+		t.Errorf("nonce mismatch: have %d, want 5", n)
+	}
+	if c := statedb.GetCode(flushAddrA); string(c) != string(code) {
+		t.Errorf("code mismatch: have %x, want %x", c, code)
+	}
+	if v := statedb.GetState(flushAddrA, common.Hash{2}); v != (common.Hash{3}) {
+		t.Errorf("storage mismatch: have %x", v)
+	}
+	if b := statedb.GetBalance(flushAddrB); b.Int64() != 7 {
+		t.Errorf("balance mismatch: have %v, want 7", b)
+	}
+}
+
+func TestApplyFlushChangesAtomic(t *testing.T) {
+	// The valid account sorts before the failing one, so it is applied
+	// before the failure is detected while applying.
+	valid := types.AccountChgStruct{BalanceChg: "7", Storage: map[string]string{"01": "02"}}
+	tests := []struct {
+		account types.AccountChgStruct
+		want    error
+	}{
+		{types.AccountChgStruct{Root: common.Hash{9}.Hex(), Storage: map[string]string{"01": "02"}}, ErrFlushRoot},
+		{types.AccountChgStruct{BalanceChg: "-101"}, ErrFlushBalance},
+		{types.AccountChgStruct{Nonce: 2}, ErrFlushNonce},
+		{types.AccountChgStruct{CodeHash: common.Hash{9}.Hex()}, ErrFlushCodeHash},
+		{types.AccountChgStruct{Code: "6000", CodeHash: common.Hash{9}.Hex()}, ErrFlushCodeHash},
+		{types.AccountChgStruct{BalanceChg: "1.5"}, ErrFlushPayload},
+		{types.AccountChgStruct{Storage: map[string]string{"zz": "01"}}, ErrFlushPayload},
+		{types.AccountChgStruct{Address: flushAddrB.Hex()}, ErrFlushAddress},
+	}
+	for i, tt := range tests {
+		evm, statedb := newFlushEVM(t)
+		before := statedb.IntermediateRoot(false)
+		dump := types.AccountChgDump{Accounts: map[string]types.AccountChgStruct{
+			flushAddrA.Hex(): tt.account,
+			"0x0000000000000000000000000000000000000001": valid,
+		}}
+		if err := evm.ApplyFlushChanges(flushReplies(t, dump, 1)); !errors.Is(err, tt.want) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.want)
+		}
+		if root := statedb.IntermediateRoot(false); root != before {
+			t.Errorf("test %d: state changed by failed flush", i)
+		}
+	}
+}
+
+func TestApplyFlushChangesReplies(t *testing.T) {
+	evm, _ := newFlushEVM(t)
+	if err := evm.ApplyFlushChanges(map[int]*pb.ScsPushMsg{1: nil}); err != ErrFlushNoReply {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrFlushNoReply)
+	}
+	replies := flushReplies(t, types.AccountChgDump{}, 2)
+	replies[1] = &pb.ScsPushMsg{Msghash: []byte("{}")}
+	if err := evm.ApplyFlushChanges(replies); !errors.Is(err, ErrFlushConflict) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrFlushConflict)
+	}
+	if err := evm.ApplyFlushChanges(map[int]*pb.ScsPushMsg{0: {Msghash: []byte("test")}}); !errors.Is(err, ErrFlushPayload) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrFlushPayload)
+	}
+}
+
+// rootlessStateDB hides the StorageRoot method of the wrapped StateDB.
+type rootlessStateDB struct {
+	StateDB
+}
+
+func TestApplyFlushChangesUnverifiableRoot(t *testing.T) {
+	evm, statedb := newFlushEVM(t)
+	evm.StateDB = rootlessStateDB{statedb}
+	before := statedb.IntermediateRoot(false)
+
+	root := storageRoot(map[common.Hash]common.Hash{{1}: {1}})
+	dump := types.AccountChgDump{Accounts: map[string]types.AccountChgStruct{
+		flushAddrA.Hex(): {BalanceChg: "1", Root: root.Hex()},
+	}}
+	if err := evm.ApplyFlushChanges(flushReplies(t, dump, 1)); !errors.Is(err, ErrFlushRoot) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrFlushRoot)
+	}
+	if root := statedb.IntermediateRoot(false); root != before {
+		t.Error("state changed by failed flush")
+	}
+	// Without a root the changes apply, a zero nonce keeping the state nonce.
+	dump.Accounts[flushAddrA.Hex()] = types.AccountChgStruct{BalanceChg: "1"}
+	if err := evm.ApplyFlushChanges(flushReplies(t, dump, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if n := statedb.GetNonce(flushAddrA); n != 3 {
+		t.Errorf("nonce mismatch: have %d, want 3", n)
+	}
+}
@@ -6,11 +6,12 @@ import (
 
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/params"
+	"github.com/holiman/uint256"
 )
 
 func TestByteOp(t *testing.T) {
 	var (
-		env   = NewEVM(Context{}, nil, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false})
+		env   = NewEVM(Context{}, nil, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false}, nil)
 		stack = newstack()
 	)
 	tests := []struct {
@@ -29,21 +30,21 @@ func TestByteOp(t *testing.T) {
 	}
 	pc := uint64(0)
 	for _, test := range tests {
-		val := new(big.Int).SetBytes(common.Hex2Bytes(test.v))
-		th := new(big.Int).SetUint64(test.th)
+		val := new(uint256.Int).SetBytes(common.Hex2Bytes(test.v))
+		th := new(uint256.Int).SetUint64(test.th)
 		stack.push(val)
 		stack.push(th)
-		opByte(&pc, env, nil, nil, stack)
+		opByte(&pc, env, nil, nil, stack, nil, nil, nil)
 		actual := stack.pop()
-		if actual.Cmp(test.expected) != 0 {
+		if actual.ToBig().Cmp(test.expected) != 0 {
 			t.Fatalf("Expected  [%v] %v:th byte to be %v, was %v.", test.v, test.th, test.expected, actual)
 		}
 	}
 }
 
-func opBenchmark(bench *testing.B, op func(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error), args ...string) {
+func opBenchmark(bench *testing.B, op executionFunc, args ...string) {
 	var (
-		env   = NewEVM(Context{}, nil, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false})
+		env   = NewEVM(Context{}, nil, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false}, nil)
 		stack = newstack()
 	)
 	// convert args
@@ -55,10 +56,10 @@ func opBenchmark(bench *testing.B, op func(pc *uint64, evm *EVM, contract *Contr
 	bench.ResetTimer()
 	for i := 0; i < bench.N; i++ {
 		for _, arg := range byteArgs {
-			a := new(big.Int).SetBytes(arg)
+			a := new(uint256.Int).SetBytes(arg)
 			stack.push(a)
 		}
-		op(&pc, env, nil, nil, stack)
+		op(&pc, env, nil, nil, stack, nil, nil, nil)
 		stack.pop()
 	}
 }
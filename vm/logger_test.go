@@ -22,6 +22,7 @@ import (
 
 	"github.com/MOACChain/MoacLib/common"
 	"github.com/MOACChain/MoacLib/params"
+	"github.com/holiman/uint256"
 )
 
 type dummyContractRef struct {
@@ -41,21 +42,25 @@ func (d *dummyContractRef) SetBalance(*big.Int)        {}
 func (d *dummyContractRef) SetNonce(uint64)            {}
 func (d *dummyContractRef) Balance() *big.Int          { return new(big.Int) }
 
+// dummyStateDB implements the StateDB methods used by the logger, the
+// embedded nil StateDB panics on any other.
 type dummyStateDB struct {
-	NoopStateDB
+	StateDB
 	ref *dummyContractRef
 }
 
+func (dummyStateDB) GetRefund() *big.Int { return new(big.Int) }
+
 func TestStoreCapture(t *testing.T) {
 	var (
-		env      = NewEVM(Context{}, nil, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false})
+		env      = NewEVM(Context{}, dummyStateDB{}, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false}, nil)
 		logger   = NewStructLogger(nil)
 		mem      = NewMemory()
 		stack    = newstack()
 		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 0)
 	)
-	stack.push(big.NewInt(1))
-	stack.push(big.NewInt(0))
+	stack.push(uint256.NewInt().SetUint64(1))
+	stack.push(uint256.NewInt().SetUint64(0))
 
 	var index common.Hash
 
@@ -75,8 +80,8 @@ func TestStorageCapture(t *testing.T) {
 	var (
 		ref      = &dummyContractRef{}
 		contract = NewContract(ref, ref, new(big.Int), 0)
-		env      = NewEVM(Context{}, dummyStateDB{ref: ref}, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false})
-		logger   = NewStructLogger(nil)
+		env      = NewEVM(Context{}, dummyStateDB{ref: ref}, params.AllProtocolChanges, Config{EnableJit: false, ForceJit: false}, nil)
+		logger   = NewStructLogger(&LogConfig{DisableStorage: true})
 		mem      = NewMemory()
 		stack    = newstack()
 	)
@@ -86,7 +91,7 @@ func TestStorageCapture(t *testing.T) {
 		t.Error("didn't expect for each to be called")
 	}
 
-	logger = NewStructLogger(&LogConfig{FullStorage: true})
+	logger = NewStructLogger(&LogConfig{})
 	logger.CaptureState(env, 0, STOP, 0, 0, mem, stack, contract, 0, nil)
 	if !ref.calledForEach {
 		t.Error("expected for each to be called")
@@ -0,0 +1,327 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package relaytest implements a scriptable in-memory vm.NetworkRelayInterface
+// for deterministic tests of code talking to SCS subchains.
+//
+// A Relay records everything pushed through it and answers pushes with the
+// responses registered for their receiver and subchain. Recorded sessions can
+// be saved and replayed later by a relay which checks that the same messages
+// are pushed in the same order.
+package relaytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MOACChain/MoacLib/common"
+	pb "github.com/MOACChain/MoacLib/proto"
+	"github.com/MOACChain/MoacLib/vm"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	// ErrNoResponse is returned for pushes no response was registered for.
+	ErrNoResponse = errors.New("relaytest: no response registered")
+	// ErrUnexpectedPush is returned by replaying relays for pushes differing
+	// from the recorded ones.
+	ErrUnexpectedPush = errors.New("relaytest: unexpected push")
+	// ErrSessionExhausted is returned by replaying relays for pushes beyond
+	// the recorded ones.
+	ErrSessionExhausted = errors.New("relaytest: session exhausted")
+)
+
+// Response scripts the answer to pushes.
+type Response struct {
+	// Replies is returned as is, so leaving out indexes simulates partial
+	// responses. Replies without a request id get the one of the push.
+	Replies map[int]*pb.ScsPushMsg
+	Err     error
+	// Latency delays the answer, in addition to the latency of the relay.
+	Latency time.Duration
+	// Times limits the number of pushes answered, zero means unlimited.
+	Times int
+}
+
+type rule struct {
+	receiver, subchain []byte
+	resp               Response
+	used               int
+}
+
+func (r *rule) match(msg *pb.ScsPushMsg) bool {
+	if r.resp.Times > 0 && r.used >= r.resp.Times {
+		return false
+	}
+	return (r.receiver == nil || bytes.Equal(r.receiver, msg.GetReceiver())) &&
+		(r.subchain == nil || bytes.Equal(r.subchain, msg.GetSubchainid()))
+}
+
+// Notification is a recorded NotifyScs call.
+type Notification struct {
+	Address common.Address
+	Msg     []byte
+	Hash    common.Hash
+	Block   *big.Int
+}
+
+// Relay is an in-memory vm.NetworkRelayInterface. It is safe for concurrent
+// use.
+type Relay struct {
+	mu          sync.Mutex
+	latency     time.Duration
+	rules       []*rule
+	replay      *Session
+	session     Session
+	notified    []Notification
+	whiteStates []uint64
+}
+
+var _ vm.NetworkRelayInterface = (*Relay)(nil)
+
+// New returns a relay without any registered responses.
+func New() *Relay {
+	return new(Relay)
+}
+
+// NewReplay returns a relay answering the pushes of session in order with
+// the recorded replies and errors. Pushes must equal the recorded ones
+// except for request ids and timestamps.
+//
+// Errors are recorded by their message only. The sentinel errors of this
+// package and of vm.Correlator are replayed as themselves, any other error
+// as a new one with the same message, which errors.Is does not match.
+func NewReplay(session *Session) *Relay {
+	return &Relay{replay: session}
+}
+
+// SetLatency delays every answer by d.
+func (r *Relay) SetLatency(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latency = d
+}
+
+// On registers resp for pushes to receiver on subchain, nil matching any.
+// Responses are tried in registration order.
+func (r *Relay) On(receiver, subchain []byte, resp Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, &rule{receiver: receiver, subchain: subchain, resp: resp})
+}
+
+// VnodePushMsg implements vm.NetworkRelayInterface.
+func (r *Relay) VnodePushMsg(msg *pb.ScsPushMsg) (map[int]*pb.ScsPushMsg, error) {
+	r.mu.Lock()
+	index := len(r.session.Exchanges)
+	resp := r.respond(index, msg)
+	latency := r.latency + resp.Latency
+	r.session.Exchanges = append(r.session.Exchanges, newExchange(msg, resp))
+	r.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	return copyReplies(msg, resp.Replies), resp.Err
+}
+
+// respond returns the answer to the index'th push msg.
+func (r *Relay) respond(index int, msg *pb.ScsPushMsg) Response {
+	if r.replay != nil {
+		if index >= len(r.replay.Exchanges) {
+			return Response{Err: ErrSessionExhausted}
+		}
+		ex := r.replay.Exchanges[index]
+		if !samePush(ex.Request, msg) {
+			return Response{Err: fmt.Errorf("%w: push %d", ErrUnexpectedPush, index)}
+		}
+		return ex.response()
+	}
+	for _, rule := range r.rules {
+		if rule.match(msg) {
+			rule.used++
+			return rule.resp
+		}
+	}
+	return Response{Err: ErrNoResponse}
+}
+
+func samePush(a, b *pb.ScsPushMsg) bool {
+	a, b = proto.Clone(a).(*pb.ScsPushMsg), proto.Clone(b).(*pb.ScsPushMsg)
+	a.Requestid, b.Requestid = nil, nil
+	a.Timestamp, b.Timestamp = nil, nil
+	return proto.Equal(a, b)
+}
+
+func copyReplies(msg *pb.ScsPushMsg, replies map[int]*pb.ScsPushMsg) map[int]*pb.ScsPushMsg {
+	if replies == nil {
+		return nil
+	}
+	out := make(map[int]*pb.ScsPushMsg, len(replies))
+	for i, reply := range replies {
+		reply = proto.Clone(reply).(*pb.ScsPushMsg)
+		if len(reply.Requestid) == 0 {
+			reply.Requestid = msg.GetRequestid()
+		}
+		out[i] = reply
+	}
+	return out
+}
+
+// NotifyScs implements vm.NetworkRelayInterface.
+func (r *Relay) NotifyScs(address common.Address, msg []byte, hash common.Hash, block *big.Int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := Notification{Address: address, Msg: common.CopyBytes(msg), Hash: hash}
+	if block != nil {
+		n.Block = new(big.Int).Set(block)
+	}
+	r.notified = append(r.notified, n)
+}
+
+// UpdateWhiteState implements vm.NetworkRelayInterface.
+func (r *Relay) UpdateWhiteState(block uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.whiteStates = append(r.whiteStates, block)
+}
+
+// Pushes returns the messages pushed so far.
+func (r *Relay) Pushes() []*pb.ScsPushMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msgs := make([]*pb.ScsPushMsg, len(r.session.Exchanges))
+	for i, ex := range r.session.Exchanges {
+		msgs[i] = ex.Request
+	}
+	return msgs
+}
+
+// Notifications returns the NotifyScs calls so far.
+func (r *Relay) Notifications() []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Notification(nil), r.notified...)
+}
+
+// WhiteStates returns the blocks passed to UpdateWhiteState so far.
+func (r *Relay) WhiteStates() []uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint64(nil), r.whiteStates...)
+}
+
+// Session returns the pushes so far together with their answers.
+func (r *Relay) Session() *Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Session{Exchanges: append([]Exchange(nil), r.session.Exchanges...)}
+}
+
+// NewCorrelator returns a vm.Correlator sending through the relay. Replies
+// are delivered asynchronously after the latency; pushes failing with an
+// error fail their request with it.
+func (r *Relay) NewCorrelator(timeout time.Duration) *vm.Correlator {
+	var c *vm.Correlator
+	c = vm.NewCorrelator(func(msg *pb.ScsPushMsg) error {
+		msg = proto.Clone(msg).(*pb.ScsPushMsg)
+		go func() {
+			replies, err := r.VnodePushMsg(msg)
+			if err != nil {
+				c.Fail(msg.Requestid, err)
+				return
+			}
+			keys := make([]int, 0, len(replies))
+			for i := range replies {
+				keys = append(keys, i)
+			}
+			sort.Ints(keys)
+			for _, i := range keys {
+				replies[i].Requestflag = false
+				c.Deliver(replies[i])
+			}
+		}()
+		return nil
+	}, timeout)
+	return c
+}
+
+// Exchange is a recorded push and its answer.
+type Exchange struct {
+	Request *pb.ScsPushMsg         `json:"request"`
+	Replies map[int]*pb.ScsPushMsg `json:"replies,omitempty"`
+	Err     string                 `json:"err,omitempty"`
+}
+
+func newExchange(msg *pb.ScsPushMsg, resp Response) Exchange {
+	// Replies are recorded without the request id filled in, so that replays
+	// answer with the ids of their pushes.
+	ex := Exchange{Request: proto.Clone(msg).(*pb.ScsPushMsg), Replies: copyReplies(new(pb.ScsPushMsg), resp.Replies)}
+	if resp.Err != nil {
+		ex.Err = resp.Err.Error()
+	}
+	return ex
+}
+
+// replayErrors are the errors replays return as themselves rather than as
+// new errors with the recorded message.
+var replayErrors = []error{
+	ErrNoResponse,
+	vm.ErrRequestTimeout,
+	vm.ErrRequestCanceled,
+	vm.ErrCorrelatorClosed,
+}
+
+func (ex Exchange) response() Response {
+	resp := Response{Replies: ex.Replies}
+	if ex.Err != "" {
+		resp.Err = errors.New(ex.Err)
+		for _, err := range replayErrors {
+			if err.Error() == ex.Err {
+				resp.Err = err
+			}
+		}
+	}
+	return resp
+}
+
+// Session is a recorded sequence of pushes.
+type Session struct {
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// Save writes the session to w as JSON.
+func (s *Session) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// LoadSession reads a session written by Save.
+func LoadSession(r io.Reader) (*Session, error) {
+	s := new(Session)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
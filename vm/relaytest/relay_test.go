@@ -0,0 +1,141 @@
+// Copyright 2017  The MOAC Foundation
+// This file is part of the MOAC library.
+//
+// The MOAC library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MOAC library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MOAC library. If not, see <http://www.gnu.org/licenses/>.
+
+package relaytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MOACChain/MoacLib/common"
+	pb "github.com/MOACChain/MoacLib/proto"
+)
+
+var (
+	contractA = []byte("contract a")
+	contractB = []byte("contract b")
+	subchain  = []byte("subchain")
+)
+
+func push(receiver []byte, id string) *pb.ScsPushMsg {
+	return &pb.ScsPushMsg{Requestid: []byte(id), Requestflag: true, Receiver: receiver, Subchainid: subchain}
+}
+
+func TestRelayResponses(t *testing.T) {
+	relay := New()
+	failure := errors.New("scs down")
+	relay.On(contractA, subchain, Response{
+		Replies: map[int]*pb.ScsPushMsg{0: {Msghash: []byte("first")}},
+		Times:   1,
+	})
+	relay.On(contractA, nil, Response{
+		Replies: map[int]*pb.ScsPushMsg{0: {Msghash: []byte("a")}, 2: {Msghash: []byte("c")}},
+	})
+	relay.On(contractB, nil, Response{Err: failure, Latency: 20 * time.Millisecond})
+
+	replies, err := relay.VnodePushMsg(push(contractA, "1"))
+	if err != nil || len(replies) != 1 || string(replies[0].Msghash) != "first" || string(replies[0].Requestid) != "1" {
+		t.Fatalf("first reply mismatch: %v, %v", replies, err)
+	}
+	// Partial responses are passed on as is.
+	replies, err = relay.VnodePushMsg(push(contractA, "2"))
+	if err != nil || len(replies) != 2 || replies[1] != nil || string(replies[2].Msghash) != "c" {
+		t.Fatalf("partial reply mismatch: %v, %v", replies, err)
+	}
+	start := time.Now()
+	if _, err := relay.VnodePushMsg(push(contractB, "3")); err != failure {
+		t.Fatalf("error mismatch: have %v, want %v", err, failure)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("latency not simulated")
+	}
+	if _, err := relay.VnodePushMsg(push([]byte("other"), "4")); err != ErrNoResponse {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoResponse)
+	}
+
+	pushes := relay.Pushes()
+	if len(pushes) != 4 || string(pushes[2].Requestid) != "3" {
+		t.Fatalf("recorded pushes mismatch: %v", pushes)
+	}
+	relay.NotifyScs(common.Address{1}, []byte("msg"), common.Hash{2}, big.NewInt(3))
+	relay.UpdateWhiteState(5)
+	if n := relay.Notifications(); len(n) != 1 || n[0].Block.Int64() != 3 || string(n[0].Msg) != "msg" {
+		t.Fatalf("notifications mismatch: %v", n)
+	}
+	if w := relay.WhiteStates(); len(w) != 1 || w[0] != 5 {
+		t.Fatalf("white states mismatch: %v", w)
+	}
+}
+
+func TestRelayReplay(t *testing.T) {
+	relay := New()
+	relay.On(contractA, nil, Response{Replies: map[int]*pb.ScsPushMsg{0: {Msghash: []byte("a")}}})
+	relay.On(contractB, nil, Response{Err: errors.New("scs down")})
+	relay.VnodePushMsg(push(contractA, "1"))
+	relay.VnodePushMsg(push(contractB, "2"))
+	relay.VnodePushMsg(push(subchain, "3"))
+
+	var buf bytes.Buffer
+	if err := relay.Session().Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	session, err := LoadSession(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay := NewReplay(session)
+	replies, err := replay.VnodePushMsg(push(contractA, "7"))
+	if err != nil || string(replies[0].Msghash) != "a" || string(replies[0].Requestid) != "7" {
+		t.Fatalf("replayed reply mismatch: %v, %v", replies, err)
+	}
+	if _, err := replay.VnodePushMsg(push(contractB, "8")); err == nil || err.Error() != "scs down" {
+		t.Fatalf("replayed error mismatch: %v", err)
+	}
+	// Sentinel errors are replayed as themselves.
+	if _, err := replay.VnodePushMsg(push(subchain, "9")); err != ErrNoResponse {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoResponse)
+	}
+	if _, err := replay.VnodePushMsg(push(contractA, "10")); err != ErrSessionExhausted {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSessionExhausted)
+	}
+
+	diverging := NewReplay(session)
+	if _, err := diverging.VnodePushMsg(push(contractB, "1")); !errors.Is(err, ErrUnexpectedPush) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrUnexpectedPush)
+	}
+}
+
+func TestRelayCorrelator(t *testing.T) {
+	relay := New()
+	relay.SetLatency(5 * time.Millisecond)
+	relay.On(contractA, nil, Response{Replies: map[int]*pb.ScsPushMsg{0: {Msghash: []byte("a")}, 1: {Msghash: []byte("b")}}})
+	c := relay.NewCorrelator(time.Second)
+
+	replies, err := c.Request(context.Background(), push(contractA, ""), 2)
+	if err != nil || len(replies) != 2 || string(replies[1].Msghash) != "b" {
+		t.Fatalf("replies mismatch: %v, %v", replies, err)
+	}
+	// Failing pushes fail their request rather than timing out.
+	c = relay.NewCorrelator(time.Minute)
+	if _, err := c.Request(context.Background(), push(contractB, ""), 1); err != ErrNoResponse {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoResponse)
+	}
+}